@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"effective-mobile-test/internal/config"
+	"effective-mobile-test/internal/db/postgresql"
+	"effective-mobile-test/internal/usecases"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+)
+
+// Detects the language of the lyrics stored before the detection ran on every
+// write. Songs whose language changes are touched like any update, so they
+// show up in the sync feed and the song events.
+func main() {
+	var (
+		batch int
+		all   bool
+	)
+
+	flag.IntVar(&batch, "batch", 500, "songs per batch")
+	flag.BoolVar(&all, "all", false, "detect the language of every song again, e.g. after the detector changed")
+
+	cfg := config.MustLoad()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	db, err := postgres.New(log, cfg.DbPath)
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	updated, err := usecases.NewLanguages(postgres.NewSongLibrary(db, cfg.TenantRls), log).Backfill(ctx, batch, all)
+	if err != nil {
+		log.Error("backfill stopped", slog.Int("updated", updated), slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	log.Info("backfill finished", slog.Int("updated", updated))
+}
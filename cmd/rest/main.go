@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
 	"effective-mobile-test/internal/config"
 	"effective-mobile-test/internal/db/postgresql"
 	"effective-mobile-test/internal/http/handlers/v1"
+	"effective-mobile-test/internal/http/middlewares/idempotency"
+	"effective-mobile-test/internal/jwks"
+	"effective-mobile-test/internal/lyrics"
 	"effective-mobile-test/internal/usecases"
+	"effective-mobile-test/internal/webhook"
+	"errors"
 	"github.com/go-chi/chi/v5"
 	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 // @title REST API EXAMPLE
@@ -17,6 +26,15 @@ import (
 // @host localhost:25565
 // @BasePath /
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Session token from POST /v1/sessions or a JWT issued by the configured identity provider, as "Bearer <token>"
+
 const (
 	envLocal = "local"
 	envProd  = "prod"
@@ -27,6 +45,11 @@ func main() {
 
 	log := setupLogger(cfg.Env)
 
+	// The background jobs stop on SIGINT or SIGTERM, except for the plays,
+	// which are flushed once the server stops taking them.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	db, err := postgres.New(log, cfg.DbPath)
 	if err != nil {
 		panic(err)
@@ -34,9 +57,105 @@ func main() {
 
 	r := chi.NewRouter()
 
-	slp := postgres.NewSongLibrary(db)
-	sluc := usecases.NewSongLibrary(slp, log)
-	handlers.NewRouter(log, r, sluc)
+	rluc := usecases.NewRoles(postgres.NewRoles(db), cfg.DefaultRole, log)
+
+	tnuc := usecases.NewTenants(postgres.NewTenants(db), rluc, log)
+
+	slp := postgres.NewSongLibrary(db, cfg.TenantRls)
+	fvp := postgres.NewFavorites(db)
+	pyuc := usecases.NewPlays(postgres.NewPlays(db), slp, rluc, usecases.PlaysOptions{
+		FlushInterval:   cfg.Plays.FlushInterval,
+		MaxBatch:        cfg.Plays.MaxBatch,
+		HourlyRetention: cfg.Plays.HourlyRetention,
+		Windows:         cfg.Plays.TrendingWindows,
+		DefaultWindow:   cfg.Plays.TrendingDefaultWindow,
+	}, log)
+
+	playsCtx, stopPlays := context.WithCancel(context.Background())
+	playsDone := make(chan struct{})
+	go func() {
+		defer close(playsDone)
+		pyuc.Run(playsCtx)
+	}()
+
+	wordlist, err := lyrics.LoadWordlist(cfg.ExplicitWordlist)
+	if err != nil {
+		panic(err)
+	}
+
+	tgp := postgres.NewTags(db)
+	crp := postgres.NewCredits(db)
+	rsp := postgres.NewRelations(db)
+	sluc := usecases.NewSongLibrary(slp, fvp, tgp, crp, rsp, pyuc, rluc, wordlist, log)
+	fvuc := usecases.NewFavorites(fvp, slp, rluc, log)
+	pluc := usecases.NewPlaylists(postgres.NewPlaylists(db), slp, rluc, log)
+	rtuc := usecases.NewRatings(postgres.NewRatings(db), slp, rluc, cfg.ReviewBlocklist, log)
+	tguc := usecases.NewTags(tgp, slp, rluc, log)
+	cruc := usecases.NewCredits(crp, slp, rluc, log)
+	rsuc := usecases.NewRelations(rsp, slp, rluc, log)
+	dpuc := usecases.NewDuplicates(postgres.NewDuplicates(db), slp, rluc, wordlist, log)
+	stuc := usecases.NewStats(slp, sluc, rluc, cfg.StatsCacheTTL, log)
+	lyuc := usecases.NewLyrics(postgres.NewLyrics(db), slp, rluc, usecases.LyricsOptions{
+		IndexInterval: cfg.Lyrics.IndexInterval,
+		IndexBatch:    cfg.Lyrics.IndexBatch,
+	}, log)
+	go lyuc.Run(ctx)
+	exuc := usecases.NewExplicit(slp, slp, rluc, wordlist, log)
+	go exuc.Rescan(ctx)
+
+	sep := postgres.NewSongEvents(db, cfg.DbPath)
+	seuc := usecases.NewSongEvents(slp, sep, log)
+	go func() {
+		if err := seuc.Run(ctx); err != nil {
+			log.Error("song events stopped", slog.String("error", err.Error()))
+		}
+	}()
+
+	whp := postgres.NewWebhooks(db)
+	whuc := usecases.NewWebhooks(whp, seuc, webhook.NewSender(cfg.Webhooks.Timeout, cfg.Webhooks.AllowInsecure), usecases.WebhooksOptions{
+		Timeout:       cfg.Webhooks.Timeout,
+		MaxAttempts:   cfg.Webhooks.MaxAttempts,
+		Backoff:       cfg.Webhooks.Backoff,
+		PollInterval:  cfg.Webhooks.PollInterval,
+		AllowInsecure: cfg.Webhooks.AllowInsecure,
+	}, log)
+	go whuc.Run(ctx)
+
+	akuc := usecases.NewApiKeys(postgres.NewApiKeys(db), log)
+	if cfg.BootstrapApiKey != "" {
+		subject, err := akuc.Bootstrap(cfg.BootstrapApiKey)
+		if err != nil {
+			panic(err)
+		}
+
+		if err = rluc.Bootstrap(subject); err != nil {
+			panic(err)
+		}
+	}
+
+	var tkuc *usecases.Tokens
+	if cfg.Jwt.JwksSource != "" {
+		keySet := jwks.New(cfg.Jwt.JwksSource, cfg.Jwt.JwksRefresh, log)
+		if err = keySet.Load(); err != nil {
+			panic(err)
+		}
+
+		tkuc = usecases.NewTokens(keySet, usecases.TokensOptions{
+			Issuer:      cfg.Jwt.Issuer,
+			Audience:    cfg.Jwt.Audience,
+			Leeway:      cfg.Jwt.Leeway,
+			ScopesClaim: cfg.Jwt.ScopesClaim,
+			TenantClaim: cfg.Jwt.TenantClaim,
+		}, log)
+	}
+
+	usuc := usecases.NewUsers(postgres.NewUsers(db), cfg.SessionTTL, log)
+	go usuc.RunCleanup(ctx, time.Hour)
+
+	idem := idempotency.New(postgres.NewIdempotencyKeys(db), cfg.IdempotencyTTL, cfg.IdempotencyMaxBody, log)
+	go idem.RunCleanup(ctx, time.Hour)
+
+	handlers.NewRouter(log, r, cfg, sluc, seuc, whuc, akuc, tkuc, rluc, tnuc, usuc, fvuc, pluc, rtuc, pyuc, tguc, cruc, rsuc, dpuc, stuc, lyuc, exuc, idem)
 
 	server := &http.Server{
 		Addr:         cfg.HttpAddr,
@@ -49,10 +168,29 @@ func main() {
 		slog.String("address", cfg.HttpAddr),
 	)
 
-	err = server.ListenAndServe()
-	if err != nil {
-		panic(err)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			panic(err)
+		}
+	}()
+
+	<-ctx.Done()
+
+	log.Info("shutting down http server")
+
+	// The event streams end along with the song events, so that they don't
+	// hold the shutdown up.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HttpShutdownTimeout)
+	defer cancel()
+
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to shut down http server", slog.String("error", err.Error()))
 	}
+
+	stopPlays()
+	<-playsDone
+
+	log.Info("stopped")
 }
 
 func setupLogger(env string) *slog.Logger {
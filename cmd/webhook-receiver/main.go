@@ -0,0 +1,59 @@
+package main
+
+import (
+	"effective-mobile-test/internal/webhook"
+	"flag"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+// A local endpoint to point webhooks at while developing: it verifies the
+// signature of every delivery and logs it.
+func main() {
+	var (
+		addr   string
+		secret string
+		status int
+	)
+
+	flag.StringVar(&addr, "addr", "localhost:25566", "address to listen on")
+	flag.StringVar(&secret, "secret", "", "webhook secret, signatures are not checked when empty")
+	flag.IntVar(&status, "status", http.StatusNoContent, "status to answer with, useful to try the retries")
+	flag.Parse()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Error("failed to read body", slog.String("error", err.Error()))
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		log := log.With(
+			slog.String("delivery", r.Header.Get(webhook.HeaderDelivery)),
+			slog.String("event", r.Header.Get(webhook.HeaderEvent)),
+		)
+
+		if secret != "" {
+			if err = webhook.Verify(secret, r.Header, body, 5*time.Minute); err != nil {
+				log.Error("rejected delivery", slog.String("error", err.Error()))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+
+		log.Info("received delivery", slog.String("payload", string(body)))
+		w.WriteHeader(status)
+	})
+
+	log.Info("starting webhook receiver", slog.String("address", addr))
+
+	if err := http.ListenAndServe(addr, nil); err != nil {
+		panic(err)
+	}
+}
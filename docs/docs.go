@@ -17,12 +17,23 @@ const docTemplate = `{
     "paths": {
         "/info": {
             "get": {
-                "description": "Get the song info",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the song info along with the originals it derives from and its own versions",
                 "consumes": [
                     "application/json"
                 ],
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "application/xml",
+                    "text/csv",
+                    "application/yaml"
                 ],
                 "tags": [
                     "song-library"
@@ -30,6 +41,12 @@ const docTemplate = `{
                 "summary": "Song Library",
                 "operationId": "get-song-info",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
                     {
                         "type": "string",
                         "description": "group name",
@@ -43,6 +60,18 @@ const docTemplate = `{
                         "name": "song",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "entity tag of the cached response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "date of the cached response",
+                        "name": "If-Modified-Since",
+                        "in": "header"
                     }
                 ],
                 "responses": {
@@ -50,120 +79,146 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/dto.GetSongResponse"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "caching policy of the route"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "strong entity tag of the response"
+                            }
                         }
                     },
+                    "304": {
+                        "description": "not modified"
+                    },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "malformed-request",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "not-acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "internal-error",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "default": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/v1/songs": {
+        "/problems": {
             "get": {
-                "description": "Get a list of songs",
-                "consumes": [
+                "description": "Get the catalogue of the codes the errors are reported with",
+                "produces": [
                     "application/json"
                 ],
+                "tags": [
+                    "problems"
+                ],
+                "summary": "Problems",
+                "operationId": "get-problems",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/response.ProblemType"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/problems/{code}": {
+            "get": {
+                "description": "Get the problem type the type URI of an error refers to",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "song-library"
+                    "problems"
                 ],
-                "summary": "Song Library",
-                "operationId": "get-songs-list",
+                "summary": "Problems",
+                "operationId": "get-problem",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "paginate through the songs list",
-                        "name": "offset",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "sets the list limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "group name",
-                        "name": "group",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": " song name",
-                        "name": "song",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "format": "date",
-                        "description": "release date",
-                        "name": "releaseDate",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
-                        "description": "link",
-                        "name": "link",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "lyrics",
-                        "name": "text",
-                        "in": "query"
+                        "description": "problem code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/dto.GetSongsListResponse"
-                            }
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.ProblemType"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "not-found",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "default": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update a specific song",
+            }
+        },
+        "/v1/favorites": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the favorite songs of the signed in user, the latest added first",
                 "consumes": [
                     "application/json"
                 ],
@@ -171,50 +226,67 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "song-library"
+                    "favorites"
                 ],
-                "summary": "Song Library",
-                "operationId": "update-song",
+                "summary": "Favorites",
+                "operationId": "get-favorites-list",
                 "parameters": [
                     {
-                        "description": "song info and the fields to update",
-                        "name": "input",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.UpdateSongRequest"
-                        }
+                        "type": "integer",
+                        "description": "paginate through the favorites list",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.FavoriteSongResponse"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "internal-error",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "default": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a song",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add the song to the favorites of the signed in user",
                 "consumes": [
                     "application/json"
                 ],
@@ -222,10 +294,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "song-library"
+                    "favorites"
                 ],
-                "summary": "Song Library",
-                "operationId": "create-song",
+                "summary": "Favorites",
+                "operationId": "add-favorite",
                 "parameters": [
                     {
                         "description": "song info",
@@ -233,39 +305,68 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.CreateSongRequest"
+                            "$ref": "#/definitions/dto.FavoriteRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/response.Response"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "malformed-request",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "internal-error",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "default": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete a specific song",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the song from the favorites of the signed in user",
                 "consumes": [
                     "application/json"
                 ],
@@ -273,10 +374,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "song-library"
+                    "favorites"
                 ],
-                "summary": "Song Library",
-                "operationId": "delete-song",
+                "summary": "Favorites",
+                "operationId": "remove-favorite",
                 "parameters": [
                     {
                         "description": "song info",
@@ -284,7 +385,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.DeleteSongRequest"
+                            "$ref": "#/definitions/dto.FavoriteRequest"
                         }
                     }
                 ],
@@ -296,29 +397,61 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "malformed-request",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "internal-error",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "default": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/v1/songs/text": {
+        "/v1/keys": {
             "get": {
-                "description": "Get the lyrics of the song",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list of the API keys of the tenant",
                 "consumes": [
                     "application/json"
                 ],
@@ -326,78 +459,8492 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "song-library"
+                    "api-keys"
                 ],
-                "summary": "Song Library",
-                "operationId": "get-song-lyrics",
+                "summary": "API keys",
+                "operationId": "get-api-keys-list",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "paginate through the song lyrics paragraphs",
+                        "description": "paginate through the api keys list",
                         "name": "offset",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "group name",
-                        "name": "group",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "song name",
-                        "name": "song",
-                        "in": "query",
-                        "required": true
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.GetTextResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.ApiKeyResponse"
+                            }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "500": {
-                        "description": "Internal Server Error",
+                        "description": "internal-error",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "default": {
                         "description": "",
                         "schema": {
-                            "$ref": "#/definitions/response.Response"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "dto.CreateSongRequest": {
-            "type": "object",
-            "required": [
-                "group",
-                "song"
-            ],
-            "properties": {
-                "group": {
-                    "type": "string"
-                },
-                "song": {
-                    "type": "string"
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Issue an API key, the key itself is only returned once. Keys belong to the tenant of the request,\ncallers bound to no tenant may leave tenantId out to issue keys bound to none. The scopes are limited\nto the ones of the caller",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "API keys",
+                "operationId": "create-api-key",
+                "parameters": [
+                    {
+                        "description": "api key info",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateApiKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateApiKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke a specific API key",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "api-keys"
+                ],
+                "summary": "API keys",
+                "operationId": "revoke-api-key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "api key id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/people": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the people of the tenant by name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credits"
+                ],
+                "summary": "Credits",
+                "operationId": "get-people-list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the people whose name starts with it, case insensitive",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "paginate through the people list",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.PersonResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a person to the tenant",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credits"
+                ],
+                "summary": "Credits",
+                "operationId": "create-person",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "person info",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PersonRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PersonResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/people/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rename the person on every song they are credited on",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credits"
+                ],
+                "summary": "Credits",
+                "operationId": "update-person",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "person id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the new name",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PersonRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete the person, the ones still credited on a song can't be deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credits"
+                ],
+                "summary": "Credits",
+                "operationId": "delete-person",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "person id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/playlists": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the playlists of the tenant: the public ones, your own and, with playlists:manage, the private ones of the tenant",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Playlists",
+                "operationId": "get-playlists-list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "paginate through the playlists list",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.PlaylistResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a playlist, owned by the signed in user or, for the other credentials with playlists:manage, by the tenant",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Playlists",
+                "operationId": "create-playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "playlist info, private by default",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreatePlaylistRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PlaylistResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/playlists/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a specific playlist with its entries in order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Playlists",
+                "operationId": "get-playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "playlist id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PlaylistWithEntriesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a specific playlist, omitted fields are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Playlists",
+                "operationId": "update-playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "playlist id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the fields to update",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdatePlaylistRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, nothing-to-update, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a specific playlist with its entries",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Playlists",
+                "operationId": "delete-playlist",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "playlist id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/playlists/{id}/entries": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add the song to the playlist at the position, at the end without one. When the playlist doesn't allow duplicates and already has the song, its existing entry is returned with 200",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Playlists",
+                "operationId": "add-playlist-entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "playlist id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "song info and the 0-based position",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AddPlaylistEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PlaylistEntryResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PlaylistEntryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/playlists/{id}/entries/{entryId}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move the entry to the 0-based position, to the end when it's past the last entry",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Playlists",
+                "operationId": "move-playlist-entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "playlist id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "entry id",
+                        "name": "entryId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the new position",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MovePlaylistEntryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the entry from the playlist",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "playlists"
+                ],
+                "summary": "Playlists",
+                "operationId": "remove-playlist-entry",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "playlist id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "entry id",
+                        "name": "entryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/ratings": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the ratings of the signed in user, the latest changed first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Ratings",
+                "operationId": "get-ratings-list",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "paginate through the ratings list",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.RatingResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update the rating of the song, omitted fields are kept. A changed review is moderated again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Ratings",
+                "operationId": "update-rating",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "the fields to update, an empty review removes it",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateRatingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, nothing-to-update, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rate the song from 1 to 5 with an optional review. Reviews with blocked words are held for moderation",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Ratings",
+                "operationId": "create-rating",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "rating info",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RateSongRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RatingResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete the rating of the song with its review",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Ratings",
+                "operationId": "delete-rating",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "song info",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.DeleteRatingRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/reviews": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the published reviews of the song, the latest changed first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Reviews",
+                "operationId": "get-reviews-list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "paginate through the reviews list",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.ReviewResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/reviews/pending": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the reviews of the tenant awaiting moderation, requires reviews:moderate",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Reviews",
+                "operationId": "get-pending-reviews-list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "paginate through the reviews list",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.ReviewResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/reviews/{id}/status": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Publish or reject the review, requires reviews:moderate",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "ratings"
+                ],
+                "summary": "Reviews",
+                "operationId": "moderate-review",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "review id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the decision",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ModerateReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/roles": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list of roles",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roles"
+                ],
+                "summary": "Roles",
+                "operationId": "get-roles-list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.RoleResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a custom role",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roles"
+                ],
+                "summary": "Roles",
+                "operationId": "create-role",
+                "parameters": [
+                    {
+                        "description": "role info",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RoleResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/roles/{name}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a custom role, omitted fields are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roles"
+                ],
+                "summary": "Roles",
+                "operationId": "update-role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "role name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the fields to update",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "built-in",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, nothing-to-update",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a custom role, unassigning it from every subject",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roles"
+                ],
+                "summary": "Roles",
+                "operationId": "delete-role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "role name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "built-in",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "nothing-to-update",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/sessions": {
+            "post": {
+                "description": "Sign in, the token goes to the Authorization: Bearer header",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Sessions",
+                "operationId": "create-session",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "credentials",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateSessionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SessionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sign out, ending the session of the request",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Sessions",
+                "operationId": "delete-session",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list of songs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "text/csv",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "song-library"
+                ],
+                "summary": "Song Library",
+                "operationId": "get-songs-list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "paginate through the songs list",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": " song name",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "release date",
+                        "name": "releaseDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "link",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "lyrics",
+                        "name": "text",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "only songs updated at or after this moment",
+                        "name": "updatedSince",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "rating",
+                            "-rating",
+                            "ratingCount",
+                            "-ratingCount"
+                        ],
+                        "type": "string",
+                        "description": "order by the average rating or the number of ratings, descending with -",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "tag names, each may list alternatives separated by commas, genres include their subgenres",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "whether songs need all the tags or any of them, any by default",
+                        "name": "tagMatch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the songs of the primary or featured artist",
+                        "name": "artist",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the songs written by the person",
+                        "name": "writer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the songs composed by the person",
+                        "name": "composer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the songs produced by the person",
+                        "name": "producer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the songs whose lyrics are detected to be in the language, an ISO 639-1 code, alternatives separated by commas",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "leave the explicit songs out",
+                        "name": "clean",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "entity tag of the cached response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "date of the cached response",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.GetSongsListResponse"
+                            }
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "caching policy of the route"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "strong entity tag of the response"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "not modified"
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "not-acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a specific song",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song-library"
+                ],
+                "summary": "Song Library",
+                "operationId": "update-song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "song info and the fields to update",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateSongRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a song",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song-library"
+                ],
+                "summary": "Song Library",
+                "operationId": "create-song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "song info",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateSongRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a specific song",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song-library"
+                ],
+                "summary": "Song Library",
+                "operationId": "delete-song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "song info",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.DeleteSongRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs/credits": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the credits of the song in the order they were added",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credits"
+                ],
+                "summary": "Credits",
+                "operationId": "get-song-credits",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.CreditResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Credit the person on the song, the person is created when there is no one by the name yet",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credits"
+                ],
+                "summary": "Credits",
+                "operationId": "add-song-credit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "song info, the person name and the role",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AddSongCreditRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreditResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the person from the song, from every role unless one is given",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "credits"
+                ],
+                "summary": "Credits",
+                "operationId": "remove-song-credit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "song info, the person name and optionally the role",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RemoveSongCreditRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs/duplicates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the pairs of songs likely to be duplicates, the likeliest first. Pairs share the group, up to case, spacing and punctuation, or the link, and are scored from the names, the lyrics and the links",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "duplicates"
+                ],
+                "summary": "Duplicates",
+                "operationId": "get-duplicates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "maximum": 1,
+                        "minimum": 0,
+                        "type": "number",
+                        "description": "the lowest score reported, 0.8 by default",
+                        "name": "minScore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "paginate through the pairs",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.DuplicatePairResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, same-song",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs/events": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stream the created, updated and deleted songs as server-sent events in the commit order. The event ids are the positions to resume from, the events around them may come again",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "song-library"
+                ],
+                "summary": "Song Library",
+                "operationId": "stream-song-events",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the events of this group",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "resume from this event id",
+                        "name": "lastEventId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "resume from this event id",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SongEventResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs/explicit": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get whether the song is explicit, detected from the EXPLICIT_WORDLIST or set by hand, and the lines of the lyrics with the listed words",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "explicit"
+                ],
+                "summary": "Explicit",
+                "operationId": "get-explicit-report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ExplicitReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Mark the song explicit or clean by hand whatever its lyrics, null goes back to the detected flag",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "explicit"
+                ],
+                "summary": "Explicit",
+                "operationId": "set-explicit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "song info and the flag",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SetExplicitRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs/merge": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Merge the loser into the survivor. Each field keeps the value of the chosen song, the survivor's unless it has none by default. The loser's favorites, ratings, playlist entries, plays, tags, credits and relations move to the survivor, the loser is deleted and its name keeps resolving to the survivor",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "duplicates"
+                ],
+                "summary": "Duplicates",
+                "operationId": "merge-songs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "the survivor and the loser, by id or by names, and the field choices",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MergeSongsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, same-song, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs/plays": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the play counters of the song, hourly for the last day or daily for the last 30 days by default",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "plays"
+                ],
+                "summary": "Plays",
+                "operationId": "get-play-stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day"
+                        ],
+                        "type": "string",
+                        "description": "counters granularity",
+                        "name": "granularity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "start of the range",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "end of the range, now by default",
+                        "name": "until",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PlayStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report the plays of the songs, they are counted in batches and show up in the stats within PLAYS_FLUSH_INTERVAL. Reading the lyrics via /v1/songs/text counts as a play on its own",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "plays"
+                ],
+                "summary": "Plays",
+                "operationId": "ingest-plays",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "up to 100 plays",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PlayEventsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs/relations": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Mark the song as a cover, remix, live version or translation of the original, or as sampling it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "relations"
+                ],
+                "summary": "Relations",
+                "operationId": "link-songs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "the derived song, the kind and the original",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SongRelationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, invalid-relation, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the relation between the song and the original",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "relations"
+                ],
+                "summary": "Relations",
+                "operationId": "unlink-songs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "the derived song, the kind and the original",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SongRelationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, invalid-relation, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs/sync": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the changes of the library since the sync token, the ones of the transactions still running are held back until they finish",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "song-library"
+                ],
+                "summary": "Song Library",
+                "operationId": "sync-songs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "sync token from the previous response, empty for a full sync",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the maximum number of changes",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SyncResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs/tags": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Tag the song, the tags it already has are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Tags",
+                "operationId": "tag-song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "song info and the tag names",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SongTagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, invalid-parent, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove the tags from the song",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Tags",
+                "operationId": "untag-song",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "song info and the tag names",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SongTagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, invalid-parent, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs/text": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the lyrics of the song",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "application/xml",
+                    "text/csv",
+                    "application/yaml"
+                ],
+                "tags": [
+                    "song-library"
+                ],
+                "summary": "Song Library",
+                "operationId": "get-song-lyrics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "paginate through the song lyrics paragraphs",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "mask the explicit words, the songs marked explicit by hand are refused",
+                        "name": "clean",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "entity tag of the cached response",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "date of the cached response",
+                        "name": "If-Modified-Since",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GetTextResponse"
+                        },
+                        "headers": {
+                            "Cache-Control": {
+                                "type": "string",
+                                "description": "caching policy of the route"
+                            },
+                            "ETag": {
+                                "type": "string",
+                                "description": "strong entity tag of the response"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "not modified"
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found, missing-lyrics",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "406": {
+                        "description": "not-acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, explicit",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs/text/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the word statistics of the song lyrics: the number of words, of distinct ones, the lexical diversity (distinct over all words) and the most used words, stopwords left out. The words are lowercased, ё is counted as е",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lyrics"
+                ],
+                "summary": "Lyrics",
+                "operationId": "get-song-lyrics-stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "song name",
+                        "name": "song",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of the most used words, 20 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.LyricsStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/songs/trending": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the songs trending within the window, scored by their plays with a play weighing half as much every quarter of the window",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "plays"
+                ],
+                "summary": "Plays",
+                "operationId": "get-trending-songs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "one of TRENDING_WINDOWS, TRENDING_DEFAULT_WINDOW by default",
+                        "name": "window",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "paginate through the songs list",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.TrendingSongResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the library statistics of the songs matching the filters of the songs list: songs per group and per release year, how many lack the lyrics, the link or the release date and the average lyrics length. The statistics are cached for STATS_CACHE_TTL, see generatedAt",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "stats"
+                ],
+                "summary": "Stats",
+                "operationId": "get-stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": " song name",
+                        "name": "song",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "release date",
+                        "name": "releaseDate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "link",
+                        "name": "link",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "lyrics",
+                        "name": "text",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "only songs updated at or after this moment",
+                        "name": "updatedSince",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "tag names, each may list alternatives separated by commas, genres include their subgenres",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "any",
+                            "all"
+                        ],
+                        "type": "string",
+                        "description": "whether songs need all the tags or any of them, any by default",
+                        "name": "tagMatch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the songs of the primary or featured artist",
+                        "name": "artist",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the songs written by the person",
+                        "name": "writer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the songs composed by the person",
+                        "name": "composer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the songs produced by the person",
+                        "name": "producer",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the songs whose lyrics are detected to be in the language, an ISO 639-1 code, alternatives separated by commas",
+                        "name": "language",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "leave the explicit songs out",
+                        "name": "clean",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.StatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/stats/lyrics": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the word statistics of the lyrics of the group, of the songs released within the years when they are set. The songs are indexed in the background and show up here up to LYRICS_INDEX_INTERVAL after their lyrics change",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "lyrics"
+                ],
+                "summary": "Lyrics",
+                "operationId": "get-group-lyrics-stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group name",
+                        "name": "group",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "only the songs released in or after the year",
+                        "name": "fromYear",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "only the songs released in or before the year",
+                        "name": "toYear",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "number of the most used words, 20 by default",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GroupLyricsStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/subjects/{subject}/roles": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the roles and the resulting permissions of the subject",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roles"
+                ],
+                "summary": "Roles",
+                "operationId": "get-subject-roles",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subject, e.g. the JWT subject or api-key:\u003cid\u003e",
+                        "name": "subject",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SubjectRolesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/subjects/{subject}/roles/{name}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Assign the role to the subject",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roles"
+                ],
+                "summary": "Roles",
+                "operationId": "assign-role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subject, e.g. the JWT subject or api-key:\u003cid\u003e",
+                        "name": "subject",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "role name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "built-in",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "nothing-to-update",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Unassign the role from the subject",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "roles"
+                ],
+                "summary": "Roles",
+                "operationId": "unassign-role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "subject, e.g. the JWT subject or api-key:\u003cid\u003e",
+                        "name": "subject",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "role name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "built-in",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "nothing-to-update",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tags": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the tags of the tenant by name",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Tags",
+                "operationId": "get-tags-list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "genre",
+                            "mood",
+                            "tag"
+                        ],
+                        "type": "string",
+                        "description": "only the tags of the kind",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "paginate through the tags list",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.TagResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, invalid-parent",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a tag, only genres may have a parent genre",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Tags",
+                "operationId": "create-tag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "tag info, a plain tag by default",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateTagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TagResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, invalid-parent, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tags/cloud": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the tag cloud: the tags of the tenant with the number of the songs tagged with each, the most used first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Tags",
+                "operationId": "get-tag-cloud",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "genre",
+                            "mood",
+                            "tag"
+                        ],
+                        "type": "string",
+                        "description": "only the tags of the kind",
+                        "name": "kind",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.TagCountResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, invalid-parent",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tags/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Rename the tag or move it under another genre, a zero parentId makes it a top-level one",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Tags",
+                "operationId": "update-tag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the fields to update",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateTagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, nothing-to-update, unknown-tenant, invalid-parent, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete the tag and untag the songs, genres with subgenres can't be deleted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tags"
+                ],
+                "summary": "Tags",
+                "operationId": "delete-tag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "tag id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists, in-use, request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant, invalid-parent, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tenants": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list of tenants",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tenants"
+                ],
+                "summary": "Tenants",
+                "operationId": "get-tenants-list",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.TenantResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Provision a tenant with its own song library",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tenants"
+                ],
+                "summary": "Tenants",
+                "operationId": "create-tenant",
+                "parameters": [
+                    {
+                        "description": "tenant info",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateTenantRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TenantResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/tenants/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a tenant, it has to be emptied first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "tenants"
+                ],
+                "summary": "Tenants",
+                "operationId": "delete-tenant",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "in-use, built-in",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users": {
+            "post": {
+                "description": "Register a user in the tenant",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Users",
+                "operationId": "register-user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "description": "user info",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RegisterUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "already-exists",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/users/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the signed in user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Users",
+                "operationId": "get-current-user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhooks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list of webhooks",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Webhooks",
+                "operationId": "get-webhooks-list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "paginate through the webhooks list",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.WebhookResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Subscribe a URL to the song events",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Webhooks",
+                "operationId": "create-webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "holds the retries with the same key while the request is in progress, the response carrying the secret isn't replayed",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "webhook info, the url must be https and the secret is generated when empty",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateWebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhooks/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the webhook info",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Webhooks",
+                "operationId": "get-webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a specific webhook, omitted fields are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Webhooks",
+                "operationId": "update-webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the fields to update",
+                        "name": "input",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateWebhookRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "validation-failed, nothing-to-update, unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a specific webhook with its delivery log",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Webhooks",
+                "operationId": "delete-webhook",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/response.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhooks/{id}/deliveries": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the delivery log of the webhook, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Webhooks",
+                "operationId": "get-webhook-deliveries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "paginate through the deliveries",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "sets the list limit",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.WebhookDeliveryResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/webhooks/{id}/deliveries/{deliveryId}/redeliver": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    },
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Queue the delivery to be sent again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhooks"
+                ],
+                "summary": "Webhooks",
+                "operationId": "redeliver-webhook-delivery",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "tenant, defaults to the one of the credentials or default",
+                        "name": "X-Tenant-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "replays the stored response for the retries with the same key",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "webhook id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "delivery id",
+                        "name": "deliveryId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/dto.WebhookDeliveryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "malformed-request, invalid-parameter",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "not-found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "request-in-progress",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "request-too-large",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "unknown-tenant, idempotency-key-reused",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "internal-error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.AddPlaylistEntryRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "position": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.AddSongCreditRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "person",
+                "role",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "person": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "primary",
+                        "featured",
+                        "writer",
+                        "composer",
+                        "producer"
+                    ]
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.ApiKeyResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenantId": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateApiKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenantId": {
+                    "type": "string",
+                    "maxLength": 63
+                }
+            }
+        },
+        "dto.CreateApiKeyResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "tenantId": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreatePlaylistRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "allowDuplicates": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "visibility": {
+                    "type": "string",
+                    "enum": [
+                        "private",
+                        "public"
+                    ]
+                }
+            }
+        },
+        "dto.CreateRoleRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "permissions"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "permissions": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.CreateSessionRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateSongRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.CreateTagRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "genre",
+                        "mood",
+                        "tag"
+                    ]
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 63
+                },
+                "parentId": {
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "dto.CreateTenantRequest": {
+            "type": "object",
+            "required": [
+                "id",
+                "name"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "maxLength": 63
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.CreateWebhookRequest": {
+            "type": "object",
+            "required": [
+                "eventTypes",
+                "url"
+            ],
+            "properties": {
+                "eventTypes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string",
+                    "minLength": 16
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateWebhookResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "eventTypes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "secret": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreditResponse": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "personId": {
+                    "type": "integer"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.DeleteRatingRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.DeleteSongRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.DuplicatePairResponse": {
+            "type": "object",
+            "properties": {
+                "link": {
+                    "type": "number"
+                },
+                "lyrics": {
+                    "type": "number"
+                },
+                "names": {
+                    "type": "number"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "songs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.DuplicateSongResponse"
+                    }
+                }
+            }
+        },
+        "dto.DuplicateSongResponse": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ExplicitLineResponse": {
+            "type": "object",
+            "properties": {
+                "line": {
+                    "type": "integer"
+                },
+                "paragraph": {
+                    "type": "integer"
+                },
+                "text": {
+                    "type": "string"
+                },
+                "words": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.ExplicitReportResponse": {
+            "type": "object",
+            "properties": {
+                "detected": {
+                    "type": "boolean"
+                },
+                "explicit": {
+                    "type": "boolean"
+                },
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ExplicitLineResponse"
+                    }
+                },
+                "override": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.FavoriteRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.FavoriteSongResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "credits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CreditResponse"
+                    }
+                },
+                "explicit": {
+                    "type": "boolean"
+                },
+                "favorited": {
+                    "type": "boolean"
+                },
+                "favoritedAt": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "languageConfidence": {
+                    "type": "number"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "ratingAvg": {
+                    "type": "number"
+                },
+                "ratingCount": {
+                    "type": "integer"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GetSongResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "credits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CreditResponse"
+                    }
+                },
+                "explicit": {
+                    "type": "boolean"
+                },
+                "favorited": {
+                    "type": "boolean"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "languageConfidence": {
+                    "type": "number"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "originals": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RelatedSongResponse"
+                    }
+                },
+                "ratingAvg": {
+                    "type": "number"
+                },
+                "ratingCount": {
+                    "type": "integer"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "versions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RelatedSongResponse"
+                    }
+                }
+            }
+        },
+        "dto.GetSongsListResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "credits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CreditResponse"
+                    }
+                },
+                "explicit": {
+                    "type": "boolean"
+                },
+                "favorited": {
+                    "type": "boolean"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "languageConfidence": {
+                    "type": "number"
+                },
+                "link": {
+                    "type": "string"
+                },
+                "ratingAvg": {
+                    "type": "number"
+                },
+                "ratingCount": {
+                    "type": "integer"
+                },
+                "releaseDate": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "text": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GetTextResponse": {
+            "type": "object",
+            "properties": {
+                "text": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GroupCountResponse": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "songs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.GroupLyricsStatsResponse": {
+            "type": "object",
+            "properties": {
+                "lexicalDiversity": {
+                    "type": "number"
+                },
+                "songs": {
+                    "type": "integer"
+                },
+                "terms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.TermCountResponse"
+                    }
+                },
+                "uniqueWords": {
+                    "type": "integer"
+                },
+                "words": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.LyricsStatsResponse": {
+            "type": "object",
+            "properties": {
+                "lexicalDiversity": {
+                    "type": "number"
+                },
+                "terms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.TermCountResponse"
+                    }
+                },
+                "uniqueWords": {
+                    "type": "integer"
+                },
+                "words": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.MergeFields": {
+            "type": "object",
+            "properties": {
+                "link": {
+                    "type": "string",
+                    "enum": [
+                        "survivor",
+                        "loser"
+                    ]
+                },
+                "releaseDate": {
+                    "type": "string",
+                    "enum": [
+                        "survivor",
+                        "loser"
+                    ]
+                },
+                "text": {
+                    "type": "string",
+                    "enum": [
+                        "survivor",
+                        "loser"
+                    ]
+                }
+            }
+        },
+        "dto.MergeSongRef": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.MergeSongsRequest": {
+            "type": "object",
+            "required": [
+                "loser",
+                "survivor"
+            ],
+            "properties": {
+                "fields": {
+                    "$ref": "#/definitions/dto.MergeFields"
+                },
+                "loser": {
+                    "$ref": "#/definitions/dto.MergeSongRef"
+                },
+                "survivor": {
+                    "$ref": "#/definitions/dto.MergeSongRef"
+                }
+            }
+        },
+        "dto.ModerateReviewRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "published",
+                        "rejected"
+                    ]
+                }
+            }
+        },
+        "dto.MovePlaylistEntryRequest": {
+            "type": "object",
+            "required": [
+                "position"
+            ],
+            "properties": {
+                "position": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "dto.PersonRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.PersonResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PlayEvent": {
+            "type": "object",
+            "required": [
+                "group",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.PlayEventsRequest": {
+            "type": "object",
+            "required": [
+                "events"
+            ],
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/dto.PlayEvent"
+                    }
+                }
+            }
+        },
+        "dto.PlayStatsResponse": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/entities.PlayBucket"
+                    }
+                },
+                "granularity": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.PlaylistEntryResponse": {
+            "type": "object",
+            "properties": {
+                "addedAt": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "song": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PlaylistResponse": {
+            "type": "object",
+            "properties": {
+                "allowDuplicates": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "ownerUserId": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PlaylistWithEntriesResponse": {
+            "type": "object",
+            "properties": {
+                "allowDuplicates": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PlaylistEntryResponse"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "ownerUserId": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RateSongRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "rating",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "rating": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                },
+                "review": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.RatingResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "integer"
+                },
+                "review": {
+                    "type": "string"
+                },
+                "reviewStatus": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RegisterUserRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                }
+            }
+        },
+        "dto.RelatedSongResponse": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RemoveSongCreditRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "person",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "person": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "primary",
+                        "featured",
+                        "writer",
+                        "composer",
+                        "producer"
+                    ]
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.ReviewResponse": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "rating": {
+                    "type": "integer"
+                },
+                "review": {
+                    "type": "string"
+                },
+                "reviewStatus": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.RoleResponse": {
+            "type": "object",
+            "properties": {
+                "builtin": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.SessionResponse": {
+            "type": "object",
+            "properties": {
+                "expiresAt": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.SetExplicitRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "song"
+            ],
+            "properties": {
+                "explicit": {
+                    "type": "boolean"
+                },
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.SongChangeResponse": {
+            "type": "object",
+            "properties": {
+                "changedAt": {
+                    "type": "string"
+                },
+                "data": {
+                    "$ref": "#/definitions/dto.GetSongsListResponse"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.SongEventResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/dto.GetSongsListResponse"
+                },
+                "group": {
+                    "type": "string"
+                },
+                "song": {
+                    "type": "string"
+                },
+                "tenant": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         },
-        "dto.DeleteSongRequest": {
+        "dto.SongRef": {
             "type": "object",
             "required": [
                 "group",
@@ -405,52 +8952,327 @@ const docTemplate = `{
             ],
             "properties": {
                 "group": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.SongRelationRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "kind",
+                "original",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "cover",
+                        "remix",
+                        "live",
+                        "sample",
+                        "translation"
+                    ]
+                },
+                "original": {
+                    "$ref": "#/definitions/dto.SongRef"
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.SongTagsRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "song",
+                "tags"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
                 },
                 "song": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "tags": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "avgTextLength": {
+                    "type": "number"
+                },
+                "generatedAt": {
                     "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GroupCountResponse"
+                    }
+                },
+                "releaseYears": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.YearCountResponse"
+                    }
+                },
+                "songs": {
+                    "type": "integer"
+                },
+                "withoutLink": {
+                    "type": "integer"
+                },
+                "withoutReleaseDate": {
+                    "type": "integer"
+                },
+                "withoutText": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.GetSongResponse": {
+        "dto.SubjectRolesResponse": {
             "type": "object",
             "properties": {
-                "link": {
+                "permissions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RoleResponse"
+                    }
+                },
+                "subject": {
                     "type": "string"
+                }
+            }
+        },
+        "dto.SyncResponse": {
+            "type": "object",
+            "properties": {
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.SongChangeResponse"
+                    }
                 },
-                "releaseDate": {
+                "hasMore": {
+                    "type": "boolean"
+                },
+                "nextToken": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.TagCountResponse": {
+            "type": "object",
+            "properties": {
+                "kind": {
                     "type": "string"
                 },
-                "text": {
+                "name": {
                     "type": "string"
+                },
+                "songs": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.GetSongsListResponse": {
+        "dto.TagResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parentId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.TenantResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.TermCountResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "term": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.TrendingSongResponse": {
             "type": "object",
             "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "credits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CreditResponse"
+                    }
+                },
+                "explicit": {
+                    "type": "boolean"
+                },
+                "favorited": {
+                    "type": "boolean"
+                },
                 "group": {
                     "type": "string"
                 },
+                "language": {
+                    "type": "string"
+                },
+                "languageConfidence": {
+                    "type": "number"
+                },
                 "link": {
                     "type": "string"
                 },
+                "plays": {
+                    "type": "integer"
+                },
+                "ratingAvg": {
+                    "type": "number"
+                },
+                "ratingCount": {
+                    "type": "integer"
+                },
                 "releaseDate": {
                     "type": "string"
                 },
+                "score": {
+                    "type": "number"
+                },
                 "song": {
                     "type": "string"
                 },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "text": {
                     "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
                 }
             }
         },
-        "dto.GetTextResponse": {
+        "dto.UpdatePlaylistRequest": {
             "type": "object",
             "properties": {
-                "text": {
+                "allowDuplicates": {
+                    "type": "boolean"
+                },
+                "description": {
                     "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 1
+                },
+                "visibility": {
+                    "type": "string",
+                    "enum": [
+                        "private",
+                        "public"
+                    ]
+                }
+            }
+        },
+        "dto.UpdateRatingRequest": {
+            "type": "object",
+            "required": [
+                "group",
+                "song"
+            ],
+            "properties": {
+                "group": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "rating": {
+                    "type": "integer",
+                    "maximum": 5,
+                    "minimum": 1
+                },
+                "review": {
+                    "type": "string",
+                    "maxLength": 1000
+                },
+                "song": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.UpdateRoleRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "permissions": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
@@ -462,7 +9284,8 @@ const docTemplate = `{
             ],
             "properties": {
                 "group": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
                 },
                 "link": {
                     "type": "string"
@@ -471,13 +9294,321 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "song": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
                 },
                 "text": {
                     "type": "string"
                 }
             }
         },
+        "dto.UpdateTagRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 63,
+                    "minLength": 1
+                },
+                "parentId": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "dto.UpdateWebhookRequest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "eventTypes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "secret": {
+                    "type": "string",
+                    "minLength": 16
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.UserResponse": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.WebhookDeliveryResponse": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deliveredAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "eventId": {
+                    "type": "integer"
+                },
+                "eventType": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "redeliveryOf": {
+                    "type": "integer"
+                },
+                "responseStatus": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.WebhookResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "eventTypes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.YearCountResponse": {
+            "type": "object",
+            "properties": {
+                "songs": {
+                    "type": "integer"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "entities.PlayBucket": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "plays": {
+                    "type": "integer"
+                }
+            }
+        },
+        "response.Code": {
+            "type": "string",
+            "enum": [
+                "malformed-request",
+                "invalid-parameter",
+                "validation-failed",
+                "unauthorized",
+                "forbidden",
+                "not-found",
+                "missing-lyrics",
+                "method-not-allowed",
+                "not-acceptable",
+                "already-exists",
+                "in-use",
+                "built-in",
+                "request-in-progress",
+                "nothing-to-update",
+                "unknown-tenant",
+                "invalid-parent",
+                "invalid-relation",
+                "same-song",
+                "explicit",
+                "idempotency-key-reused",
+                "request-too-large",
+                "internal-error"
+            ],
+            "x-enum-varnames": [
+                "CodeMalformedRequest",
+                "CodeInvalidParameter",
+                "CodeValidationFailed",
+                "CodeUnauthorized",
+                "CodeForbidden",
+                "CodeNotFound",
+                "CodeMissingLyrics",
+                "CodeMethodNotAllowed",
+                "CodeNotAcceptable",
+                "CodeAlreadyExists",
+                "CodeInUse",
+                "CodeBuiltin",
+                "CodeRequestInProgress",
+                "CodeNothingToUpdate",
+                "CodeUnknownTenant",
+                "CodeInvalidParent",
+                "CodeInvalidRelation",
+                "CodeSameSong",
+                "CodeExplicit",
+                "CodeIdempotencyKeyReused",
+                "CodeRequestTooLarge",
+                "CodeInternal"
+            ]
+        },
+        "response.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "description": "Field is the JSON or the query key, with the path for nested fields.",
+                    "type": "string",
+                    "example": "group"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "group is a required field"
+                },
+                "rule": {
+                    "type": "string",
+                    "example": "required"
+                }
+            }
+        },
+        "response.Problem": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Code is stable, clients should branch on it rather than on the title or\nthe detail, see GET /problems for the catalogue.",
+                    "enum": [
+                        "malformed-request",
+                        "invalid-parameter",
+                        "validation-failed",
+                        "unauthorized",
+                        "forbidden",
+                        "not-found",
+                        "missing-lyrics",
+                        "method-not-allowed",
+                        "not-acceptable",
+                        "already-exists",
+                        "in-use",
+                        "built-in",
+                        "request-in-progress",
+                        "nothing-to-update",
+                        "unknown-tenant",
+                        "invalid-parent",
+                        "invalid-relation",
+                        "same-song",
+                        "explicit",
+                        "idempotency-key-reused",
+                        "request-too-large",
+                        "internal-error"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ]
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "song not found"
+                },
+                "errors": {
+                    "description": "Errors lists the invalid fields of a validation-failed problem, with the\nmessages in English or Russian as the Accept-Language header prefers.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/response.FieldError"
+                    }
+                },
+                "instance": {
+                    "description": "Instance is the path of the request.",
+                    "type": "string",
+                    "example": "/v1/songs/text"
+                },
+                "requestId": {
+                    "type": "string",
+                    "example": "host/abcdef-000001"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not found"
+                },
+                "type": {
+                    "description": "Type resolves to the ProblemType of the code.",
+                    "type": "string",
+                    "example": "/problems/not-found"
+                }
+            }
+        },
+        "response.ProblemType": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/response.Code"
+                        }
+                    ],
+                    "example": "not-found"
+                },
+                "description": {
+                    "type": "string",
+                    "example": "the song, or another resource the request refers to, doesn't exist"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "/problems/not-found"
+                }
+            }
+        },
         "response.Response": {
             "type": "object",
             "properties": {
@@ -489,6 +9620,19 @@ const docTemplate = `{
                 }
             }
         }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        },
+        "BearerAuth": {
+            "description": "Session token from POST /v1/sessions or a JWT issued by the configured identity provider, as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
     }
 }`
 
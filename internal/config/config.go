@@ -8,11 +8,64 @@ import (
 )
 
 type Config struct {
-	Env              string        `env:"ENV" env-required:"true"`
-	DbPath           string        `env:"DB_PATH" env-required:"true"`
-	HttpAddr         string        `env:"HTTP_ADDR" env-required:"true"`
-	HttpReadTimeout  time.Duration `env:"HTTP_READ_TIMEOUT" env-default:"10s"`
-	HttpWriteTimeout time.Duration `env:"HTTP_WRITE_TIMEOUT" env-default:"10s"`
+	Env                 string        `env:"ENV" env-required:"true"`
+	DbPath              string        `env:"DB_PATH" env-required:"true"`
+	HttpAddr            string        `env:"HTTP_ADDR" env-required:"true"`
+	HttpReadTimeout     time.Duration `env:"HTTP_READ_TIMEOUT" env-default:"10s"`
+	HttpWriteTimeout    time.Duration `env:"HTTP_WRITE_TIMEOUT" env-default:"10s"`
+	HttpShutdownTimeout time.Duration `env:"HTTP_SHUTDOWN_TIMEOUT" env-default:"10s"`
+	EventsHeartbeat     time.Duration `env:"EVENTS_HEARTBEAT" env-default:"15s"`
+	IdempotencyTTL      time.Duration `env:"IDEMPOTENCY_TTL" env-default:"24h"`
+	IdempotencyMaxBody  int64         `env:"IDEMPOTENCY_MAX_BODY" env-default:"1048576"`
+	BootstrapApiKey     string        `env:"BOOTSTRAP_API_KEY"`
+	DefaultRole         string        `env:"DEFAULT_ROLE" env-default:"viewer"`
+	TenantRls           bool          `env:"TENANT_RLS" env-default:"false"`
+	SessionTTL          time.Duration `env:"SESSION_TTL" env-default:"720h"`
+	ReviewBlocklist     []string      `env:"REVIEW_BLOCKLIST" env-separator:","`
+	StatsCacheTTL       time.Duration `env:"STATS_CACHE_TTL" env-default:"5m"`
+	ExplicitWordlist    string        `env:"EXPLICIT_WORDLIST"`
+	CacheControl        CacheControl
+	Webhooks            Webhooks
+	Plays               Plays
+	Lyrics              Lyrics
+	Jwt                 Jwt
+}
+
+type Jwt struct {
+	JwksSource  string        `env:"JWT_JWKS_SOURCE"`
+	JwksRefresh time.Duration `env:"JWT_JWKS_REFRESH" env-default:"15m"`
+	Issuer      string        `env:"JWT_ISSUER"`
+	Audience    string        `env:"JWT_AUDIENCE"`
+	Leeway      time.Duration `env:"JWT_LEEWAY" env-default:"30s"`
+	ScopesClaim string        `env:"JWT_SCOPES_CLAIM" env-default:"scope"`
+	TenantClaim string        `env:"JWT_TENANT_CLAIM" env-default:"tenant"`
+}
+
+type Webhooks struct {
+	Timeout       time.Duration `env:"WEBHOOKS_TIMEOUT" env-default:"10s"`
+	MaxAttempts   int           `env:"WEBHOOKS_MAX_ATTEMPTS" env-default:"8"`
+	Backoff       time.Duration `env:"WEBHOOKS_BACKOFF" env-default:"10s"`
+	PollInterval  time.Duration `env:"WEBHOOKS_POLL_INTERVAL" env-default:"2s"`
+	AllowInsecure bool          `env:"WEBHOOKS_ALLOW_INSECURE" env-default:"false"`
+}
+
+type Plays struct {
+	FlushInterval         time.Duration            `env:"PLAYS_FLUSH_INTERVAL" env-default:"10s"`
+	MaxBatch              int                      `env:"PLAYS_MAX_BATCH" env-default:"1000"`
+	HourlyRetention       time.Duration            `env:"PLAYS_HOURLY_RETENTION" env-default:"720h"`
+	TrendingWindows       map[string]time.Duration `env:"TRENDING_WINDOWS" env-default:"day:24h,week:168h,month:720h"`
+	TrendingDefaultWindow string                   `env:"TRENDING_DEFAULT_WINDOW" env-default:"day"`
+}
+
+type Lyrics struct {
+	IndexInterval time.Duration `env:"LYRICS_INDEX_INTERVAL" env-default:"10s"`
+	IndexBatch    int           `env:"LYRICS_INDEX_BATCH" env-default:"100"`
+}
+
+type CacheControl struct {
+	Info string `env:"CACHE_CONTROL_INFO" env-default:"private, max-age=60"`
+	Text string `env:"CACHE_CONTROL_TEXT" env-default:"private, max-age=300"`
+	List string `env:"CACHE_CONTROL_LIST" env-default:"no-cache"`
 }
 
 func MustLoad() *Config {
@@ -31,6 +84,25 @@ func MustLoad() *Config {
 		panic("unable to read config: " + err.Error())
 	}
 
+	// The intervals drive tickers, which don't take zero or negative ones.
+	intervals := map[string]time.Duration{
+		"EVENTS_HEARTBEAT":       cfg.EventsHeartbeat,
+		"WEBHOOKS_POLL_INTERVAL": cfg.Webhooks.PollInterval,
+		"PLAYS_FLUSH_INTERVAL":   cfg.Plays.FlushInterval,
+		"LYRICS_INDEX_INTERVAL":  cfg.Lyrics.IndexInterval,
+	}
+	for name, interval := range intervals {
+		if interval <= 0 {
+			panic("config " + name + " must be positive: " + interval.String())
+		}
+	}
+
+	// Without them any token signed by a key of the set would do, whoever it
+	// was issued for.
+	if cfg.Jwt.JwksSource != "" && (cfg.Jwt.Issuer == "" || cfg.Jwt.Audience == "") {
+		panic("config JWT_ISSUER and JWT_AUDIENCE are required with JWT_JWKS_SOURCE")
+	}
+
 	return &cfg
 }
 
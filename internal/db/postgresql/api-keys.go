@@ -0,0 +1,192 @@
+package postgres
+
+import (
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"log/slog"
+	"time"
+)
+
+var apiKeyColumns = []string{"id", "name", "prefix", "key_hash", "scopes", "tenant_id", "created_at", "expires_at", "last_used_at", "revoked_at"}
+
+type ApiKeys struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewApiKeys(db *DB) *ApiKeys {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &ApiKeys{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+// Create returns sql.ErrNoRows when a key with the same hash already exists.
+func (ak *ApiKeys) Create(name, prefix, hash string, scopes []string, tenant *string, expiresAt *time.Time) (*entities.ApiKey, error) {
+	const fn = "db.postgres.ApiKeys.Create"
+	var query string
+
+	defer func(query *string) {
+		ak.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := ak.stmtBuilder.
+		Insert("api_keys").
+		Columns("name", "prefix", "key_hash", "scopes", "tenant_id", "expires_at").
+		Values(name, prefix, hash, pq.Array(scopes), tenant, expiresAt).
+		Suffix("ON CONFLICT (key_hash) DO NOTHING").
+		Suffix("RETURNING " + columns(apiKeyColumns))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var apiKey entities.ApiKey
+	err = ak.db.Get(&apiKey, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &apiKey, nil
+}
+
+func (ak *ApiKeys) GetByHash(hash string) (*entities.ApiKey, error) {
+	const fn = "db.postgres.ApiKeys.GetByHash"
+	var query string
+
+	defer func(query *string) {
+		ak.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := ak.stmtBuilder.
+		Select(apiKeyColumns...).
+		From("api_keys").
+		Where(squirrel.Eq{"key_hash": hash})
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var apiKey entities.ApiKey
+	err = ak.db.Get(&apiKey, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &apiKey, nil
+}
+
+// GetList returns the keys of the tenant and, withUnbound, the keys bound to
+// no tenant.
+func (ak *ApiKeys) GetList(tenant string, withUnbound bool, pagination *pagination.Pagination) (*[]entities.ApiKey, error) {
+	const fn = "db.postgres.ApiKeys.GetList"
+	var query string
+
+	defer func(query *string) {
+		ak.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := ak.stmtBuilder.
+		Select(apiKeyColumns...).
+		From("api_keys").
+		Where(byKeyTenant(tenant, withUnbound)).
+		OrderBy("id")
+
+	queryBuilder = buildPagination(queryBuilder, pagination, 10)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var apiKeys []entities.ApiKey
+	err = ak.db.Select(&apiKeys, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &apiKeys, nil
+}
+
+func (ak *ApiKeys) Revoke(id int, tenant string, withUnbound bool) error {
+	const fn = "db.postgres.ApiKeys.Revoke"
+	var query string
+
+	defer func(query *string) {
+		ak.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := ak.stmtBuilder.
+		Update("api_keys").
+		Set("revoked_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": id, "revoked_at": nil}).
+		Where(byKeyTenant(tenant, withUnbound))
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(ak.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// Touch records the key usage, at most once a minute to keep the hot path cheap.
+func (ak *ApiKeys) Touch(id int) error {
+	const fn = "db.postgres.ApiKeys.Touch"
+	var query string
+
+	defer func(query *string) {
+		ak.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := ak.stmtBuilder.
+		Update("api_keys").
+		Set("last_used_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": id}).
+		Where("(last_used_at IS NULL OR last_used_at < now() - INTERVAL '1 minute')")
+
+	query, _, _ = queryBuilder.ToSql()
+
+	_, err := queryBuilder.RunWith(ak.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func byKeyTenant(tenant string, withUnbound bool) squirrel.Sqlizer {
+	if withUnbound {
+		return squirrel.Or{squirrel.Eq{"tenant_id": tenant}, squirrel.Eq{"tenant_id": nil}}
+	}
+	return squirrel.Eq{"tenant_id": tenant}
+}
@@ -0,0 +1,301 @@
+package postgres
+
+import (
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"log/slog"
+)
+
+var (
+	personColumns = []string{"id", "tenant_id", "name", "created_at"}
+	creditColumns = []string{"id", "song_id", "person_id", "role", "created_at"}
+)
+
+type Credits struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewCredits(db *DB) *Credits {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Credits{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+func (cr *Credits) CreatePerson(tenant, name string) (*entities.Person, error) {
+	const fn = "db.postgres.Credits.CreatePerson"
+	var query string
+
+	defer func(query *string) {
+		cr.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := cr.stmtBuilder.
+		Insert("people").
+		Columns("tenant_id", "name").
+		Values(tenant, name).
+		Suffix("RETURNING " + columns(personColumns))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var res entities.Person
+	err = cr.db.Get(&res, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &res, nil
+}
+
+// EnsurePerson returns the id of the person of the tenant by the name,
+// creating one when there is none.
+func (cr *Credits) EnsurePerson(tenant, name string) (int, error) {
+	const fn = "db.postgres.Credits.EnsurePerson"
+	var query string
+
+	defer func(query *string) {
+		cr.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	// The no-op update makes RETURNING yield the row on conflict too.
+	queryBuilder := cr.stmtBuilder.
+		Insert("people").
+		Columns("tenant_id", "name").
+		Values(tenant, name).
+		Suffix("ON CONFLICT (tenant_id, name) DO UPDATE SET name = EXCLUDED.name RETURNING id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var id int
+	err = cr.db.Get(&id, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return id, nil
+}
+
+// GetPeople returns the people of the tenant by name, only the ones whose
+// name starts with the prefix unless it's empty.
+func (cr *Credits) GetPeople(tenant, prefix string, pagination *pagination.Pagination) (*[]entities.Person, error) {
+	const fn = "db.postgres.Credits.GetPeople"
+	var query string
+
+	defer func(query *string) {
+		cr.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := cr.stmtBuilder.
+		Select(personColumns...).
+		From("people").
+		Where(squirrel.Eq{"tenant_id": tenant}).
+		OrderBy("name")
+
+	if prefix != "" {
+		queryBuilder = queryBuilder.Where(squirrel.ILike{"name": prefix + "%"})
+	}
+
+	queryBuilder = buildPagination(queryBuilder, pagination, 50)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var people []entities.Person
+	err = cr.db.Select(&people, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &people, nil
+}
+
+func (cr *Credits) UpdatePerson(tenant string, id int, name string) error {
+	const fn = "db.postgres.Credits.UpdatePerson"
+	var query string
+
+	defer func(query *string) {
+		cr.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := cr.stmtBuilder.
+		Update("people").
+		Set("name", name).
+		Where(squirrel.Eq{"tenant_id": tenant, "id": id})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(cr.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (cr *Credits) DeletePerson(tenant string, id int) error {
+	const fn = "db.postgres.Credits.DeletePerson"
+	var query string
+
+	defer func(query *string) {
+		cr.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := cr.stmtBuilder.
+		Delete("people").
+		Where(squirrel.Eq{"tenant_id": tenant, "id": id})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(cr.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (cr *Credits) AddCredit(songID, personID int, role string) (*entities.SongCredit, error) {
+	const fn = "db.postgres.Credits.AddCredit"
+	var query string
+
+	defer func(query *string) {
+		cr.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := cr.stmtBuilder.
+		Insert("song_credits").
+		Columns("song_id", "person_id", "role").
+		Values(songID, personID, role).
+		Suffix("RETURNING " + columns(creditColumns))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var res entities.SongCredit
+	err = cr.db.Get(&res, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &res, nil
+}
+
+// RemoveCredit removes the credit of the person by the name, from every role
+// when role is empty.
+func (cr *Credits) RemoveCredit(tenant string, songID int, name, role string) error {
+	const fn = "db.postgres.Credits.RemoveCredit"
+	var query string
+
+	defer func(query *string) {
+		cr.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := cr.stmtBuilder.
+		Delete("song_credits").
+		Where(squirrel.Eq{"song_id": songID}).
+		Where("person_id = (SELECT id FROM people WHERE tenant_id = ? AND name = ?)", tenant, name)
+
+	if role != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"role": role})
+	}
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(cr.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GetBySongs returns the credits of the songs with the names of the people,
+// in the order they were added.
+func (cr *Credits) GetBySongs(songIDs []int) (*[]entities.SongCredit, error) {
+	const fn = "db.postgres.Credits.GetBySongs"
+	var query string
+
+	defer func(query *string) {
+		cr.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := cr.stmtBuilder.
+		Select(append(qualify("sc", creditColumns), "p.name")...).
+		From("song_credits sc").
+		Join("people p ON p.id = sc.person_id").
+		Where("sc.song_id = ANY(?)", pq.Array(songIDs)).
+		OrderBy("sc.song_id", "sc.id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var credits []entities.SongCredit
+	err = cr.db.Select(&credits, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &credits, nil
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"effective-mobile-test/internal/entities"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"log/slog"
+)
+
+type Duplicates struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewDuplicates(db *DB) *Duplicates {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Duplicates{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+// GetCandidates returns the songs of the tenant that share the group, up to
+// case, spacing and punctuation, or the link with another song.
+func (dp *Duplicates) GetCandidates(tenant string) (*[]entities.Song, error) {
+	const fn = "db.postgres.Duplicates.GetCandidates"
+
+	const query = `
+		WITH keyed AS (
+			SELECT *, lower(regexp_replace("group", '[^[:alnum:]]+', '', 'g')) AS loose_group_key
+			FROM song_library
+			WHERE tenant_id = $1
+		)
+		SELECT k.id, k.tenant_id, k."group", k.song, k.release_date, k.link, k.text, k.created_at, k.updated_at
+		FROM keyed k
+		WHERE EXISTS (
+			SELECT 1
+			FROM keyed o
+			WHERE o.id <> k.id AND (o.loose_group_key = k.loose_group_key OR o.link = k.link)
+		)
+		ORDER BY k.id`
+
+	defer dp.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.String("query", query))
+
+	var songs []entities.Song
+	err := dp.db.Select(&songs, query, tenant)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &songs, nil
+}
+
+// Merge moves everything that refers to the loser over to the survivor,
+// updates the survivor with the fields and deletes the loser, leaving a
+// redirect from its id and name to the survivor. Favorites, ratings, tags and
+// credits the survivor already has win over the loser's ones, the plays add
+// up.
+func (dp *Duplicates) Merge(survivorID, loserID int, fields map[string]interface{}) error {
+	const fn = "db.postgres.Duplicates.Merge"
+
+	defer dp.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.Int("survivor", survivorID), slog.Int("loser", loserID), slog.Any("fields", fields))
+
+	tx, err := dp.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if len(fields) > 0 {
+		query, args, err := dp.stmtBuilder.
+			Update("song_library").
+			SetMap(fields).
+			Where(squirrel.Eq{"id": survivorID}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+
+		if _, err = tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	if err = dp.moveReferences(tx, survivorID, loserID); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return tx.Commit()
+}
+
+func (dp *Duplicates) moveReferences(tx *sqlx.Tx, survivorID, loserID int) error {
+	statements := []string{
+		`INSERT INTO favorites (user_id, song_id, created_at)
+		SELECT user_id, $1::int, created_at FROM favorites WHERE song_id = $2
+		ON CONFLICT DO NOTHING`,
+
+		// Playlists without duplicates keep the survivor's entry only.
+		`DELETE FROM playlist_entries e
+		USING playlists p
+		WHERE e.song_id = $2 AND p.id = e.playlist_id AND NOT p.allow_duplicates
+			AND EXISTS (SELECT 1 FROM playlist_entries o WHERE o.playlist_id = e.playlist_id AND o.song_id = $1)`,
+		`UPDATE playlist_entries SET song_id = $1 WHERE song_id = $2`,
+
+		// Inserting rather than updating keeps song_rating_stats in sync.
+		`INSERT INTO song_ratings (song_id, user_id, rating, review, review_status, created_at, updated_at)
+		SELECT $1::int, user_id, rating, review, review_status, created_at, updated_at FROM song_ratings WHERE song_id = $2
+		ON CONFLICT DO NOTHING`,
+
+		`INSERT INTO song_plays_hourly (song_id, hour, plays)
+		SELECT $1::int, hour, plays FROM song_plays_hourly WHERE song_id = $2
+		ON CONFLICT (song_id, hour) DO UPDATE SET plays = song_plays_hourly.plays + EXCLUDED.plays`,
+		`INSERT INTO song_plays_daily (song_id, day, plays)
+		SELECT $1::int, day, plays FROM song_plays_daily WHERE song_id = $2
+		ON CONFLICT (song_id, day) DO UPDATE SET plays = song_plays_daily.plays + EXCLUDED.plays`,
+
+		`INSERT INTO song_tags (song_id, tag_id)
+		SELECT $1::int, tag_id FROM song_tags WHERE song_id = $2
+		ON CONFLICT DO NOTHING`,
+		`INSERT INTO song_credits (song_id, person_id, role, created_at)
+		SELECT $1::int, person_id, role, created_at FROM song_credits WHERE song_id = $2
+		ON CONFLICT DO NOTHING`,
+
+		`INSERT INTO song_relations (song_id, related_id, kind, created_at)
+		SELECT $1::int, related_id, kind, created_at FROM song_relations WHERE song_id = $2 AND related_id <> $1
+		ON CONFLICT DO NOTHING`,
+		`INSERT INTO song_relations (song_id, related_id, kind, created_at)
+		SELECT song_id, $1::int, kind, created_at FROM song_relations WHERE related_id = $2 AND song_id <> $1
+		ON CONFLICT DO NOTHING`,
+
+		// The redirects to the loser follow it to the survivor.
+		`UPDATE song_redirects SET to_id = $1 WHERE to_id = $2`,
+		`INSERT INTO song_redirects (from_id, to_id, tenant_id, "group", song)
+		SELECT id, $1::int, tenant_id, "group", song FROM song_library WHERE id = $2`,
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, survivorID, loserID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM song_library WHERE id = $1`, loserID); err != nil {
+		return err
+	}
+
+	// Once the songs sharing the key of the survivor are all flagged with
+	// name_collision, the oldest one keeps the key.
+	_, err := tx.Exec(`
+		UPDATE song_library SET name_collision = false
+		WHERE id = (
+			SELECT min(o.id)
+			FROM song_library o
+			JOIN song_library s ON o.tenant_id = s.tenant_id AND o.group_key = s.group_key AND o.song_key = s.song_key
+			WHERE s.id = $1
+			HAVING bool_and(o.name_collision)
+		)`, survivorID)
+
+	return err
+}
@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"log/slog"
+)
+
+type Favorites struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewFavorites(db *DB) *Favorites {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Favorites{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+// Add is a no-op for the songs already in the favorites.
+func (fv *Favorites) Add(userID, songID int) error {
+	const fn = "db.postgres.Favorites.Add"
+	var query string
+
+	defer func(query *string) {
+		fv.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := fv.stmtBuilder.
+		Insert("favorites").
+		Columns("user_id", "song_id").
+		Values(userID, songID).
+		Suffix("ON CONFLICT DO NOTHING")
+
+	query, _, _ = queryBuilder.ToSql()
+
+	_, err := queryBuilder.RunWith(fv.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (fv *Favorites) Remove(userID, songID int) error {
+	const fn = "db.postgres.Favorites.Remove"
+	var query string
+
+	defer func(query *string) {
+		fv.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := fv.stmtBuilder.
+		Delete("favorites").
+		Where(squirrel.Eq{"user_id": userID, "song_id": songID})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(fv.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GetList returns the favorite songs of the user, the latest added first.
+func (fv *Favorites) GetList(userID int, pagination *pagination.Pagination) (*[]entities.FavoriteSong, error) {
+	const fn = "db.postgres.Favorites.GetList"
+	var query string
+
+	defer func(query *string) {
+		fv.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := fv.stmtBuilder.
+		Select("f.created_at AS favorited_at",
+			"s.id", "s.tenant_id", `s."group"`, "s.song", "s.release_date", "s.link", "s.text", "s.created_at", "s.updated_at").
+		From("favorites f").
+		Join("song_library s ON s.id = f.song_id").
+		Where(squirrel.Eq{"f.user_id": userID}).
+		OrderBy("f.created_at DESC", "s.id")
+
+	queryBuilder = buildPagination(queryBuilder, pagination, 10)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var songs []entities.FavoriteSong
+	err = fv.db.Select(&songs, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &songs, nil
+}
+
+// GetFavorited returns which of the songs are in the favorites of the user.
+func (fv *Favorites) GetFavorited(userID int, songIDs []int) ([]int, error) {
+	const fn = "db.postgres.Favorites.GetFavorited"
+	var query string
+
+	defer func(query *string) {
+		fv.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := fv.stmtBuilder.
+		Select("song_id").
+		From("favorites").
+		Where(squirrel.Eq{"user_id": userID}).
+		Where("song_id = ANY(?)", pq.Array(songIDs))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var ids []int
+	err = fv.db.Select(&ids, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"log/slog"
+	"time"
+)
+
+var idempotencyKeyColumns = []string{"key", "fingerprint", "status_code", "headers", "body", "created_at", "expires_at"}
+
+type IdempotencyKeys struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewIdempotencyKeys(db *DB) *IdempotencyKeys {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &IdempotencyKeys{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+// Reserve claims the key for the request. When the key is already taken and
+// hasn't expired yet, the stored record is returned with reserved set to false.
+func (ik *IdempotencyKeys) Reserve(key, fingerprint string, ttl time.Duration) (*entities.IdempotencyKey, bool, error) {
+	const fn = "db.postgres.IdempotencyKeys.Reserve"
+
+	query := `
+		INSERT INTO idempotency_keys (key, fingerprint, expires_at)
+		VALUES ($1, $2, now() + make_interval(secs => $3))
+		ON CONFLICT (key) DO UPDATE
+			SET fingerprint  = EXCLUDED.fingerprint,
+			    status_code  = NULL,
+			    headers      = NULL,
+			    body         = NULL,
+			    created_at   = now(),
+			    expires_at   = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at < now()
+		RETURNING ` + columns(idempotencyKeyColumns)
+
+	defer ik.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.String("query", query))
+
+	var record entities.IdempotencyKey
+	err := ik.db.Get(&record, query, key, fingerprint, ttl.Seconds())
+	if err == nil {
+		return &record, true, nil
+	}
+
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	selectQuery, args, err := ik.stmtBuilder.
+		Select(idempotencyKeyColumns...).
+		From("idempotency_keys").
+		Where(squirrel.Eq{"key": key}).
+		ToSql()
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err = ik.db.Get(&record, selectQuery, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &record, false, nil
+}
+
+func (ik *IdempotencyKeys) Complete(key string, statusCode int, headers, body []byte) error {
+	const fn = "db.postgres.IdempotencyKeys.Complete"
+	var query string
+
+	defer func(query *string) {
+		ik.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := ik.stmtBuilder.
+		Update("idempotency_keys").
+		Set("status_code", statusCode).
+		Set("headers", headers).
+		Set("body", body).
+		Where(squirrel.Eq{"key": key})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	_, err := queryBuilder.RunWith(ik.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (ik *IdempotencyKeys) Release(key string) error {
+	const fn = "db.postgres.IdempotencyKeys.Release"
+	var query string
+
+	defer func(query *string) {
+		ik.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := ik.stmtBuilder.
+		Delete("idempotency_keys").
+		Where(squirrel.Eq{"key": key})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	_, err := queryBuilder.RunWith(ik.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (ik *IdempotencyKeys) DeleteExpired() (int64, error) {
+	const fn = "db.postgres.IdempotencyKeys.DeleteExpired"
+	var query string
+
+	defer func(query *string) {
+		ik.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := ik.stmtBuilder.
+		Delete("idempotency_keys").
+		Where("expires_at < now()")
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(ik.db).Exec()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return rows, nil
+}
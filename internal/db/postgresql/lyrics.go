@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"effective-mobile-test/internal/entities"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"log/slog"
+)
+
+type Lyrics struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewLyrics(db *DB) *Lyrics {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Lyrics{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+// GetStale returns the songs of every tenant whose lyrics weren't analyzed
+// since their last update, the least recently updated first.
+func (ly *Lyrics) GetStale(limit int) (*[]entities.Song, error) {
+	const fn = "db.postgres.Lyrics.GetStale"
+	var query string
+
+	defer func(query *string) {
+		ly.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := ly.stmtBuilder.
+		Select("s.id", "s.tenant_id", "s.text", "s.updated_at").
+		From("song_library s").
+		LeftJoin("song_text_stats ts ON ts.song_id = s.id").
+		Where("ts.song_id IS NULL OR ts.analyzed_at < s.updated_at").
+		OrderBy("s.updated_at").
+		Limit(uint64(limit))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var songs []entities.Song
+	err = ly.db.Select(&songs, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &songs, nil
+}
+
+// Save replaces the counted terms of the song.
+func (ly *Lyrics) Save(stats *entities.TextStats) error {
+	const fn = "db.postgres.Lyrics.Save"
+
+	defer ly.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.Int("song", stats.SongID), slog.Int("terms", len(stats.Terms)))
+
+	tx, err := ly.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.Exec(`DELETE FROM song_terms WHERE song_id = $1`, stats.SongID); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if len(stats.Terms) > 0 {
+		queryBuilder := ly.stmtBuilder.
+			Insert("song_terms").
+			Columns("song_id", "term", "count", "stopword")
+
+		for _, term := range stats.Terms {
+			queryBuilder = queryBuilder.Values(stats.SongID, term.Term, term.Count, term.Stopword)
+		}
+
+		query, args, err := queryBuilder.ToSql()
+		if err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+
+		if _, err = tx.Exec(query, args...); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO song_text_stats (song_id, words, unique_words, analyzed_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (song_id) DO UPDATE
+		SET words = excluded.words, unique_words = excluded.unique_words, analyzed_at = excluded.analyzed_at`,
+		stats.SongID, stats.Words, stats.UniqueWords, stats.AnalyzedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return tx.Commit()
+}
+
+// GetGroupStats aggregates the analyzed lyrics of the songs in scope, with the
+// limit most frequent terms that aren't stopwords. Songs counts the ones with
+// lyrics.
+func (ly *Lyrics) GetGroupStats(tenant string, scope *entities.LyricsScope, limit int) (*entities.TextStats, error) {
+	const fn = "db.postgres.Lyrics.GetGroupStats"
+	var query string
+
+	defer func(query *string) {
+		ly.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	totalsBuilder := inScope(
+		ly.stmtBuilder.
+			Select("count(*) AS songs", "coalesce(sum(ts.words), 0) AS words").
+			From("song_library s").
+			Join("song_text_stats ts ON ts.song_id = s.id").
+			Where("ts.words > 0"),
+		tenant, scope,
+	)
+
+	uniqueBuilder := inScope(
+		ly.stmtBuilder.
+			Select("count(DISTINCT st.term)").
+			From("song_terms st").
+			Join("song_library s ON s.id = st.song_id"),
+		tenant, scope,
+	)
+
+	termsBuilder := inScope(
+		ly.stmtBuilder.
+			Select("st.term", "sum(st.count) AS count").
+			From("song_terms st").
+			Join("song_library s ON s.id = st.song_id").
+			Where("NOT st.stopword"),
+		tenant, scope,
+	).
+		GroupBy("st.term").
+		OrderBy("count DESC", "st.term").
+		Limit(uint64(limit))
+
+	query, args, err := totalsBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var stats entities.TextStats
+	if err = ly.db.Get(&stats, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	query, args, err = uniqueBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	if err = ly.db.Get(&stats.UniqueWords, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	query, args, err = termsBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	if err = ly.db.Select(&stats.Terms, query, args...); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &stats, nil
+}
+
+// inScope restricts the songs aliased s to the scope, see GetStats for the
+// release years.
+func inScope(queryBuilder squirrel.SelectBuilder, tenant string, scope *entities.LyricsScope) squirrel.SelectBuilder {
+	queryBuilder = queryBuilder.
+		Where(squirrel.Eq{"s.tenant_id": tenant}).
+		Where("s.group_key = song_name_key(?)", scope.Group)
+
+	const year = "substring(s.release_date FROM '\\d{4}')::int"
+
+	if scope.FromYear != nil {
+		queryBuilder = queryBuilder.Where(year+" >= ?", *scope.FromYear)
+	}
+
+	if scope.ToYear != nil {
+		queryBuilder = queryBuilder.Where(year+" <= ?", *scope.ToYear)
+	}
+
+	return queryBuilder
+}
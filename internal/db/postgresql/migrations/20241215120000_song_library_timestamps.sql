@@ -0,0 +1,71 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE SEQUENCE song_library_change_seq;
+
+ALTER TABLE song_library
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    ADD COLUMN change_seq BIGINT      NOT NULL DEFAULT nextval('song_library_change_seq');
+
+CREATE INDEX idx_song_library_updated_at ON song_library (updated_at);
+CREATE INDEX idx_song_library_change_seq ON song_library (change_seq);
+
+CREATE TABLE song_library_tombstones
+(
+    id         SERIAL PRIMARY KEY,
+    song_id    INTEGER      NOT NULL,
+    "group"    VARCHAR(255) NOT NULL,
+    song       VARCHAR(255) NOT NULL,
+    deleted_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    change_seq BIGINT       NOT NULL DEFAULT nextval('song_library_change_seq')
+);
+
+CREATE INDEX idx_song_library_tombstones_change_seq ON song_library_tombstones (change_seq);
+
+CREATE FUNCTION song_library_touch() RETURNS TRIGGER AS
+$$
+BEGIN
+    NEW.created_at = OLD.created_at;
+    NEW.updated_at = now();
+    NEW.change_seq = nextval('song_library_change_seq');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER song_library_touch
+    BEFORE UPDATE
+    ON song_library
+    FOR EACH ROW
+EXECUTE FUNCTION song_library_touch();
+
+CREATE FUNCTION song_library_tombstone() RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO song_library_tombstones (song_id, "group", song)
+    VALUES (OLD.id, OLD."group", OLD.song);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER song_library_tombstone
+    AFTER DELETE
+    ON song_library
+    FOR EACH ROW
+EXECUTE FUNCTION song_library_tombstone();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS song_library_tombstone ON song_library;
+DROP TRIGGER IF EXISTS song_library_touch ON song_library;
+DROP FUNCTION IF EXISTS song_library_tombstone();
+DROP FUNCTION IF EXISTS song_library_touch();
+DROP TABLE IF EXISTS song_library_tombstones;
+
+ALTER TABLE song_library
+    DROP COLUMN IF EXISTS change_seq,
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at;
+
+DROP SEQUENCE IF EXISTS song_library_change_seq
+-- +goose StatementEnd
@@ -0,0 +1,50 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE FUNCTION song_library_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'type', CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END,
+            'id', NEW.id,
+            'group', NEW."group",
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER song_library_notify
+    AFTER INSERT OR UPDATE
+    ON song_library
+    FOR EACH ROW
+EXECUTE FUNCTION song_library_notify();
+
+CREATE FUNCTION song_library_tombstones_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'type', 'deleted',
+            'id', NEW.song_id,
+            'group', NEW."group",
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER song_library_tombstones_notify
+    AFTER INSERT
+    ON song_library_tombstones
+    FOR EACH ROW
+EXECUTE FUNCTION song_library_tombstones_notify();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS song_library_tombstones_notify ON song_library_tombstones;
+DROP TRIGGER IF EXISTS song_library_notify ON song_library;
+DROP FUNCTION IF EXISTS song_library_tombstones_notify();
+DROP FUNCTION IF EXISTS song_library_notify()
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE webhooks
+(
+    id          SERIAL PRIMARY KEY,
+    url         TEXT        NOT NULL,
+    event_types TEXT[]      NOT NULL,
+    secret      TEXT        NOT NULL,
+    active      BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE webhook_deliveries
+(
+    id              SERIAL PRIMARY KEY,
+    webhook_id      INTEGER     NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id        BIGINT      NOT NULL,
+    event_type      TEXT        NOT NULL,
+    payload         JSONB       NOT NULL,
+    status          VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    response_status INTEGER,
+    error           TEXT,
+    redelivery_of   INTEGER REFERENCES webhook_deliveries (id) ON DELETE SET NULL,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    delivered_at    TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_event ON webhook_deliveries (webhook_id, event_id)
+    WHERE redelivery_of IS NULL;
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at)
+    WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks
+-- +goose StatementEnd
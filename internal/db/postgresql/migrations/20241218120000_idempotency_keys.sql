@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE idempotency_keys
+(
+    key          VARCHAR(255) PRIMARY KEY,
+    fingerprint  CHAR(64)    NOT NULL,
+    status_code  INTEGER,
+    content_type TEXT,
+    body         BYTEA,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys
+-- +goose StatementEnd
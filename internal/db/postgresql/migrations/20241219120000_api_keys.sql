@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE api_keys
+(
+    id           SERIAL PRIMARY KEY,
+    name         VARCHAR(255) NOT NULL,
+    prefix       VARCHAR(16)  NOT NULL,
+    key_hash     CHAR(64)     NOT NULL,
+    scopes       TEXT[]       NOT NULL,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE UNIQUE INDEX idx_api_keys_key_hash ON api_keys (key_hash);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS api_keys
+-- +goose StatementEnd
@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE roles
+(
+    id          SERIAL PRIMARY KEY,
+    name        VARCHAR(64)  NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    permissions TEXT[]       NOT NULL,
+    builtin     BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE TABLE role_assignments
+(
+    subject    VARCHAR(255) NOT NULL,
+    role_id    INTEGER      NOT NULL REFERENCES roles (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    PRIMARY KEY (subject, role_id)
+);
+
+INSERT INTO roles (name, description, permissions, builtin) VALUES
+('viewer', 'Reads songs and lyrics', ARRAY ['songs:read'], TRUE),
+('editor', 'Adds songs and changes their details and lyrics', ARRAY ['songs:read', 'songs:create', 'songs:update'], TRUE),
+('admin', 'Manages everything', ARRAY ['songs:read', 'songs:create', 'songs:update', 'songs:delete', 'roles:manage'], TRUE);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS role_assignments;
+DROP TABLE IF EXISTS roles
+-- +goose StatementEnd
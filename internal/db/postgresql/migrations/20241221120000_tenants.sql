@@ -0,0 +1,201 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tenants
+(
+    id         VARCHAR(63)  PRIMARY KEY CHECK (id ~ '^[a-z0-9][a-z0-9-]*$'),
+    name       VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+INSERT INTO tenants (id, name) VALUES ('default', 'Default');
+
+ALTER TABLE song_library
+    ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+
+ALTER TABLE song_library
+    DROP CONSTRAINT unique_group_song;
+
+ALTER TABLE song_library
+    ADD CONSTRAINT unique_tenant_group_song
+        UNIQUE (tenant_id, "group", song);
+
+ALTER TABLE song_library_tombstones
+    ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default';
+
+CREATE INDEX idx_song_library_tombstones_tenant ON song_library_tombstones (tenant_id, change_seq);
+
+ALTER TABLE webhooks
+    ADD COLUMN tenant_id VARCHAR(63) NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+
+CREATE INDEX idx_webhooks_tenant ON webhooks (tenant_id);
+
+ALTER TABLE api_keys
+    ADD COLUMN tenant_id VARCHAR(63) REFERENCES tenants (id);
+
+-- Role assignments hold in their tenant, the ones without a tenant in every
+-- tenant.
+ALTER TABLE role_assignments
+    ADD COLUMN tenant_id VARCHAR(63) REFERENCES tenants (id) ON DELETE CASCADE;
+
+ALTER TABLE role_assignments
+    DROP CONSTRAINT role_assignments_pkey;
+
+CREATE UNIQUE INDEX unique_role_assignment ON role_assignments (subject, role_id, coalesce(tenant_id, ''));
+
+-- Idempotency keys are stored as "<tenant>:<hash of the subject and the key>".
+ALTER TABLE idempotency_keys
+    ALTER COLUMN key TYPE VARCHAR(320);
+
+UPDATE roles
+SET permissions = array_append(permissions, 'tenants:manage')
+WHERE name = 'admin';
+
+CREATE OR REPLACE FUNCTION song_library_tombstone() RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO song_library_tombstones (song_id, tenant_id, "group", song)
+    VALUES (OLD.id, OLD.tenant_id, OLD."group", OLD.song);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION song_library_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'type', CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END,
+            'tenant', NEW.tenant_id,
+            'id', NEW.id,
+            'group', NEW."group",
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION song_library_tombstones_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'type', 'deleted',
+            'tenant', NEW.tenant_id,
+            'id', NEW.song_id,
+            'group', NEW."group",
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- The policies deny the sessions without app.tenant_id. The service starts
+-- its sessions with '*', which sees every tenant, and narrows them down to
+-- the tenant of the request with TENANT_RLS enabled.
+ALTER TABLE song_library ENABLE ROW LEVEL SECURITY;
+ALTER TABLE song_library FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY song_library_tenant ON song_library
+    USING (current_setting('app.tenant_id', TRUE) IN ('*', tenant_id));
+
+ALTER TABLE song_library_tombstones ENABLE ROW LEVEL SECURITY;
+ALTER TABLE song_library_tombstones FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY song_library_tombstones_tenant ON song_library_tombstones
+    USING (current_setting('app.tenant_id', TRUE) IN ('*', tenant_id));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP POLICY IF EXISTS song_library_tombstones_tenant ON song_library_tombstones;
+ALTER TABLE song_library_tombstones NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE song_library_tombstones DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS song_library_tenant ON song_library;
+ALTER TABLE song_library NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE song_library DISABLE ROW LEVEL SECURITY;
+
+CREATE OR REPLACE FUNCTION song_library_tombstones_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'type', 'deleted',
+            'id', NEW.song_id,
+            'group', NEW."group",
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION song_library_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'type', CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END,
+            'id', NEW.id,
+            'group', NEW."group",
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION song_library_tombstone() RETURNS TRIGGER AS
+$$
+BEGIN
+    INSERT INTO song_library_tombstones (song_id, "group", song)
+    VALUES (OLD.id, OLD."group", OLD.song);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+UPDATE roles
+SET permissions = array_remove(permissions, 'tenants:manage')
+WHERE name = 'admin';
+
+DELETE FROM idempotency_keys;
+
+ALTER TABLE idempotency_keys
+    ALTER COLUMN key TYPE VARCHAR(255);
+
+DELETE FROM role_assignments
+WHERE tenant_id IS NOT NULL;
+
+DROP INDEX IF EXISTS unique_role_assignment;
+
+ALTER TABLE role_assignments
+    DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE role_assignments
+    ADD PRIMARY KEY (subject, role_id);
+
+ALTER TABLE api_keys
+    DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE webhooks
+    DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE song_library_tombstones
+    DROP COLUMN IF EXISTS tenant_id;
+
+DELETE FROM song_library a
+    USING song_library b
+WHERE a."group" = b."group"
+  AND a.song = b.song
+  AND a.id > b.id;
+
+ALTER TABLE song_library
+    DROP CONSTRAINT IF EXISTS unique_tenant_group_song;
+
+ALTER TABLE song_library
+    DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE song_library
+    ADD CONSTRAINT unique_group_song
+        UNIQUE ("group", song);
+
+DROP TABLE IF EXISTS tenants
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE users
+(
+    id            SERIAL PRIMARY KEY,
+    tenant_id     VARCHAR(63)  NOT NULL REFERENCES tenants (id),
+    email         VARCHAR(255) NOT NULL,
+    name          VARCHAR(255) NOT NULL DEFAULT '',
+    password_hash VARCHAR(72)  NOT NULL,
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_users_tenant_email ON users (tenant_id, lower(email));
+
+CREATE TABLE user_sessions
+(
+    id           SERIAL PRIMARY KEY,
+    user_id      INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash   CHAR(64)    NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE UNIQUE INDEX idx_user_sessions_token_hash ON user_sessions (token_hash);
+CREATE INDEX idx_user_sessions_expires_at ON user_sessions (expires_at);
+
+CREATE TABLE favorites
+(
+    user_id    INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    song_id    INTEGER     NOT NULL REFERENCES song_library (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, song_id)
+);
+
+CREATE INDEX idx_favorites_user_created_at ON favorites (user_id, created_at DESC);
+CREATE INDEX idx_favorites_song ON favorites (song_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS favorites;
+DROP TABLE IF EXISTS user_sessions;
+DROP TABLE IF EXISTS users
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE playlists
+(
+    id               SERIAL PRIMARY KEY,
+    tenant_id        VARCHAR(63)  NOT NULL REFERENCES tenants (id),
+    owner_user_id    INTEGER REFERENCES users (id) ON DELETE CASCADE,
+    name             VARCHAR(255) NOT NULL,
+    description      TEXT         NOT NULL DEFAULT '',
+    visibility       VARCHAR(16)  NOT NULL DEFAULT 'private' CHECK (visibility IN ('private', 'public')),
+    allow_duplicates BOOLEAN      NOT NULL DEFAULT FALSE,
+    created_at       TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    updated_at       TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_playlists_tenant ON playlists (tenant_id, id);
+CREATE INDEX idx_playlists_owner ON playlists (owner_user_id);
+
+-- The entries are ordered by rank, spaced out so that a move only rewrites
+-- the moved entry until the gap between two neighbours runs out.
+CREATE TABLE playlist_entries
+(
+    id          SERIAL PRIMARY KEY,
+    playlist_id INTEGER     NOT NULL REFERENCES playlists (id) ON DELETE CASCADE,
+    song_id     INTEGER     NOT NULL REFERENCES song_library (id) ON DELETE CASCADE,
+    rank        BIGINT      NOT NULL,
+    added_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT unique_playlist_rank UNIQUE (playlist_id, rank) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX idx_playlist_entries_song ON playlist_entries (playlist_id, song_id);
+
+UPDATE roles
+SET permissions = array_append(permissions, 'playlists:manage')
+WHERE name IN ('editor', 'admin');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE roles
+SET permissions = array_remove(permissions, 'playlists:manage');
+
+DROP TABLE IF EXISTS playlist_entries;
+DROP TABLE IF EXISTS playlists
+-- +goose StatementEnd
@@ -0,0 +1,75 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE song_ratings
+(
+    id            SERIAL PRIMARY KEY,
+    song_id       INTEGER     NOT NULL REFERENCES song_library (id) ON DELETE CASCADE,
+    user_id       INTEGER     NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    rating        SMALLINT    NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    review        TEXT,
+    review_status VARCHAR(16) NOT NULL DEFAULT 'published' CHECK (review_status IN ('published', 'pending', 'rejected')),
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT unique_song_user_rating UNIQUE (song_id, user_id)
+);
+
+CREATE INDEX idx_song_ratings_user ON song_ratings (user_id, id);
+CREATE INDEX idx_song_ratings_pending ON song_ratings (id) WHERE review_status = 'pending';
+
+-- The aggregates live apart from song_library, so that rating a song doesn't
+-- bump its change_seq and emit song events.
+CREATE TABLE song_rating_stats
+(
+    song_id      INTEGER PRIMARY KEY REFERENCES song_library (id) ON DELETE CASCADE,
+    rating_count INTEGER     NOT NULL DEFAULT 0,
+    rating_sum   INTEGER     NOT NULL DEFAULT 0,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_song_rating_stats_avg ON song_rating_stats ((rating_sum::numeric / nullif(rating_count, 0)));
+
+CREATE FUNCTION song_ratings_stats() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        UPDATE song_rating_stats
+        SET rating_count = rating_count - 1,
+            rating_sum   = rating_sum - OLD.rating,
+            updated_at   = now()
+        WHERE song_id = OLD.song_id;
+    END IF;
+
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        INSERT INTO song_rating_stats (song_id, rating_count, rating_sum)
+        VALUES (NEW.song_id, 1, NEW.rating)
+        ON CONFLICT (song_id) DO UPDATE
+            SET rating_count = song_rating_stats.rating_count + 1,
+                rating_sum   = song_rating_stats.rating_sum + NEW.rating,
+                updated_at   = now();
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER song_ratings_stats
+    AFTER INSERT OR DELETE OR UPDATE OF rating
+    ON song_ratings
+    FOR EACH ROW
+EXECUTE FUNCTION song_ratings_stats();
+
+UPDATE roles
+SET permissions = array_append(permissions, 'reviews:moderate')
+WHERE name IN ('editor', 'admin');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE roles
+SET permissions = array_remove(permissions, 'reviews:moderate');
+
+DROP TRIGGER IF EXISTS song_ratings_stats ON song_ratings;
+DROP FUNCTION IF EXISTS song_ratings_stats();
+DROP TABLE IF EXISTS song_rating_stats;
+DROP TABLE IF EXISTS song_ratings
+-- +goose StatementEnd
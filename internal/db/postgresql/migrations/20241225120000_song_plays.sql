@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE song_plays_hourly
+(
+    song_id INTEGER     NOT NULL REFERENCES song_library (id) ON DELETE CASCADE,
+    hour    TIMESTAMPTZ NOT NULL,
+    plays   BIGINT      NOT NULL DEFAULT 0,
+    PRIMARY KEY (song_id, hour)
+);
+
+CREATE INDEX idx_song_plays_hourly_hour ON song_plays_hourly (hour);
+
+CREATE TABLE song_plays_daily
+(
+    song_id INTEGER NOT NULL REFERENCES song_library (id) ON DELETE CASCADE,
+    day     DATE    NOT NULL,
+    plays   BIGINT  NOT NULL DEFAULT 0,
+    PRIMARY KEY (song_id, day)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS song_plays_daily;
+DROP TABLE IF EXISTS song_plays_hourly
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE tags
+(
+    id         SERIAL PRIMARY KEY,
+    tenant_id  VARCHAR(63) NOT NULL REFERENCES tenants (id),
+    name       VARCHAR(63) NOT NULL,
+    kind       VARCHAR(16) NOT NULL DEFAULT 'tag' CHECK (kind IN ('genre', 'mood', 'tag')),
+    parent_id  INTEGER REFERENCES tags (id),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT unique_tenant_tag UNIQUE (tenant_id, name)
+);
+
+CREATE INDEX idx_tags_parent ON tags (parent_id);
+
+CREATE TABLE song_tags
+(
+    song_id INTEGER NOT NULL REFERENCES song_library (id) ON DELETE CASCADE,
+    tag_id  INTEGER NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (song_id, tag_id)
+);
+
+CREATE INDEX idx_song_tags_tag ON song_tags (tag_id, song_id);
+
+UPDATE roles
+SET permissions = array_append(permissions, 'tags:manage')
+WHERE name IN ('editor', 'admin');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE roles
+SET permissions = array_remove(permissions, 'tags:manage');
+
+DROP TABLE IF EXISTS song_tags;
+DROP TABLE IF EXISTS tags
+-- +goose StatementEnd
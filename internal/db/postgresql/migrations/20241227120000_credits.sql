@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE people
+(
+    id         SERIAL PRIMARY KEY,
+    tenant_id  VARCHAR(63)  NOT NULL REFERENCES tenants (id),
+    name       VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now(),
+    CONSTRAINT unique_tenant_person UNIQUE (tenant_id, name)
+);
+
+CREATE TABLE song_credits
+(
+    id         SERIAL PRIMARY KEY,
+    song_id    INTEGER     NOT NULL REFERENCES song_library (id) ON DELETE CASCADE,
+    person_id  INTEGER     NOT NULL REFERENCES people (id),
+    role       VARCHAR(16) NOT NULL CHECK (role IN ('primary', 'featured', 'writer', 'composer', 'producer')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT unique_song_credit UNIQUE (song_id, person_id, role)
+);
+
+CREATE INDEX idx_song_credits_person ON song_credits (person_id, role);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS song_credits;
+DROP TABLE IF EXISTS people
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE song_relations
+(
+    id         SERIAL PRIMARY KEY,
+    song_id    INTEGER     NOT NULL REFERENCES song_library (id) ON DELETE CASCADE,
+    related_id INTEGER     NOT NULL REFERENCES song_library (id) ON DELETE CASCADE,
+    kind       VARCHAR(16) NOT NULL CHECK (kind IN ('cover', 'remix', 'live', 'sample', 'translation')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CONSTRAINT unique_song_relation UNIQUE (song_id, related_id, kind),
+    CONSTRAINT check_song_relation_self CHECK (song_id <> related_id)
+);
+
+-- A song samples any number of others, but is a version of a single original.
+CREATE UNIQUE INDEX idx_song_relations_original ON song_relations (song_id, kind) WHERE kind <> 'sample';
+
+CREATE INDEX idx_song_relations_related ON song_relations (related_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS song_relations
+-- +goose StatementEnd
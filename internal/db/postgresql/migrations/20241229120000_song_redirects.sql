@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE song_redirects
+(
+    from_id    INTEGER PRIMARY KEY,
+    to_id      INTEGER      NOT NULL REFERENCES song_library (id) ON DELETE CASCADE,
+    tenant_id  VARCHAR(63)  NOT NULL REFERENCES tenants (id),
+    "group"    VARCHAR(255) NOT NULL,
+    song       VARCHAR(255) NOT NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_song_redirects_to ON song_redirects (to_id);
+CREATE INDEX idx_song_redirects_name ON song_redirects (tenant_id, "group", song);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS song_redirects
+-- +goose StatementEnd
@@ -0,0 +1,146 @@
+-- +goose Up
+-- +goose StatementBegin
+-- song_name_key is the canonical form of a group or song name: trimmed, with
+-- the inner whitespace collapsed, NFC-normalized and lower-cased. It is the
+-- only definition of the key, the service compares the keys the database
+-- computes. Lower-casing uses the root ICU collation rather than the one of
+-- the database, so that the key doesn't depend on its locale.
+CREATE FUNCTION song_name_key(name TEXT) RETURNS TEXT AS
+$$
+SELECT lower(normalize(btrim(regexp_replace(name, '\s+', ' ', 'g')), NFC) COLLATE "und-x-icu")
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE;
+
+ALTER TABLE song_library
+    ADD COLUMN group_key      TEXT GENERATED ALWAYS AS (song_name_key("group")) STORED,
+    ADD COLUMN song_key       TEXT GENERATED ALWAYS AS (song_name_key(song)) STORED,
+    ADD COLUMN name_collision BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX idx_song_library_name_key ON song_library (tenant_id, group_key, song_key);
+
+-- Songs of a tenant only differing in the canonical form of their names keep
+-- the oldest one as is and flag the others until they are merged into it. The
+-- names become unique by their keys in 20250103120000_song_name_keys_unique,
+-- which leaves the flagged songs out. The flags aren't changes of the songs.
+ALTER TABLE song_library DISABLE TRIGGER USER;
+
+UPDATE song_library s
+SET name_collision = true
+WHERE EXISTS (SELECT 1
+              FROM song_library o
+              WHERE o.tenant_id = s.tenant_id
+                AND o.group_key = s.group_key
+                AND o.song_key = s.song_key
+                AND o.id < s.id);
+
+ALTER TABLE song_library ENABLE TRIGGER USER;
+
+DO
+$$
+DECLARE
+    collisions TEXT;
+BEGIN
+    SELECT string_agg(format('%s: %s', tenant_id, names), E'\n')
+    INTO collisions
+    FROM (SELECT tenant_id, string_agg(format('%L / %L', "group", song), ', ' ORDER BY id) AS names
+          FROM song_library
+          GROUP BY tenant_id, group_key, song_key
+          HAVING count(*) > 1) c;
+
+    IF collisions IS NOT NULL THEN
+        RAISE WARNING 'songs differing only in case, whitespace or Unicode composition were flagged with name_collision'
+            USING DETAIL = collisions,
+                HINT = 'list them with GET /v1/songs/duplicates and merge them with POST /v1/songs/merge';
+    END IF;
+END
+$$;
+
+ALTER TABLE song_redirects
+    ADD COLUMN group_key TEXT GENERATED ALWAYS AS (song_name_key("group")) STORED,
+    ADD COLUMN song_key  TEXT GENERATED ALWAYS AS (song_name_key(song)) STORED;
+
+DROP INDEX idx_song_redirects_name;
+CREATE INDEX idx_song_redirects_name ON song_redirects (tenant_id, group_key, song_key);
+
+-- The events carry the key of the group, which the subscribers to a group are
+-- matched by.
+CREATE OR REPLACE FUNCTION song_library_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'type', CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END,
+            'tenant', NEW.tenant_id,
+            'id', NEW.id,
+            'group', NEW."group",
+            'groupKey', NEW.group_key,
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION song_library_tombstones_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'type', 'deleted',
+            'tenant', NEW.tenant_id,
+            'id', NEW.song_id,
+            'group', NEW."group",
+            'groupKey', song_name_key(NEW."group"),
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION song_library_tombstones_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'type', 'deleted',
+            'tenant', NEW.tenant_id,
+            'id', NEW.song_id,
+            'group', NEW."group",
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION song_library_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'type', CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END,
+            'tenant', NEW.tenant_id,
+            'id', NEW.id,
+            'group', NEW."group",
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX idx_song_redirects_name;
+CREATE INDEX idx_song_redirects_name ON song_redirects (tenant_id, "group", song);
+
+ALTER TABLE song_redirects
+    DROP COLUMN song_key,
+    DROP COLUMN group_key;
+
+DROP INDEX idx_song_library_name_key;
+
+ALTER TABLE song_library
+    DROP COLUMN name_collision,
+    DROP COLUMN song_key,
+    DROP COLUMN group_key;
+
+DROP FUNCTION IF EXISTS song_name_key(TEXT)
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE song_text_stats
+(
+    song_id      INTEGER PRIMARY KEY REFERENCES song_library (id) ON DELETE CASCADE,
+    words        INTEGER     NOT NULL,
+    unique_words INTEGER     NOT NULL,
+    analyzed_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE song_terms
+(
+    song_id  INTEGER NOT NULL REFERENCES song_library (id) ON DELETE CASCADE,
+    term     TEXT    NOT NULL,
+    count    INTEGER NOT NULL,
+    stopword BOOLEAN NOT NULL,
+    PRIMARY KEY (song_id, term)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS song_terms;
+DROP TABLE IF EXISTS song_text_stats
+-- +goose StatementEnd
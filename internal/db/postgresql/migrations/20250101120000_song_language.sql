@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE song_library
+    ADD COLUMN language            VARCHAR(8),
+    ADD COLUMN language_confidence REAL;
+
+CREATE INDEX idx_song_library_language ON song_library (tenant_id, language);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_song_library_language;
+
+ALTER TABLE song_library
+    DROP COLUMN IF EXISTS language_confidence,
+    DROP COLUMN IF EXISTS language
+-- +goose StatementEnd
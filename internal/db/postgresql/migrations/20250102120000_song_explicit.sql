@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE song_library
+    ADD COLUMN explicit          BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN explicit_override BOOLEAN
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE song_library
+    DROP COLUMN IF EXISTS explicit_override,
+    DROP COLUMN IF EXISTS explicit
+-- +goose StatementEnd
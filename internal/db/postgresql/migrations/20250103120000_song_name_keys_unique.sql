@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The songs flagged with name_collision are left out until they are merged,
+-- the merge clears the flag of the song left with the key.
+ALTER TABLE song_library
+    DROP CONSTRAINT unique_tenant_group_song;
+
+CREATE UNIQUE INDEX unique_tenant_group_song ON song_library (tenant_id, group_key, song_key)
+    WHERE NOT name_collision;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX unique_tenant_group_song;
+
+ALTER TABLE song_library
+    ADD CONSTRAINT unique_tenant_group_song
+        UNIQUE (tenant_id, "group", song)
+-- +goose StatementEnd
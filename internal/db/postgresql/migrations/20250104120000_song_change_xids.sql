@@ -0,0 +1,49 @@
+-- +goose Up
+-- +goose StatementBegin
+-- change_seq is taken when a change is written rather than when it commits, a
+-- transaction committing late shows up with a seq below the ones already
+-- read. change_xid is the transaction of the change, the changes are read in
+-- its order and only up to the oldest transaction still running, the ones
+-- below it are final.
+ALTER TABLE song_library
+    ADD COLUMN change_xid XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+ALTER TABLE song_library_tombstones
+    ADD COLUMN change_xid XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+CREATE INDEX idx_song_library_change_xid ON song_library (change_xid, change_seq);
+CREATE INDEX idx_song_library_tombstones_change_xid ON song_library_tombstones (change_xid, change_seq);
+
+CREATE OR REPLACE FUNCTION song_library_touch() RETURNS TRIGGER AS
+$$
+BEGIN
+    NEW.created_at = OLD.created_at;
+    NEW.updated_at = now();
+    NEW.change_seq = nextval('song_library_change_seq');
+    NEW.change_xid = pg_current_xact_id();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION song_library_touch() RETURNS TRIGGER AS
+$$
+BEGIN
+    NEW.created_at = OLD.created_at;
+    NEW.updated_at = now();
+    NEW.change_seq = nextval('song_library_change_seq');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_song_library_tombstones_change_xid;
+DROP INDEX IF EXISTS idx_song_library_change_xid;
+
+ALTER TABLE song_library_tombstones
+    DROP COLUMN IF EXISTS change_xid;
+
+ALTER TABLE song_library
+    DROP COLUMN IF EXISTS change_xid
+-- +goose StatementEnd
@@ -0,0 +1,82 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The events carry the oldest transaction running when they were written as
+-- their bound. The notifications go out in the commit order, the changes of
+-- the transactions below the bound are out before the event, so a stream is
+-- resumed by replaying the changes from the bound of the last event it got.
+CREATE OR REPLACE FUNCTION song_library_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'bound', pg_snapshot_xmin(pg_current_snapshot())::text::bigint,
+            'type', CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END,
+            'tenant', NEW.tenant_id,
+            'id', NEW.id,
+            'group', NEW."group",
+            'groupKey', NEW.group_key,
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION song_library_tombstones_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'bound', pg_snapshot_xmin(pg_current_snapshot())::text::bigint,
+            'type', 'deleted',
+            'tenant', NEW.tenant_id,
+            'id', NEW.song_id,
+            'group', NEW."group",
+            'groupKey', song_name_key(NEW."group"),
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- The deliveries are queued again from the newest bound after a restart.
+ALTER TABLE webhook_deliveries
+    ADD COLUMN event_bound BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE webhook_deliveries
+    DROP COLUMN IF EXISTS event_bound;
+
+CREATE OR REPLACE FUNCTION song_library_tombstones_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'type', 'deleted',
+            'tenant', NEW.tenant_id,
+            'id', NEW.song_id,
+            'group', NEW."group",
+            'groupKey', song_name_key(NEW."group"),
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION song_library_notify() RETURNS TRIGGER AS
+$$
+BEGIN
+    PERFORM pg_notify('song_library_events', json_build_object(
+            'seq', NEW.change_seq,
+            'type', CASE TG_OP WHEN 'INSERT' THEN 'created' ELSE 'updated' END,
+            'tenant', NEW.tenant_id,
+            'id', NEW.id,
+            'group', NEW."group",
+            'groupKey', NEW.group_key,
+            'song', NEW.song
+        )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The replayed responses carry the Location and the ETag along with the
+-- Content-Type, kept as a JSON object of the header names.
+ALTER TABLE idempotency_keys
+    ADD COLUMN headers JSONB;
+
+UPDATE idempotency_keys
+SET headers = jsonb_build_object('Content-Type', content_type)
+WHERE content_type IS NOT NULL;
+
+ALTER TABLE idempotency_keys
+    DROP COLUMN content_type;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE idempotency_keys
+    ADD COLUMN content_type TEXT;
+
+UPDATE idempotency_keys
+SET content_type = headers ->> 'Content-Type';
+
+ALTER TABLE idempotency_keys
+    DROP COLUMN headers
+-- +goose StatementEnd
@@ -0,0 +1,87 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Lower-casing alone keeps "Straße" and "STRASSE" apart. Upper-casing first
+-- with the full ICU mappings, ß to SS among them, folds the case the way the
+-- Unicode case folding does for the names. The key depends on the ICU version
+-- of the server, see the README for what to do after upgrading it.
+CREATE OR REPLACE FUNCTION song_name_key(name TEXT) RETURNS TEXT AS
+$$
+SELECT normalize(lower(upper(normalize(btrim(regexp_replace(name, '\s+', ' ', 'g')), NFC) COLLATE "und-x-icu")), NFC)
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE;
+
+-- The stored keys are computed again, the songs whose keys now match the
+-- ones of older songs are flagged with name_collision, as in
+-- 20241230120000_song_name_keys. Neither is a change of the songs.
+DROP INDEX unique_tenant_group_song;
+
+ALTER TABLE song_library DISABLE TRIGGER USER;
+
+UPDATE song_library
+SET "group" = "group",
+    song    = song;
+
+DO
+$$
+DECLARE
+    collisions TEXT;
+BEGIN
+    WITH flagged AS (
+        UPDATE song_library s
+            SET name_collision = true
+            WHERE NOT s.name_collision
+                AND EXISTS (SELECT 1
+                            FROM song_library o
+                            WHERE o.tenant_id = s.tenant_id
+                              AND o.group_key = s.group_key
+                              AND o.song_key = s.song_key
+                              AND NOT o.name_collision
+                              AND o.id < s.id)
+            RETURNING s.tenant_id, s."group", s.song)
+    SELECT string_agg(format('%s: %L / %L', tenant_id, "group", song), E'\n')
+    INTO collisions
+    FROM flagged;
+
+    IF collisions IS NOT NULL THEN
+        RAISE WARNING 'songs differing only in case folding were flagged with name_collision'
+            USING DETAIL = collisions,
+                HINT = 'list them with GET /v1/songs/duplicates and merge them with POST /v1/songs/merge';
+    END IF;
+END
+$$;
+
+ALTER TABLE song_library ENABLE TRIGGER USER;
+
+UPDATE song_redirects
+SET "group" = "group",
+    song    = song;
+
+CREATE UNIQUE INDEX unique_tenant_group_song ON song_library (tenant_id, group_key, song_key)
+    WHERE NOT name_collision;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION song_name_key(name TEXT) RETURNS TEXT AS
+$$
+SELECT lower(normalize(btrim(regexp_replace(name, '\s+', ' ', 'g')), NFC) COLLATE "und-x-icu")
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE;
+
+-- The lower-cased keys tell apart whatever the folded ones do, the flags
+-- stay.
+DROP INDEX unique_tenant_group_song;
+
+ALTER TABLE song_library DISABLE TRIGGER USER;
+
+UPDATE song_library
+SET "group" = "group",
+    song    = song;
+
+ALTER TABLE song_library ENABLE TRIGGER USER;
+
+UPDATE song_redirects
+SET "group" = "group",
+    song    = song;
+
+CREATE UNIQUE INDEX unique_tenant_group_song ON song_library (tenant_id, group_key, song_key)
+    WHERE NOT name_collision
+-- +goose StatementEnd
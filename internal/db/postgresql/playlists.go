@@ -0,0 +1,461 @@
+package postgres
+
+import (
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"log/slog"
+)
+
+// playlistRankStep is the gap left between the ranks of the neighbouring
+// entries on append and renumbering.
+const playlistRankStep = 1024
+
+var playlistColumns = []string{"id", "tenant_id", "owner_user_id", "name", "description", "visibility", "allow_duplicates", "created_at", "updated_at"}
+
+const playlistEntryColumns = `e.id, e.playlist_id, e.song_id, e.rank, s."group", s.song, e.added_at`
+
+type Playlists struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewPlaylists(db *DB) *Playlists {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Playlists{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+func (pl *Playlists) Create(playlist *entities.Playlist) (*entities.Playlist, error) {
+	const fn = "db.postgres.Playlists.Create"
+	var query string
+
+	defer func(query *string) {
+		pl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := pl.stmtBuilder.
+		Insert("playlists").
+		Columns("tenant_id", "owner_user_id", "name", "description", "visibility", "allow_duplicates").
+		Values(playlist.TenantID, playlist.OwnerUserID, playlist.Name, playlist.Description, playlist.Visibility, playlist.AllowDuplicates).
+		Suffix("RETURNING " + columns(playlistColumns))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var res entities.Playlist
+	err = pl.db.Get(&res, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &res, nil
+}
+
+func (pl *Playlists) Get(id int) (*entities.Playlist, error) {
+	const fn = "db.postgres.Playlists.Get"
+	var query string
+
+	defer func(query *string) {
+		pl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := pl.stmtBuilder.
+		Select(playlistColumns...).
+		From("playlists").
+		Where(squirrel.Eq{"id": id})
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var playlist entities.Playlist
+	err = pl.db.Get(&playlist, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &playlist, nil
+}
+
+// GetList returns the playlists of the tenant visible to the user: the public
+// ones, their own and, with withTenantOwned, the private ones of the tenant.
+func (pl *Playlists) GetList(tenant string, userID int, withTenantOwned bool, pagination *pagination.Pagination) (*[]entities.Playlist, error) {
+	const fn = "db.postgres.Playlists.GetList"
+	var query string
+
+	defer func(query *string) {
+		pl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	visible := squirrel.Or{
+		squirrel.Eq{"visibility": entities.PlaylistPublic},
+	}
+	if userID != 0 {
+		visible = append(visible, squirrel.Eq{"owner_user_id": userID})
+	}
+	if withTenantOwned {
+		visible = append(visible, squirrel.Eq{"owner_user_id": nil})
+	}
+
+	queryBuilder := pl.stmtBuilder.
+		Select(playlistColumns...).
+		From("playlists").
+		Where(squirrel.Eq{"tenant_id": tenant}).
+		Where(visible).
+		OrderBy("id")
+
+	queryBuilder = buildPagination(queryBuilder, pagination, 10)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var playlists []entities.Playlist
+	err = pl.db.Select(&playlists, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &playlists, nil
+}
+
+func (pl *Playlists) Update(id int, fields map[string]interface{}) error {
+	const fn = "db.postgres.Playlists.Update"
+	var query string
+
+	defer func(query *string) {
+		pl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := pl.stmtBuilder.
+		Update("playlists").
+		SetMap(fields).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": id})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(pl.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (pl *Playlists) Delete(id int) error {
+	const fn = "db.postgres.Playlists.Delete"
+	var query string
+
+	defer func(query *string) {
+		pl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := pl.stmtBuilder.
+		Delete("playlists").
+		Where(squirrel.Eq{"id": id})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(pl.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (pl *Playlists) GetEntries(playlistID int) (*[]entities.PlaylistEntry, error) {
+	const fn = "db.postgres.Playlists.GetEntries"
+
+	const query = `
+		SELECT ` + playlistEntryColumns + `, row_number() OVER (ORDER BY e.rank) - 1 AS position
+		FROM playlist_entries e
+		JOIN song_library s ON s.id = e.song_id
+		WHERE e.playlist_id = $1
+		ORDER BY e.rank`
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.String("query", query))
+
+	var entries []entities.PlaylistEntry
+	err := pl.db.Select(&entries, query, playlistID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &entries, nil
+}
+
+// AddEntry puts the song at the index, at the end when it's nil or past the
+// last entry. When the playlist doesn't allow duplicates and already has the
+// song, the existing entry is returned with added set to false.
+func (pl *Playlists) AddEntry(playlistID, songID int, index *int) (*entities.PlaylistEntry, bool, error) {
+	const fn = "db.postgres.Playlists.AddEntry"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.Int("playlistId", playlistID), slog.Int("songId", songID))
+
+	var entry *entities.PlaylistEntry
+	var added bool
+
+	err := pl.inTx(playlistID, func(tx *sqlx.Tx, playlist *entities.Playlist) error {
+		if !playlist.AllowDuplicates {
+			var existingID int
+			err := tx.Get(&existingID, `
+				SELECT id FROM playlist_entries
+				WHERE playlist_id = $1 AND song_id = $2
+				ORDER BY rank
+				LIMIT 1`, playlistID, songID)
+			if err == nil {
+				entry, err = getPlaylistEntry(tx, existingID)
+				return err
+			}
+			if !errors.Is(err, sql.ErrNoRows) {
+				return err
+			}
+		}
+
+		rank, err := placeAt(tx, playlistID, 0, index)
+		if err != nil {
+			return err
+		}
+
+		var id int
+		err = tx.Get(&id, `
+			INSERT INTO playlist_entries (playlist_id, song_id, rank)
+			VALUES ($1, $2, $3)
+			RETURNING id`, playlistID, songID, rank)
+		if err != nil {
+			return err
+		}
+
+		entry, err = getPlaylistEntry(tx, id)
+		added = true
+
+		return err
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return entry, added, nil
+}
+
+// MoveEntry puts the entry at the index among the other entries, at the end
+// when it's past the last one. Only the moved entry is rewritten unless its
+// new neighbours have no gap left between them.
+func (pl *Playlists) MoveEntry(playlistID, entryID, index int) error {
+	const fn = "db.postgres.Playlists.MoveEntry"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.Int("playlistId", playlistID), slog.Int("entryId", entryID), slog.Int("index", index))
+
+	err := pl.inTx(playlistID, func(tx *sqlx.Tx, _ *entities.Playlist) error {
+		if _, err := getPlaylistEntry(tx, entryID, playlistID); err != nil {
+			return err
+		}
+
+		rank, err := placeAt(tx, playlistID, entryID, &index)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`UPDATE playlist_entries SET rank = $1 WHERE id = $2`, rank, entryID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (pl *Playlists) RemoveEntry(playlistID, entryID int) error {
+	const fn = "db.postgres.Playlists.RemoveEntry"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.Int("playlistId", playlistID), slog.Int("entryId", entryID))
+
+	err := pl.inTx(playlistID, func(tx *sqlx.Tx, _ *entities.Playlist) error {
+		res, err := tx.Exec(`DELETE FROM playlist_entries WHERE id = $1 AND playlist_id = $2`, entryID, playlistID)
+		if err != nil {
+			return err
+		}
+
+		rows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+
+		if rows == 0 {
+			return sql.ErrNoRows
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// inTx runs fn in a transaction holding the lock of the playlist, so that the
+// concurrent changes of its entries don't pick the same ranks, and bumps the
+// playlist updated_at.
+func (pl *Playlists) inTx(playlistID int, fn func(tx *sqlx.Tx, playlist *entities.Playlist) error) error {
+	tx, err := pl.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var playlist entities.Playlist
+	err = tx.Get(&playlist, `SELECT `+columns(playlistColumns)+` FROM playlists WHERE id = $1 FOR UPDATE`, playlistID)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx, &playlist); err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec(`UPDATE playlists SET updated_at = now() WHERE id = $1`, playlistID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func getPlaylistEntry(tx *sqlx.Tx, id int, playlistID ...int) (*entities.PlaylistEntry, error) {
+	query := `
+		SELECT ` + playlistEntryColumns + `,
+			(SELECT count(*) FROM playlist_entries p WHERE p.playlist_id = e.playlist_id AND p.rank < e.rank) AS position
+		FROM playlist_entries e
+		JOIN song_library s ON s.id = e.song_id
+		WHERE e.id = $1`
+	args := []interface{}{id}
+	if len(playlistID) > 0 {
+		query += ` AND e.playlist_id = $2`
+		args = append(args, playlistID[0])
+	}
+
+	var entry entities.PlaylistEntry
+	if err := tx.Get(&entry, query, args...); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+// placeAt returns the rank putting an entry at the index among the entries
+// other than excludeID, renumbering them when there is no gap left there.
+func placeAt(tx *sqlx.Tx, playlistID, excludeID int, index *int) (int64, error) {
+	rank, ok, err := rankAt(tx, playlistID, excludeID, index)
+	if err != nil || ok {
+		return rank, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE playlist_entries e
+		SET rank = r.n * $2
+		FROM (SELECT id, row_number() OVER (ORDER BY rank) AS n
+		      FROM playlist_entries
+		      WHERE playlist_id = $1) r
+		WHERE e.id = r.id`, playlistID, playlistRankStep)
+	if err != nil {
+		return 0, err
+	}
+
+	rank, _, err = rankAt(tx, playlistID, excludeID, index)
+
+	return rank, err
+}
+
+func rankAt(tx *sqlx.Tx, playlistID, excludeID int, index *int) (int64, bool, error) {
+	if index != nil {
+		offset := *index - 1
+		if offset < 0 {
+			offset = 0
+		}
+
+		var ranks []int64
+		err := tx.Select(&ranks, `
+			SELECT rank FROM playlist_entries
+			WHERE playlist_id = $1 AND id <> $2
+			ORDER BY rank
+			LIMIT 2 OFFSET $3`, playlistID, excludeID, offset)
+		if err != nil {
+			return 0, false, err
+		}
+
+		switch {
+		case *index == 0 && len(ranks) == 0:
+			return playlistRankStep, true, nil
+		case *index == 0:
+			return ranks[0] - playlistRankStep, true, nil
+		case len(ranks) == 1:
+			return ranks[0] + playlistRankStep, true, nil
+		case len(ranks) == 2:
+			if ranks[1]-ranks[0] < 2 {
+				return 0, false, nil
+			}
+			return ranks[0] + (ranks[1]-ranks[0])/2, true, nil
+		}
+	}
+
+	var last int64
+	err := tx.Get(&last, `
+		SELECT coalesce(max(rank), 0) FROM playlist_entries
+		WHERE playlist_id = $1 AND id <> $2`, playlistID, excludeID)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return last + playlistRankStep, true, nil
+}
@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"log/slog"
+	"time"
+)
+
+type Plays struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewPlays(db *DB) *Plays {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Plays{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+// addPlaysChunk bounds the counts written by a single statement.
+const addPlaysChunk = 5000
+
+// AddPlays adds the batch to the hourly and the daily counters at once. The
+// counts of merged songs go to the survivor, the ones of deleted songs are
+// dropped.
+func (pl *Plays) AddPlays(counts []entities.PlayCount) error {
+	const fn = "db.postgres.Plays.AddPlays"
+
+	// The counts are passed as arrays, which keeps the parameters at three
+	// whatever the size of the batch, and are resolved against song_library,
+	// so a song deleted since it was played doesn't fail the whole batch.
+	const query = `
+		WITH counts AS (
+			SELECT s.id AS song_id, c.hour, sum(c.plays) AS plays
+			FROM unnest($1::int[], $2::timestamptz[], $3::bigint[]) AS c(song_id, hour, plays)
+			LEFT JOIN song_redirects r ON r.from_id = c.song_id
+			JOIN song_library s ON s.id = COALESCE(r.to_id, c.song_id)
+			GROUP BY s.id, c.hour
+		), hourly AS (
+			INSERT INTO song_plays_hourly (song_id, hour, plays)
+			SELECT song_id, hour, plays FROM counts
+			ON CONFLICT (song_id, hour) DO UPDATE SET plays = song_plays_hourly.plays + EXCLUDED.plays
+		)
+		INSERT INTO song_plays_daily (song_id, day, plays)
+		SELECT song_id, (hour AT TIME ZONE 'UTC')::date, sum(plays) FROM counts
+		GROUP BY song_id, (hour AT TIME ZONE 'UTC')::date
+		ON CONFLICT (song_id, day) DO UPDATE SET plays = song_plays_daily.plays + EXCLUDED.plays`
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.String("query", query), slog.Int("counts", len(counts)))
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	tx, err := pl.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for start := 0; start < len(counts); start += addPlaysChunk {
+		chunk := counts[start:min(start+addPlaysChunk, len(counts))]
+
+		songIDs := make([]int64, 0, len(chunk))
+		hours := make([]string, 0, len(chunk))
+		plays := make([]int64, 0, len(chunk))
+		for _, count := range chunk {
+			songIDs = append(songIDs, int64(count.SongID))
+			hours = append(hours, count.Hour.UTC().Format(time.RFC3339))
+			plays = append(plays, count.Plays)
+		}
+
+		if _, err = tx.Exec(query, pq.Array(songIDs), pq.Array(hours), pq.Array(plays)); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// GetTrending scores the songs of the tenant by their plays within the
+// window, each play weighing half as much every halfLife.
+func (pl *Plays) GetTrending(tenant string, window, halfLife time.Duration, pagination *pagination.Pagination) (*[]entities.TrendingSong, error) {
+	const fn = "db.postgres.Plays.GetTrending"
+	var query string
+
+	defer func(query *string) {
+		pl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := pl.stmtBuilder.
+		Select(qualify("s", songColumns)...).
+		Column("sum(p.plays * power(0.5, extract(epoch FROM now() - p.hour) / ?)) AS score", halfLife.Seconds()).
+		Column("sum(p.plays) AS plays").
+		From("song_plays_hourly p").
+		Join("song_library s ON s.id = p.song_id").
+		Where(squirrel.Eq{"s.tenant_id": tenant}).
+		Where("p.hour >= now() - make_interval(secs => ?)", window.Seconds()).
+		GroupBy("s.id").
+		OrderBy("score DESC", "s.id")
+
+	queryBuilder = buildPagination(queryBuilder, pagination, 10)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var songs []entities.TrendingSong
+	err = pl.db.Select(&songs, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &songs, nil
+}
+
+// GetStats returns the non-empty hourly or daily counters of the song within
+// [since, until).
+func (pl *Plays) GetStats(songID int, granularity string, since, until time.Time) (*[]entities.PlayBucket, error) {
+	const fn = "db.postgres.Plays.GetStats"
+	var query string
+
+	defer func(query *string) {
+		pl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	table, column := "song_plays_hourly", "hour"
+	if granularity == entities.PlaysDaily {
+		table, column = "song_plays_daily", "day"
+		since, until = since.Truncate(24*time.Hour), until.Truncate(24*time.Hour).Add(24*time.Hour)
+	}
+
+	queryBuilder := pl.stmtBuilder.
+		Select(column+" AS at", "plays").
+		From(table).
+		Where(squirrel.Eq{"song_id": songID}).
+		Where(squirrel.GtOrEq{column: since}).
+		Where(squirrel.Lt{column: until}).
+		OrderBy(column)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var buckets []entities.PlayBucket
+	err = pl.db.Select(&buckets, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &buckets, nil
+}
+
+// DeleteHourlyBefore drops the hourly counters older than before, the daily
+// ones are kept.
+func (pl *Plays) DeleteHourlyBefore(before time.Time) (int64, error) {
+	const fn = "db.postgres.Plays.DeleteHourlyBefore"
+	var query string
+
+	defer func(query *string) {
+		pl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := pl.stmtBuilder.
+		Delete("song_plays_hourly").
+		Where(squirrel.Lt{"hour": before})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(pl.db).Exec()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return rows, nil
+}
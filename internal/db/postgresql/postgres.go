@@ -1,13 +1,16 @@
 package postgres
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"embed"
+	"errors"
 	"fmt"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/pressly/goose/v3"
 	"log/slog"
-
-	_ "github.com/lib/pq"
 )
 
 type DB struct {
@@ -21,11 +24,13 @@ var migrations embed.FS
 func New(log *slog.Logger, dbPath string) (*DB, error) {
 	const fn = "db.postgres.New"
 
-	db, err := sqlx.Connect("postgres", dbPath)
+	connector, err := pq.NewConnector(dbPath)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", fn, err)
 	}
 
+	db := sqlx.NewDb(sql.OpenDB(&allTenantsConnector{connector}), "postgres")
+
 	err = db.Ping()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", fn, err)
@@ -51,3 +56,30 @@ func New(log *slog.Logger, dbPath string) (*DB, error) {
 		log: log,
 	}, nil
 }
+
+// allTenantsConnector starts the sessions with app.tenant_id set to '*', so
+// that the row-level security policies let them see every tenant, see
+// SongLibrary.withTenant for the sessions of a single one.
+type allTenantsConnector struct {
+	driver.Connector
+}
+
+func (c *allTenantsConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		_ = conn.Close()
+		return nil, errors.New("the driver connection doesn't execute statements")
+	}
+
+	if _, err = execer.ExecContext(ctx, "SET app.tenant_id = '*'", nil); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
@@ -0,0 +1,216 @@
+package postgres
+
+import (
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"log/slog"
+)
+
+var ratingListColumns = []string{
+	"r.id", "r.song_id", "r.user_id", "r.rating", "r.review", "r.review_status", "r.created_at", "r.updated_at",
+	`s."group"`, "s.song", "u.name AS author",
+}
+
+type Ratings struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewRatings(db *DB) *Ratings {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Ratings{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+func (rt *Ratings) Create(rating *entities.Rating) (*entities.Rating, error) {
+	const fn = "db.postgres.Ratings.Create"
+	var query string
+
+	defer func(query *string) {
+		rt.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rt.stmtBuilder.
+		Insert("song_ratings").
+		Columns("song_id", "user_id", "rating", "review", "review_status").
+		Values(rating.SongID, rating.UserID, rating.Rating, rating.Review, rating.ReviewStatus).
+		Suffix("RETURNING id, song_id, user_id, rating, review, review_status, created_at, updated_at")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var res entities.Rating
+	err = rt.db.Get(&res, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &res, nil
+}
+
+func (rt *Ratings) Update(songID, userID int, fields map[string]interface{}) error {
+	const fn = "db.postgres.Ratings.Update"
+	var query string
+
+	defer func(query *string) {
+		rt.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rt.stmtBuilder.
+		Update("song_ratings").
+		SetMap(fields).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"song_id": songID, "user_id": userID})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(rt.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (rt *Ratings) Delete(songID, userID int) error {
+	const fn = "db.postgres.Ratings.Delete"
+	var query string
+
+	defer func(query *string) {
+		rt.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rt.stmtBuilder.
+		Delete("song_ratings").
+		Where(squirrel.Eq{"song_id": songID, "user_id": userID})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(rt.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GetByUser returns the ratings of the user, the latest changed first.
+func (rt *Ratings) GetByUser(userID int, pagination *pagination.Pagination) (*[]entities.Rating, error) {
+	const fn = "db.postgres.Ratings.GetByUser"
+
+	return rt.getList(fn, squirrel.Eq{"r.user_id": userID}, pagination)
+}
+
+// GetReviews returns the published reviews of the song, the latest changed
+// first.
+func (rt *Ratings) GetReviews(songID int, pagination *pagination.Pagination) (*[]entities.Rating, error) {
+	const fn = "db.postgres.Ratings.GetReviews"
+
+	return rt.getList(fn, squirrel.And{
+		squirrel.Eq{"r.song_id": songID, "r.review_status": entities.ReviewPublished},
+		squirrel.NotEq{"r.review": nil},
+	}, pagination)
+}
+
+// GetPending returns the reviews of the tenant awaiting moderation.
+func (rt *Ratings) GetPending(tenant string, pagination *pagination.Pagination) (*[]entities.Rating, error) {
+	const fn = "db.postgres.Ratings.GetPending"
+
+	return rt.getList(fn, squirrel.Eq{"s.tenant_id": tenant, "r.review_status": entities.ReviewPending}, pagination)
+}
+
+// SetReviewStatus moderates the review of the tenant.
+func (rt *Ratings) SetReviewStatus(tenant string, id int, status string) error {
+	const fn = "db.postgres.Ratings.SetReviewStatus"
+
+	const query = `
+		UPDATE song_ratings r
+		SET review_status = $3
+		FROM song_library s
+		WHERE s.id = r.song_id AND s.tenant_id = $1 AND r.id = $2 AND r.review IS NOT NULL`
+
+	defer rt.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.String("query", query))
+
+	res, err := rt.db.Exec(query, tenant, id, status)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (rt *Ratings) getList(fn string, where squirrel.Sqlizer, pagination *pagination.Pagination) (*[]entities.Rating, error) {
+	var query string
+
+	defer func(query *string) {
+		rt.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rt.stmtBuilder.
+		Select(ratingListColumns...).
+		From("song_ratings r").
+		Join("song_library s ON s.id = r.song_id").
+		Join("users u ON u.id = r.user_id").
+		Where(where).
+		OrderBy("r.updated_at DESC", "r.id")
+
+	queryBuilder = buildPagination(queryBuilder, pagination, 10)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var ratings []entities.Rating
+	err = rt.db.Select(&ratings, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &ratings, nil
+}
@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"log/slog"
+)
+
+var relationColumns = []string{"id", "song_id", "related_id", "kind", "created_at"}
+
+type Relations struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewRelations(db *DB) *Relations {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Relations{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+func (rl *Relations) Add(songID, relatedID int, kind string) error {
+	const fn = "db.postgres.Relations.Add"
+	var query string
+
+	defer func(query *string) {
+		rl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rl.stmtBuilder.
+		Insert("song_relations").
+		Columns("song_id", "related_id", "kind").
+		Values(songID, relatedID, kind)
+
+	query, _, _ = queryBuilder.ToSql()
+
+	_, err := queryBuilder.RunWith(rl.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (rl *Relations) Remove(songID, relatedID int, kind string) error {
+	const fn = "db.postgres.Relations.Remove"
+	var query string
+
+	defer func(query *string) {
+		rl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rl.stmtBuilder.
+		Delete("song_relations").
+		Where(squirrel.Eq{"song_id": songID, "related_id": relatedID, "kind": kind})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(rl.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GetOriginals returns the songs the song is a version of or samples, named
+// in the Group and Song of the relations.
+func (rl *Relations) GetOriginals(songID int) (*[]entities.SongRelation, error) {
+	const fn = "db.postgres.Relations.GetOriginals"
+
+	return rl.get(fn, "related_id", squirrel.Eq{"r.song_id": songID})
+}
+
+// GetVersions returns the songs derived from the song, named in the Group and
+// Song of the relations.
+func (rl *Relations) GetVersions(songID int) (*[]entities.SongRelation, error) {
+	const fn = "db.postgres.Relations.GetVersions"
+
+	return rl.get(fn, "song_id", squirrel.Eq{"r.related_id": songID})
+}
+
+// get selects the relations along with the names of the songs joined by the
+// other column.
+func (rl *Relations) get(fn, other string, where squirrel.Eq) (*[]entities.SongRelation, error) {
+	var query string
+
+	defer func(query *string) {
+		rl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rl.stmtBuilder.
+		Select(append(qualify("r", relationColumns), `s."group"`, "s.song")...).
+		From("song_relations r").
+		Join("song_library s ON s.id = r." + other).
+		Where(where).
+		OrderBy("r.id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var relations []entities.SongRelation
+	err = rl.db.Select(&relations, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &relations, nil
+}
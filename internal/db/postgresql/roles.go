@@ -0,0 +1,296 @@
+package postgres
+
+import (
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"log/slog"
+)
+
+var roleColumns = []string{"id", "name", "description", "permissions", "builtin", "created_at"}
+
+type Roles struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewRoles(db *DB) *Roles {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Roles{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+func (rl *Roles) Create(name, description string, permissions []string) (*entities.Role, error) {
+	const fn = "db.postgres.Roles.Create"
+	var query string
+
+	defer func(query *string) {
+		rl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rl.stmtBuilder.
+		Insert("roles").
+		Columns("name", "description", "permissions").
+		Values(name, description, pq.Array(permissions)).
+		Suffix("RETURNING " + columns(roleColumns))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var role entities.Role
+	err = rl.db.Get(&role, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &role, nil
+}
+
+func (rl *Roles) Get(name string) (*entities.Role, error) {
+	const fn = "db.postgres.Roles.Get"
+	var query string
+
+	defer func(query *string) {
+		rl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rl.stmtBuilder.
+		Select(roleColumns...).
+		From("roles").
+		Where(squirrel.Eq{"name": name})
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var role entities.Role
+	err = rl.db.Get(&role, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &role, nil
+}
+
+func (rl *Roles) GetList() (*[]entities.Role, error) {
+	const fn = "db.postgres.Roles.GetList"
+	var query string
+
+	defer func(query *string) {
+		rl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rl.stmtBuilder.
+		Select(roleColumns...).
+		From("roles").
+		OrderBy("id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var roles []entities.Role
+	err = rl.db.Select(&roles, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &roles, nil
+}
+
+// Update only changes custom roles, built-in ones are reported as sql.ErrNoRows.
+func (rl *Roles) Update(name string, fields map[string]interface{}) error {
+	const fn = "db.postgres.Roles.Update"
+	var query string
+
+	defer func(query *string) {
+		rl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	if permissions, ok := fields["permissions"].([]string); ok {
+		fields["permissions"] = pq.Array(permissions)
+	}
+
+	queryBuilder := rl.stmtBuilder.
+		Update("roles").
+		SetMap(fields).
+		Where(squirrel.Eq{"name": name, "builtin": false})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(rl.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// Delete only removes custom roles, built-in ones are reported as sql.ErrNoRows.
+func (rl *Roles) Delete(name string) error {
+	const fn = "db.postgres.Roles.Delete"
+	var query string
+
+	defer func(query *string) {
+		rl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rl.stmtBuilder.
+		Delete("roles").
+		Where(squirrel.Eq{"name": name, "builtin": false})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(rl.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GetSubjectRoles returns the roles assigned to the subject in the tenant and
+// in every tenant, only the latter when the tenant is empty.
+func (rl *Roles) GetSubjectRoles(subject, tenant string) (*[]entities.Role, error) {
+	const fn = "db.postgres.Roles.GetSubjectRoles"
+	var query string
+
+	defer func(query *string) {
+		rl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	tenants := squirrel.Or{squirrel.Eq{"ra.tenant_id": nil}}
+	if tenant != "" {
+		tenants = append(tenants, squirrel.Eq{"ra.tenant_id": tenant})
+	}
+
+	queryBuilder := rl.stmtBuilder.
+		Select("r.id", "r.name", "r.description", "r.permissions", "r.builtin", "r.created_at").
+		Distinct().
+		From("role_assignments ra").
+		Join("roles r ON r.id = ra.role_id").
+		Where(squirrel.Eq{"ra.subject": subject}).
+		Where(tenants).
+		OrderBy("r.id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var roles []entities.Role
+	err = rl.db.Select(&roles, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &roles, nil
+}
+
+// Assign assigns the role to the subject in the tenant, in every tenant when
+// it's empty.
+func (rl *Roles) Assign(subject string, roleID int, tenant string) error {
+	const fn = "db.postgres.Roles.Assign"
+	var query string
+
+	defer func(query *string) {
+		rl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rl.stmtBuilder.
+		Insert("role_assignments").
+		Columns("subject", "role_id", "tenant_id").
+		Values(subject, roleID, tenantID(tenant)).
+		Suffix("ON CONFLICT DO NOTHING")
+
+	query, _, _ = queryBuilder.ToSql()
+
+	_, err := queryBuilder.RunWith(rl.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (rl *Roles) Unassign(subject string, roleID int, tenant string) error {
+	const fn = "db.postgres.Roles.Unassign"
+	var query string
+
+	defer func(query *string) {
+		rl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := rl.stmtBuilder.
+		Delete("role_assignments").
+		Where(squirrel.Eq{"subject": subject, "role_id": roleID, "tenant_id": tenantID(tenant)})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(rl.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// tenantID stores the empty tenant, meaning every tenant, as NULL.
+func tenantID(tenant string) interface{} {
+	if tenant == "" {
+		return nil
+	}
+	return tenant
+}
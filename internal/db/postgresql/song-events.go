@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"effective-mobile-test/internal/entities"
+	"encoding/json"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
+	"time"
+)
+
+const songEventsChannel = "song_library_events"
+
+type SongEvents struct {
+	*DB
+	dbPath string
+}
+
+func NewSongEvents(db *DB, dbPath string) *SongEvents {
+	return &SongEvents{
+		DB:     db,
+		dbPath: dbPath,
+	}
+}
+
+// Listen streams the song_library notifications until ctx is done.
+// A nil event means the connection was re-established and notifications
+// sent in the meantime may have been lost.
+func (se *SongEvents) Listen(ctx context.Context) (<-chan *entities.SongEvent, error) {
+	const fn = "db.postgres.SongEvents.Listen"
+
+	log := se.log.With(
+		slog.String("fn", fn),
+	)
+
+	listener := pq.NewListener(se.dbPath, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Error("listener event", slog.Int("event", int(ev)), slog.String("error", err.Error()))
+		}
+	})
+
+	if err := listener.Listen(songEventsChannel); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	events := make(chan *entities.SongEvent)
+
+	go func() {
+		defer close(events)
+		defer listener.Close()
+
+		ping := time.NewTicker(90 * time.Second)
+		defer ping.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ping.C:
+				go func() {
+					if err := listener.Ping(); err != nil {
+						log.Error("failed to ping listener", slog.String("error", err.Error()))
+					}
+				}()
+			case n := <-listener.Notify:
+				var event *entities.SongEvent
+
+				if n != nil {
+					event = &entities.SongEvent{}
+					if err := json.Unmarshal([]byte(n.Extra), event); err != nil {
+						log.Error("failed to decode notification", slog.String("error", err.Error()))
+
+						continue
+					}
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
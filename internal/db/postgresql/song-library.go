@@ -4,25 +4,74 @@ import (
 	"database/sql"
 	"effective-mobile-test/internal/entities"
 	"effective-mobile-test/internal/http/middlewares/pagination"
+	"errors"
 	"fmt"
 	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"log/slog"
+	"strings"
+	"time"
 )
 
+var songColumns = []string{"id", "tenant_id", `"group"`, "song", "release_date", "link", "text", "language", "language_confidence", "explicit", "explicit_override", "created_at", "updated_at"}
+
+// ratingColumns are the aggregates of song_rating_stats joined as rs.
+var ratingColumns = []string{
+	"round(rs.rating_sum::numeric / nullif(rs.rating_count, 0), 2) AS rating_avg",
+	"coalesce(rs.rating_count, 0) AS rating_count",
+	"rs.updated_at AS rated_at",
+}
+
+var songSorts = map[string]string{
+	"rating":       "rating_avg NULLS FIRST",
+	"-rating":      "rating_avg DESC NULLS LAST",
+	"ratingCount":  "rating_count",
+	"-ratingCount": "rating_count DESC",
+}
+
+// SongLibrary scopes every query to the tenant. With rls set the queries also
+// run with app.tenant_id, so that the row-level security policies back the
+// WHERE clauses up.
 type SongLibrary struct {
 	*DB
 	stmtBuilder squirrel.StatementBuilderType
+	rls         bool
 }
 
-func NewSongLibrary(db *DB) *SongLibrary {
+func NewSongLibrary(db *DB, rls bool) *SongLibrary {
 	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 
 	return &SongLibrary{
 		DB:          db,
 		stmtBuilder: stmtBuilder,
+		rls:         rls,
 	}
 }
 
+func (sl *SongLibrary) withTenant(tenant string, fn func(q sqlx.Ext) error) error {
+	if !sl.rls || tenant == "" {
+		return fn(sl.db)
+	}
+
+	tx, err := sl.db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec("SELECT set_config('app.tenant_id', $1, TRUE)", tenant); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
 func buildPagination(queryBuilder squirrel.SelectBuilder, pagination *pagination.Pagination, defaultLimit int) squirrel.SelectBuilder {
 	if pagination.Limit <= 0 && defaultLimit > 0 {
 		pagination.Limit = defaultLimit
@@ -39,7 +88,87 @@ func buildPagination(queryBuilder squirrel.SelectBuilder, pagination *pagination
 	return queryBuilder
 }
 
-func (sl *SongLibrary) Create(group, song string) error {
+func columns(names []string) string {
+	return strings.Join(names, ", ")
+}
+
+func qualify(table string, names []string) []string {
+	qualified := make([]string, 0, len(names))
+	for _, name := range names {
+		qualified = append(qualified, table+"."+name)
+	}
+	return qualified
+}
+
+// applyFilter narrows the songs down by the GetList filter, the values are
+// matched by their type: tag id groups, credits, languages, the clean mode, a
+// lower bound for the times and a substring for the rest.
+func applyFilter(queryBuilder squirrel.SelectBuilder, filter map[string]interface{}) squirrel.SelectBuilder {
+	for key, value := range filter {
+		switch v := value.(type) {
+		case [][]int:
+			// The songs tagged with any of the ids of every group.
+			for _, ids := range v {
+				queryBuilder = queryBuilder.Where(
+					"EXISTS (SELECT 1 FROM song_tags st WHERE st.song_id = song_library.id AND st.tag_id = ANY(?))",
+					pq.Array(ids),
+				)
+			}
+		case entities.CreditFilter:
+			queryBuilder = queryBuilder.Where(
+				"EXISTS (SELECT 1 FROM song_credits sc JOIN people p ON p.id = sc.person_id "+
+					"WHERE sc.song_id = song_library.id AND p.tenant_id = song_library.tenant_id AND lower(p.name) = lower(?) AND sc.role = ANY(?))",
+				v.Person, pq.Array(v.Roles),
+			)
+		case entities.CleanFilter:
+			queryBuilder = queryBuilder.Where("NOT coalesce(song_library.explicit_override, song_library.explicit)")
+		case entities.LanguageFilter:
+			queryBuilder = queryBuilder.Where("song_library.language = ANY(?)", pq.Array(v))
+		case time.Time:
+			queryBuilder = queryBuilder.Where(`song_library."`+key+`" >= ?`, v)
+		default:
+			queryBuilder = queryBuilder.Where(`song_library."`+key+`" LIKE ?`, fmt.Sprint("%", v, "%"))
+		}
+	}
+
+	return queryBuilder
+}
+
+// byName matches a single song of the tenant by the canonical forms of the
+// names, see song_name_key. The songs flagged with name_collision share the
+// key with another one and only match by their exact names, which win over
+// the key.
+func byName(tenant, group, song string) squirrel.Sqlizer {
+	return squirrel.Expr(`song_library.id = (
+			SELECT n.id
+			FROM song_library n
+			WHERE n.tenant_id = ? AND n.group_key = song_name_key(?) AND n.song_key = song_name_key(?)
+				AND ((n."group" = ? AND n.song = ?) OR NOT n.name_collision)
+			ORDER BY (n."group" = ? AND n.song = ?) DESC
+			LIMIT 1
+		)`,
+		tenant, group, song, group, song, group, song,
+	)
+}
+
+// byRedirectName matches the redirects by the canonical forms of the names.
+func byRedirectName(group, song string) squirrel.Sqlizer {
+	return squirrel.Expr("sr.group_key = song_name_key(?) AND sr.song_key = song_name_key(?)", group, song)
+}
+
+// GetNameKey returns the song_name_key of the name.
+func (sl *SongLibrary) GetNameKey(name string) (string, error) {
+	const fn = "sl.postgres.SongLibrary.GetNameKey"
+
+	var key string
+	if err := sl.db.Get(&key, `SELECT song_name_key($1)`, name); err != nil {
+		return "", fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return key, nil
+}
+
+func (sl *SongLibrary) Create(tenant, group, song string) error {
 	const fn = "sl.postgres.SongLibrary.Create"
 	var query string
 
@@ -49,12 +178,15 @@ func (sl *SongLibrary) Create(group, song string) error {
 
 	queryBuilder := sl.stmtBuilder.
 		Insert("song_library").
-		Columns(`"group"`, "song").
-		Values(group, song)
+		Columns("tenant_id", `"group"`, "song").
+		Values(tenant, group, song)
 
 	query, _, _ = queryBuilder.ToSql()
 
-	_, err := queryBuilder.RunWith(sl.db).Exec()
+	err := sl.withTenant(tenant, func(q sqlx.Ext) error {
+		_, err := queryBuilder.RunWith(q).Exec()
+		return err
+	})
 
 	if err != nil {
 		return fmt.Errorf("%w: %s", err, fn)
@@ -63,7 +195,7 @@ func (sl *SongLibrary) Create(group, song string) error {
 	return nil
 }
 
-func (sl *SongLibrary) Get(group, song string) (*entities.Song, error) {
+func (sl *SongLibrary) Get(tenant, group, song string) (*entities.Song, error) {
 	const fn = "sl.postgres.SongLibrary.Get"
 	var query string
 
@@ -73,10 +205,63 @@ func (sl *SongLibrary) Get(group, song string) (*entities.Song, error) {
 		).Debug("", slog.String("query", *query))
 	}(&query)
 
-	queryBuilder := sl.stmtBuilder.
-		Select("text").
+	selectBuilder := sl.stmtBuilder.
+		Select(qualify("song_library", songColumns)...).
+		Columns(ratingColumns...).
 		From("song_library").
-		Where(squirrel.Eq{`"group"`: group, `"song"`: song})
+		LeftJoin("song_rating_stats rs ON rs.song_id = song_library.id")
+
+	queryBuilder := selectBuilder.
+		Where(squirrel.Eq{"tenant_id": tenant}).
+		Where(byName(tenant, group, song))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var songRes entities.Song
+	err = sl.withTenant(tenant, func(q sqlx.Ext) error {
+		return sqlx.Get(q, &songRes, query, args...)
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// The song may have been merged into another one.
+		query, args, err = selectBuilder.
+			Join("song_redirects sr ON sr.to_id = song_library.id").
+			Where(squirrel.Eq{"sr.tenant_id": tenant}).
+			Where(byRedirectName(group, song)).
+			ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		err = sl.withTenant(tenant, func(q sqlx.Ext) error {
+			return sqlx.Get(q, &songRes, query, args...)
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &songRes, nil
+}
+
+func (sl *SongLibrary) GetByID(id int) (*entities.Song, error) {
+	const fn = "sl.postgres.SongLibrary.GetByID"
+	var query string
+
+	defer func(query *string) {
+		sl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	selectBuilder := sl.stmtBuilder.
+		Select(songColumns...).
+		From("song_library")
+
+	queryBuilder := selectBuilder.
+		Where(squirrel.Eq{"id": id})
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -85,6 +270,17 @@ func (sl *SongLibrary) Get(group, song string) (*entities.Song, error) {
 
 	var songRes entities.Song
 	err = sl.db.Get(&songRes, query, args...)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The song may have been merged into another one.
+		query, args, err = selectBuilder.
+			Where("id = (SELECT to_id FROM song_redirects WHERE from_id = ?)", id).
+			ToSql()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		err = sl.db.Get(&songRes, query, args...)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", fn, err)
 	}
@@ -92,7 +288,8 @@ func (sl *SongLibrary) Get(group, song string) (*entities.Song, error) {
 	return &songRes, nil
 }
 
-func (sl *SongLibrary) GetList(filter map[string]interface{}, pagination *pagination.Pagination) (*[]entities.Song, error) {
+// GetList orders the songs by one of songSorts, by id when sort is empty.
+func (sl *SongLibrary) GetList(tenant string, filter map[string]interface{}, sort string, pagination *pagination.Pagination) (*[]entities.Song, error) {
 	const fn = "sl.postgres.SongLibrary.GetList"
 	var query string
 
@@ -103,15 +300,20 @@ func (sl *SongLibrary) GetList(filter map[string]interface{}, pagination *pagina
 	}(&query)
 
 	queryBuilder := sl.stmtBuilder.
-		Select(`"group"`, "song", "release_date", "link", "text").
+		Select(qualify("song_library", songColumns)...).
+		Columns(ratingColumns...).
 		From("song_library").
-		OrderBy("id")
+		LeftJoin("song_rating_stats rs ON rs.song_id = song_library.id").
+		Where(squirrel.Eq{"tenant_id": tenant})
+
+	if orderBy, ok := songSorts[sort]; ok {
+		queryBuilder = queryBuilder.OrderBy(orderBy)
+	}
+	queryBuilder = queryBuilder.OrderBy("id")
 
 	queryBuilder = buildPagination(queryBuilder, pagination, 10)
 
-	for key, value := range filter {
-		queryBuilder = queryBuilder.Where(`"`+key+`" LIKE ?`, fmt.Sprint("%", value, "%"))
-	}
+	queryBuilder = applyFilter(queryBuilder, filter)
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
@@ -119,7 +321,9 @@ func (sl *SongLibrary) GetList(filter map[string]interface{}, pagination *pagina
 	}
 
 	var songs []entities.Song
-	err = sl.db.Select(&songs, query, args...)
+	err = sl.withTenant(tenant, func(q sqlx.Ext) error {
+		return sqlx.Select(q, &songs, query, args...)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", fn, err)
 	}
@@ -127,7 +331,159 @@ func (sl *SongLibrary) GetList(filter map[string]interface{}, pagination *pagina
 	return &songs, nil
 }
 
-func (sl *SongLibrary) Update(group, song string, fields map[string]interface{}) error {
+// GetStats aggregates the songs of the tenant matching the GetList filter.
+// The groups are counted by their canonical names and shown as the first
+// spelling of each.
+func (sl *SongLibrary) GetStats(tenant string, filter map[string]interface{}) (*entities.LibraryStats, error) {
+	const fn = "sl.postgres.SongLibrary.GetStats"
+	var query string
+
+	defer func(query *string) {
+		sl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	// The subquery keeps the ? placeholders, the outer queries number them.
+	songs := applyFilter(
+		squirrel.
+			Select("song_library.id", `song_library."group"`, "song_library.group_key", "release_date", "link", "text").
+			From("song_library").
+			Where(squirrel.Eq{"song_library.tenant_id": tenant}),
+		filter,
+	)
+
+	totalsBuilder := sl.stmtBuilder.
+		Select(
+			"count(*) AS songs",
+			"count(*) FILTER (WHERE coalesce(text, '') = '') AS without_text",
+			"count(*) FILTER (WHERE coalesce(link, '') = '') AS without_link",
+			"count(*) FILTER (WHERE coalesce(release_date, '') = '') AS without_release_date",
+			"round(avg(length(text)) FILTER (WHERE coalesce(text, '') <> ''), 1) AS avg_text_length",
+		).
+		FromSelect(songs, "s")
+
+	groupsBuilder := sl.stmtBuilder.
+		Select(`(array_agg("group" ORDER BY id))[1] AS "group"`, "count(*) AS songs").
+		FromSelect(songs, "s").
+		GroupBy("group_key").
+		OrderBy("songs DESC", `"group"`)
+
+	// Both dd.mm.yyyy and yyyy-mm-dd dates have a single run of four digits.
+	yearsBuilder := sl.stmtBuilder.
+		Select("substring(release_date FROM '\\d{4}')::int AS year", "count(*) AS songs").
+		FromSelect(songs, "s").
+		Where("release_date ~ '\\d{4}'").
+		GroupBy("year").
+		OrderBy("year")
+
+	var stats entities.LibraryStats
+	err := sl.withTenant(tenant, func(q sqlx.Ext) error {
+		var args []interface{}
+		var err error
+
+		query, args, err = totalsBuilder.ToSql()
+		if err != nil {
+			return err
+		}
+		if err = sqlx.Get(q, &stats, query, args...); err != nil {
+			return err
+		}
+
+		query, args, err = groupsBuilder.ToSql()
+		if err != nil {
+			return err
+		}
+		if err = sqlx.Select(q, &stats.Groups, query, args...); err != nil {
+			return err
+		}
+
+		query, args, err = yearsBuilder.ToSql()
+		if err != nil {
+			return err
+		}
+		return sqlx.Select(q, &stats.Years, query, args...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &stats, nil
+}
+
+// songChangesQuery selects the changes matching the condition, of the tenant
+// in $3 or of every tenant when it's empty, $2 at most.
+const songChangesQuery = `
+	SELECT change_seq, change_xid::text::bigint AS change_xid,
+	       pg_snapshot_xmin(pg_current_snapshot())::text::bigint AS bound, type, changed_at, group_key, id, tenant_id, "group", song,
+	       release_date, link, text, language, language_confidence, explicit, explicit_override, created_at, updated_at
+	FROM (
+		SELECT change_seq, change_xid, 'upsert' AS type, updated_at AS changed_at, group_key,
+		       id, tenant_id, "group", song, release_date, link, text,
+		       language, language_confidence, explicit, explicit_override, created_at, updated_at
+		FROM song_library
+		WHERE %[1]s AND ($3 = '' OR tenant_id = $3)
+		UNION ALL
+		SELECT change_seq, change_xid, 'delete' AS type, deleted_at AS changed_at, song_name_key("group"),
+		       song_id AS id, tenant_id, "group", song, NULL, NULL, NULL, NULL, NULL, false, NULL, deleted_at, deleted_at
+		FROM song_library_tombstones
+		WHERE %[1]s AND ($3 = '' OR tenant_id = $3)
+	) AS changes
+	ORDER BY %[2]s
+	LIMIT $2`
+
+// GetChanges returns the changes of the tenant after the position, of every
+// tenant when it's empty, in the position order. Unlike GetSettledChanges it
+// returns the changes of the transactions committed after the running ones
+// too.
+func (sl *SongLibrary) GetChanges(tenant string, after entities.ChangePosition, limit int) (*[]entities.SongChange, error) {
+	const fn = "sl.postgres.SongLibrary.GetChanges"
+
+	query := fmt.Sprintf(songChangesQuery, "(change_xid, change_seq) > ($1::xid8, $4::bigint)", "change_xid, change_seq")
+
+	defer sl.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.String("query", query))
+
+	var changes []entities.SongChange
+	err := sl.withTenant(tenant, func(q sqlx.Ext) error {
+		return sqlx.Select(q, &changes, query, after.Xid, limit, tenant, after.Seq)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &changes, nil
+}
+
+// GetSettledChanges returns the changes of the tenant after the position, of
+// every tenant when it's empty, in the position order. Only the changes of the
+// transactions older than the oldest one still running are returned, no change
+// can commit later before them.
+func (sl *SongLibrary) GetSettledChanges(tenant string, after entities.ChangePosition, limit int) (*[]entities.SongChange, error) {
+	const fn = "sl.postgres.SongLibrary.GetSettledChanges"
+
+	query := fmt.Sprintf(songChangesQuery,
+		"(change_xid, change_seq) > ($1::xid8, $4::bigint) AND change_xid < pg_snapshot_xmin(pg_current_snapshot())",
+		"change_xid, change_seq",
+	)
+
+	defer sl.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.String("query", query))
+
+	var changes []entities.SongChange
+	err := sl.withTenant(tenant, func(q sqlx.Ext) error {
+		return sqlx.Select(q, &changes, query, after.Xid, limit, tenant, after.Seq)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &changes, nil
+}
+
+func (sl *SongLibrary) Update(tenant, group, song string, fields map[string]interface{}) error {
 	const fn = "sl.postgres.SongLibrary.Update"
 	var query string
 
@@ -138,16 +494,59 @@ func (sl *SongLibrary) Update(group, song string, fields map[string]interface{})
 	queryBuilder := sl.stmtBuilder.
 		Update("song_library").
 		SetMap(fields).
-		Where(squirrel.Eq{`"group"`: group, `"song"`: song})
+		Where(squirrel.Eq{"tenant_id": tenant}).
+		Where(byName(tenant, group, song))
 
 	query, _, _ = queryBuilder.ToSql()
 
-	res, err := queryBuilder.RunWith(sl.db).Exec()
+	var rows int64
+	err := sl.withTenant(tenant, func(q sqlx.Ext) error {
+		res, err := queryBuilder.RunWith(q).Exec()
+		if err != nil {
+			return err
+		}
+
+		rows, err = res.RowsAffected()
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", fn, err)
 	}
 
-	rows, err := res.RowsAffected()
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (sl *SongLibrary) Delete(tenant, group, song string) error {
+	const fn = "sl.postgres.SongLibrary.Delete"
+	var query string
+
+	defer func(query *string) {
+		sl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := sl.stmtBuilder.
+		Delete("song_library").
+		Where(squirrel.Eq{"tenant_id": tenant}).
+		Where(byName(tenant, group, song))
+
+	query, _, _ = queryBuilder.ToSql()
+
+	var rows int64
+	err := sl.withTenant(tenant, func(q sqlx.Ext) error {
+		res, err := queryBuilder.RunWith(q).Exec()
+		if err != nil {
+			return err
+		}
+
+		rows, err = res.RowsAffected()
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("%s: %w", fn, err)
 	}
@@ -159,8 +558,10 @@ func (sl *SongLibrary) Update(group, song string, fields map[string]interface{})
 	return nil
 }
 
-func (sl *SongLibrary) Delete(group, song string) error {
-	const fn = "sl.postgres.SongLibrary.Delete"
+// GetTexts returns the songs of every tenant with lyrics after the id in id
+// order, only the ones without a language unless all is set.
+func (sl *SongLibrary) GetTexts(afterID, limit int, all bool) (*[]entities.Song, error) {
+	const fn = "sl.postgres.SongLibrary.GetTexts"
 	var query string
 
 	defer func(query *string) {
@@ -170,24 +571,94 @@ func (sl *SongLibrary) Delete(group, song string) error {
 	}(&query)
 
 	queryBuilder := sl.stmtBuilder.
-		Delete("song_library").
-		Where(squirrel.Eq{`"group"`: group, `"song"`: song})
+		Select(songColumns...).
+		From("song_library").
+		Where("id > ?", afterID).
+		Where("coalesce(text, '') <> ''").
+		OrderBy("id").
+		Limit(uint64(limit))
+
+	if !all {
+		queryBuilder = queryBuilder.Where("language IS NULL")
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var songs []entities.Song
+	err = sl.db.Select(&songs, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &songs, nil
+}
+
+// SetLanguage stores the detected language of the song. The song is only
+// touched when the language changes, so reruns don't emit song events.
+func (sl *SongLibrary) SetLanguage(id int, language *string, confidence *float64) (bool, error) {
+	const fn = "sl.postgres.SongLibrary.SetLanguage"
+	var query string
+
+	defer func(query *string) {
+		sl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := sl.stmtBuilder.
+		Update("song_library").
+		Set("language", language).
+		Set("language_confidence", confidence).
+		Where(squirrel.Eq{"id": id}).
+		Where("(language, language_confidence) IS DISTINCT FROM (?::varchar, ?::real)", language, confidence)
 
 	query, _, _ = queryBuilder.ToSql()
 
 	res, err := queryBuilder.RunWith(sl.db).Exec()
 	if err != nil {
-		return fmt.Errorf("%s: %w", fn, err)
+		return false, fmt.Errorf("%s: %w", fn, err)
 	}
 
 	rows, err := res.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("%s: %w", fn, err)
+		return false, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	return rows > 0, nil
+}
+
+// SetExplicit stores the detected explicit flag of the song, the song is only
+// touched when the flag changes.
+func (sl *SongLibrary) SetExplicit(id int, explicit bool) (bool, error) {
+	const fn = "sl.postgres.SongLibrary.SetExplicit"
+	var query string
+
+	defer func(query *string) {
+		sl.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := sl.stmtBuilder.
+		Update("song_library").
+		Set("explicit", explicit).
+		Where(squirrel.Eq{"id": id}).
+		Where(squirrel.NotEq{"explicit": explicit})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(sl.db).Exec()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	return nil
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return rows > 0, nil
 }
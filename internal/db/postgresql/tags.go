@@ -0,0 +1,392 @@
+package postgres
+
+import (
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"log/slog"
+)
+
+var tagColumns = []string{"id", "tenant_id", "name", "kind", "parent_id", "created_at"}
+
+type Tags struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewTags(db *DB) *Tags {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Tags{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+func (tg *Tags) Create(tag *entities.Tag) (*entities.Tag, error) {
+	const fn = "db.postgres.Tags.Create"
+	var query string
+
+	defer func(query *string) {
+		tg.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tg.stmtBuilder.
+		Insert("tags").
+		Columns("tenant_id", "name", "kind", "parent_id").
+		Values(tag.TenantID, tag.Name, tag.Kind, tag.ParentID).
+		Suffix("RETURNING " + columns(tagColumns))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var res entities.Tag
+	err = tg.db.Get(&res, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &res, nil
+}
+
+func (tg *Tags) Get(tenant string, id int) (*entities.Tag, error) {
+	const fn = "db.postgres.Tags.Get"
+	var query string
+
+	defer func(query *string) {
+		tg.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tg.stmtBuilder.
+		Select(tagColumns...).
+		From("tags").
+		Where(squirrel.Eq{"tenant_id": tenant, "id": id})
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var tag entities.Tag
+	err = tg.db.Get(&tag, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &tag, nil
+}
+
+// GetList returns the tags of the tenant, only the ones of the kind unless
+// it's empty.
+func (tg *Tags) GetList(tenant, kind string, pagination *pagination.Pagination) (*[]entities.Tag, error) {
+	const fn = "db.postgres.Tags.GetList"
+	var query string
+
+	defer func(query *string) {
+		tg.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tg.stmtBuilder.
+		Select(tagColumns...).
+		From("tags").
+		Where(squirrel.Eq{"tenant_id": tenant}).
+		OrderBy("name")
+
+	if kind != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"kind": kind})
+	}
+
+	queryBuilder = buildPagination(queryBuilder, pagination, 50)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var tags []entities.Tag
+	err = tg.db.Select(&tags, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &tags, nil
+}
+
+func (tg *Tags) GetByNames(tenant string, names []string) (*[]entities.Tag, error) {
+	const fn = "db.postgres.Tags.GetByNames"
+	var query string
+
+	defer func(query *string) {
+		tg.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tg.stmtBuilder.
+		Select(tagColumns...).
+		From("tags").
+		Where(squirrel.Eq{"tenant_id": tenant}).
+		Where("name = ANY(?)", pq.Array(names))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var tags []entities.Tag
+	err = tg.db.Select(&tags, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &tags, nil
+}
+
+func (tg *Tags) Update(tenant string, id int, fields map[string]interface{}) error {
+	const fn = "db.postgres.Tags.Update"
+	var query string
+
+	defer func(query *string) {
+		tg.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tg.stmtBuilder.
+		Update("tags").
+		SetMap(fields).
+		Where(squirrel.Eq{"tenant_id": tenant, "id": id})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(tg.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (tg *Tags) Delete(tenant string, id int) error {
+	const fn = "db.postgres.Tags.Delete"
+	var query string
+
+	defer func(query *string) {
+		tg.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tg.stmtBuilder.
+		Delete("tags").
+		Where(squirrel.Eq{"tenant_id": tenant, "id": id})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(tg.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// Expand maps each of the tag names to the ids of the tag and of all its
+// descendants, the unknown names are left out.
+func (tg *Tags) Expand(tenant string, names []string) (map[string][]int, error) {
+	const fn = "db.postgres.Tags.Expand"
+
+	const query = `
+		WITH RECURSIVE tree AS (
+			SELECT id, name AS root
+			FROM tags
+			WHERE tenant_id = $1 AND name = ANY($2)
+			UNION
+			SELECT t.id, tree.root
+			FROM tags t
+			JOIN tree ON t.parent_id = tree.id
+		)
+		SELECT root, id FROM tree`
+
+	defer tg.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.String("query", query))
+
+	var rows []struct {
+		Root string `db:"root"`
+		ID   int    `db:"id"`
+	}
+	err := tg.db.Select(&rows, query, tenant, pq.Array(names))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	expanded := make(map[string][]int, len(names))
+	for _, row := range rows {
+		expanded[row.Root] = append(expanded[row.Root], row.ID)
+	}
+
+	return expanded, nil
+}
+
+func (tg *Tags) AddSongTags(songID int, tagIDs []int) error {
+	const fn = "db.postgres.Tags.AddSongTags"
+	var query string
+
+	defer func(query *string) {
+		tg.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tg.stmtBuilder.
+		Insert("song_tags").
+		Columns("song_id", "tag_id").
+		Suffix("ON CONFLICT DO NOTHING")
+
+	for _, tagID := range tagIDs {
+		queryBuilder = queryBuilder.Values(songID, tagID)
+	}
+
+	query, _, _ = queryBuilder.ToSql()
+
+	_, err := queryBuilder.RunWith(tg.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (tg *Tags) RemoveSongTags(songID int, tagIDs []int) error {
+	const fn = "db.postgres.Tags.RemoveSongTags"
+	var query string
+
+	defer func(query *string) {
+		tg.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tg.stmtBuilder.
+		Delete("song_tags").
+		Where(squirrel.Eq{"song_id": songID}).
+		Where("tag_id = ANY(?)", pq.Array(tagIDs))
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(tg.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// GetBySongs returns the tag names of the songs.
+func (tg *Tags) GetBySongs(songIDs []int) (*[]entities.SongTag, error) {
+	const fn = "db.postgres.Tags.GetBySongs"
+	var query string
+
+	defer func(query *string) {
+		tg.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tg.stmtBuilder.
+		Select("st.song_id", "t.name").
+		From("song_tags st").
+		Join("tags t ON t.id = st.tag_id").
+		Where("st.song_id = ANY(?)", pq.Array(songIDs)).
+		OrderBy("st.song_id", "t.name")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var tags []entities.SongTag
+	err = tg.db.Select(&tags, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &tags, nil
+}
+
+// GetCloud returns the tags of the tenant with the number of the songs tagged
+// with each, the most used first.
+func (tg *Tags) GetCloud(tenant, kind string) (*[]entities.TagCount, error) {
+	const fn = "db.postgres.Tags.GetCloud"
+	var query string
+
+	defer func(query *string) {
+		tg.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tg.stmtBuilder.
+		Select(append(qualify("t", tagColumns), "count(st.song_id) AS songs")...).
+		From("tags t").
+		LeftJoin("song_tags st ON st.tag_id = t.id").
+		Where(squirrel.Eq{"t.tenant_id": tenant}).
+		GroupBy("t.id").
+		OrderBy("songs DESC", "t.name")
+
+	if kind != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"t.kind": kind})
+	}
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var tags []entities.TagCount
+	err = tg.db.Select(&tags, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &tags, nil
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"log/slog"
+)
+
+var tenantColumns = []string{"id", "name", "created_at"}
+
+type Tenants struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewTenants(db *DB) *Tenants {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Tenants{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+func (tn *Tenants) Create(id, name string) (*entities.Tenant, error) {
+	const fn = "db.postgres.Tenants.Create"
+	var query string
+
+	defer func(query *string) {
+		tn.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tn.stmtBuilder.
+		Insert("tenants").
+		Columns("id", "name").
+		Values(id, name).
+		Suffix("RETURNING " + columns(tenantColumns))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var tenant entities.Tenant
+	err = tn.db.Get(&tenant, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &tenant, nil
+}
+
+func (tn *Tenants) Get(id string) (*entities.Tenant, error) {
+	const fn = "db.postgres.Tenants.Get"
+	var query string
+
+	defer func(query *string) {
+		tn.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tn.stmtBuilder.
+		Select(tenantColumns...).
+		From("tenants").
+		Where(squirrel.Eq{"id": id})
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var tenant entities.Tenant
+	err = tn.db.Get(&tenant, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &tenant, nil
+}
+
+func (tn *Tenants) GetList() (*[]entities.Tenant, error) {
+	const fn = "db.postgres.Tenants.GetList"
+	var query string
+
+	defer func(query *string) {
+		tn.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tn.stmtBuilder.
+		Select(tenantColumns...).
+		From("tenants").
+		OrderBy("id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var tenants []entities.Tenant
+	err = tn.db.Select(&tenants, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &tenants, nil
+}
+
+func (tn *Tenants) Delete(id string) error {
+	const fn = "db.postgres.Tenants.Delete"
+	var query string
+
+	defer func(query *string) {
+		tn.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := tn.stmtBuilder.
+		Delete("tenants").
+		Where(squirrel.Eq{"id": id})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(tn.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
@@ -0,0 +1,241 @@
+package postgres
+
+import (
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"log/slog"
+	"time"
+)
+
+var (
+	userColumns    = []string{"id", "tenant_id", "email", "name", "password_hash", "created_at"}
+	sessionColumns = []string{"id", "user_id", "token_hash", "created_at", "expires_at"}
+)
+
+type Users struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewUsers(db *DB) *Users {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Users{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+func (us *Users) Create(tenant, email, name, passwordHash string) (*entities.User, error) {
+	const fn = "db.postgres.Users.Create"
+	var query string
+
+	defer func(query *string) {
+		us.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := us.stmtBuilder.
+		Insert("users").
+		Columns("tenant_id", "email", "name", "password_hash").
+		Values(tenant, email, name, passwordHash).
+		Suffix("RETURNING " + columns(userColumns))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var user entities.User
+	err = us.db.Get(&user, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &user, nil
+}
+
+func (us *Users) Get(id int) (*entities.User, error) {
+	const fn = "db.postgres.Users.Get"
+	var query string
+
+	defer func(query *string) {
+		us.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := us.stmtBuilder.
+		Select(userColumns...).
+		From("users").
+		Where(squirrel.Eq{"id": id})
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var user entities.User
+	err = us.db.Get(&user, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &user, nil
+}
+
+func (us *Users) GetByEmail(tenant, email string) (*entities.User, error) {
+	const fn = "db.postgres.Users.GetByEmail"
+	var query string
+
+	defer func(query *string) {
+		us.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := us.stmtBuilder.
+		Select(userColumns...).
+		From("users").
+		Where(squirrel.Eq{"tenant_id": tenant}).
+		Where("lower(email) = lower(?)", email)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var user entities.User
+	err = us.db.Get(&user, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &user, nil
+}
+
+func (us *Users) CreateSession(userID int, tokenHash string, expiresAt time.Time) (*entities.Session, error) {
+	const fn = "db.postgres.Users.CreateSession"
+	var query string
+
+	defer func(query *string) {
+		us.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := us.stmtBuilder.
+		Insert("user_sessions").
+		Columns("user_id", "token_hash", "expires_at").
+		Values(userID, tokenHash, expiresAt).
+		Suffix("RETURNING " + columns(sessionColumns))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var session entities.Session
+	err = us.db.Get(&session, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &session, nil
+}
+
+// GetSession returns the unexpired session with the token hash.
+func (us *Users) GetSession(tokenHash string) (*entities.Session, error) {
+	const fn = "db.postgres.Users.GetSession"
+	var query string
+
+	defer func(query *string) {
+		us.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := us.stmtBuilder.
+		Select(sessionColumns...).
+		From("user_sessions").
+		Where(squirrel.Eq{"token_hash": tokenHash}).
+		Where("expires_at > now()")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var session entities.Session
+	err = us.db.Get(&session, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &session, nil
+}
+
+func (us *Users) DeleteSession(id int) error {
+	const fn = "db.postgres.Users.DeleteSession"
+	var query string
+
+	defer func(query *string) {
+		us.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := us.stmtBuilder.
+		Delete("user_sessions").
+		Where(squirrel.Eq{"id": id})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(us.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (us *Users) DeleteExpiredSessions() (int64, error) {
+	const fn = "db.postgres.Users.DeleteExpiredSessions"
+	var query string
+
+	defer func(query *string) {
+		us.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := us.stmtBuilder.
+		Delete("user_sessions").
+		Where("expires_at < now()")
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(us.db).Exec()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,406 @@
+package postgres
+
+import (
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/lib/pq"
+	"log/slog"
+	"time"
+)
+
+var (
+	webhookColumns = []string{"id", "tenant_id", "url", "event_types", "secret", "active", "created_at", "updated_at"}
+
+	webhookDeliveryColumns = []string{
+		"id", "webhook_id", "event_id", "event_bound", "event_type", "payload", "status", "attempts",
+		"response_status", "error", "redelivery_of", "next_attempt_at", "created_at", "delivered_at",
+	}
+)
+
+type Webhooks struct {
+	*DB
+	stmtBuilder squirrel.StatementBuilderType
+}
+
+func NewWebhooks(db *DB) *Webhooks {
+	stmtBuilder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+
+	return &Webhooks{
+		DB:          db,
+		stmtBuilder: stmtBuilder,
+	}
+}
+
+func (wh *Webhooks) Create(tenant, url string, eventTypes []string, secret string) (*entities.Webhook, error) {
+	const fn = "db.postgres.Webhooks.Create"
+	var query string
+
+	defer func(query *string) {
+		wh.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := wh.stmtBuilder.
+		Insert("webhooks").
+		Columns("tenant_id", "url", "event_types", "secret").
+		Values(tenant, url, pq.Array(eventTypes), secret).
+		Suffix("RETURNING " + columns(webhookColumns))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var webhook entities.Webhook
+	err = wh.db.Get(&webhook, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &webhook, nil
+}
+
+func (wh *Webhooks) Get(id int) (*entities.Webhook, error) {
+	const fn = "db.postgres.Webhooks.Get"
+	var query string
+
+	defer func(query *string) {
+		wh.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := wh.stmtBuilder.
+		Select(webhookColumns...).
+		From("webhooks").
+		Where(squirrel.Eq{"id": id})
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var webhook entities.Webhook
+	err = wh.db.Get(&webhook, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &webhook, nil
+}
+
+func (wh *Webhooks) GetList(tenant string, pagination *pagination.Pagination) (*[]entities.Webhook, error) {
+	const fn = "db.postgres.Webhooks.GetList"
+	var query string
+
+	defer func(query *string) {
+		wh.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := wh.stmtBuilder.
+		Select(webhookColumns...).
+		From("webhooks").
+		Where(squirrel.Eq{"tenant_id": tenant}).
+		OrderBy("id")
+
+	queryBuilder = buildPagination(queryBuilder, pagination, 10)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var webhooks []entities.Webhook
+	err = wh.db.Select(&webhooks, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &webhooks, nil
+}
+
+func (wh *Webhooks) GetByEventType(tenant, eventType string) (*[]entities.Webhook, error) {
+	const fn = "db.postgres.Webhooks.GetByEventType"
+	var query string
+
+	defer func(query *string) {
+		wh.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := wh.stmtBuilder.
+		Select(webhookColumns...).
+		From("webhooks").
+		Where(squirrel.Eq{"tenant_id": tenant, "active": true}).
+		Where("? = ANY(event_types)", eventType).
+		OrderBy("id")
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var webhooks []entities.Webhook
+	err = wh.db.Select(&webhooks, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &webhooks, nil
+}
+
+func (wh *Webhooks) Update(tenant string, id int, fields map[string]interface{}) error {
+	const fn = "db.postgres.Webhooks.Update"
+	var query string
+
+	defer func(query *string) {
+		wh.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	if eventTypes, ok := fields["event_types"].([]string); ok {
+		fields["event_types"] = pq.Array(eventTypes)
+	}
+
+	queryBuilder := wh.stmtBuilder.
+		Update("webhooks").
+		SetMap(fields).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"tenant_id": tenant, "id": id})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(wh.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (wh *Webhooks) Delete(tenant string, id int) error {
+	const fn = "db.postgres.Webhooks.Delete"
+	var query string
+
+	defer func(query *string) {
+		wh.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := wh.stmtBuilder.
+		Delete("webhooks").
+		Where(squirrel.Eq{"tenant_id": tenant, "id": id})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(wh.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+// CreateDelivery returns sql.ErrNoRows when the event is already queued for the webhook.
+func (wh *Webhooks) CreateDelivery(delivery *entities.WebhookDelivery) (*entities.WebhookDelivery, error) {
+	const fn = "db.postgres.Webhooks.CreateDelivery"
+	var query string
+
+	defer func(query *string) {
+		wh.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := wh.stmtBuilder.
+		Insert("webhook_deliveries").
+		Columns("webhook_id", "event_id", "event_bound", "event_type", "payload", "redelivery_of").
+		Values(delivery.WebhookID, delivery.EventID, delivery.EventBound, delivery.EventType, delivery.Payload, delivery.RedeliveryOf).
+		Suffix("ON CONFLICT (webhook_id, event_id) WHERE redelivery_of IS NULL DO NOTHING").
+		Suffix("RETURNING " + columns(webhookDeliveryColumns))
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var res entities.WebhookDelivery
+	err = wh.db.Get(&res, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &res, nil
+}
+
+// ClaimDeliveries picks the due pending deliveries and postpones them by lease,
+// so that concurrent workers don't send them twice.
+func (wh *Webhooks) ClaimDeliveries(limit int, lease time.Duration) (*[]entities.WebhookDelivery, error) {
+	const fn = "db.postgres.Webhooks.ClaimDeliveries"
+
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = now() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + columns(webhookDeliveryColumns)
+
+	defer wh.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.String("query", query))
+
+	var deliveries []entities.WebhookDelivery
+	err := wh.db.Select(&deliveries, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &deliveries, nil
+}
+
+// GetLastEventBound returns the newest bound of the events a delivery was
+// queued for, 0 when there is none.
+func (wh *Webhooks) GetLastEventBound() (int64, error) {
+	const fn = "db.postgres.Webhooks.GetLastEventBound"
+
+	const query = `SELECT coalesce(max(event_bound), 0) FROM webhook_deliveries`
+
+	defer wh.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.String("query", query))
+
+	var id int64
+	if err := wh.db.Get(&id, query); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return id, nil
+}
+
+func (wh *Webhooks) UpdateDelivery(id int, fields map[string]interface{}) error {
+	const fn = "db.postgres.Webhooks.UpdateDelivery"
+	var query string
+
+	defer func(query *string) {
+		wh.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := wh.stmtBuilder.
+		Update("webhook_deliveries").
+		SetMap(fields).
+		Where(squirrel.Eq{"id": id})
+
+	query, _, _ = queryBuilder.ToSql()
+
+	res, err := queryBuilder.RunWith(wh.db).Exec()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("%s: %w", fn, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (wh *Webhooks) GetDelivery(webhookID, id int) (*entities.WebhookDelivery, error) {
+	const fn = "db.postgres.Webhooks.GetDelivery"
+	var query string
+
+	defer func(query *string) {
+		wh.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := wh.stmtBuilder.
+		Select(webhookDeliveryColumns...).
+		From("webhook_deliveries").
+		Where(squirrel.Eq{"webhook_id": webhookID, "id": id})
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var delivery entities.WebhookDelivery
+	err = wh.db.Get(&delivery, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &delivery, nil
+}
+
+func (wh *Webhooks) GetDeliveries(webhookID int, pagination *pagination.Pagination) (*[]entities.WebhookDelivery, error) {
+	const fn = "db.postgres.Webhooks.GetDeliveries"
+	var query string
+
+	defer func(query *string) {
+		wh.log.With(
+			slog.String("fn", fn),
+		).Debug("", slog.String("query", *query))
+	}(&query)
+
+	queryBuilder := wh.stmtBuilder.
+		Select(webhookDeliveryColumns...).
+		From("webhook_deliveries").
+		Where(squirrel.Eq{"webhook_id": webhookID}).
+		OrderBy("id DESC")
+
+	queryBuilder = buildPagination(queryBuilder, pagination, 20)
+
+	query, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var deliveries []entities.WebhookDelivery
+	err = wh.db.Select(&deliveries, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &deliveries, nil
+}
@@ -0,0 +1,46 @@
+package entities
+
+import (
+	"github.com/lib/pq"
+	"slices"
+	"time"
+)
+
+const (
+	ScopeSongsRead      = "songs:read"
+	ScopeSongsWrite     = "songs:write"
+	ScopeWebhooksManage = "webhooks:manage"
+	ScopeKeysManage     = "keys:manage"
+)
+
+var Scopes = []string{ScopeSongsRead, ScopeSongsWrite, ScopeWebhooksManage, ScopeKeysManage}
+
+type ApiKey struct {
+	ID         int            `json:"id" db:"id"`
+	Name       string         `json:"name" db:"name"`
+	Prefix     string         `json:"prefix" db:"prefix"`
+	KeyHash    string         `json:"-" db:"key_hash"`
+	Scopes     pq.StringArray `json:"scopes" db:"scopes"`
+	TenantID   *string        `json:"tenantId" db:"tenant_id"`
+	CreatedAt  time.Time      `json:"createdAt" db:"created_at"`
+	ExpiresAt  *time.Time     `json:"expiresAt" db:"expires_at"`
+	LastUsedAt *time.Time     `json:"lastUsedAt" db:"last_used_at"`
+	RevokedAt  *time.Time     `json:"revokedAt" db:"revoked_at"`
+}
+
+// Principal is the authenticated caller of the request. KeyID is set for
+// API keys, UserID and SessionID for user sessions, Claims for bearer tokens.
+// Tenant is empty when the caller isn't bound to a single tenant.
+type Principal struct {
+	Subject   string
+	KeyID     int
+	UserID    int
+	SessionID int
+	Tenant    string
+	Scopes    []string
+	Claims    map[string]interface{}
+}
+
+func (p *Principal) HasScope(scope string) bool {
+	return p != nil && slices.Contains(p.Scopes, scope)
+}
@@ -0,0 +1,37 @@
+package entities
+
+import "time"
+
+const (
+	CreditPrimary  = "primary"
+	CreditFeatured = "featured"
+	CreditWriter   = "writer"
+	CreditComposer = "composer"
+	CreditProducer = "producer"
+)
+
+// Person is an artist or a songwriter of the tenant, credited on the songs
+// under one or more roles.
+type Person struct {
+	ID        int       `json:"id" db:"id"`
+	TenantID  string    `json:"tenantId" db:"tenant_id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// SongCredit is a person credited on a song, the performing artists are the
+// primary and the featured ones. Credits keep the order they were added in.
+type SongCredit struct {
+	ID        int       `json:"id" db:"id"`
+	SongID    int       `json:"songId" db:"song_id"`
+	PersonID  int       `json:"personId" db:"person_id"`
+	Name      string    `json:"name" db:"name"`
+	Role      string    `json:"role" db:"role"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+// CreditFilter matches the songs crediting the person under any of the roles.
+type CreditFilter struct {
+	Person string
+	Roles  []string
+}
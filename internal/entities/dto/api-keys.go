@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"effective-mobile-test/internal/entities"
+	"time"
+)
+
+type CreateApiKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=255"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=songs:read songs:write webhooks:manage keys:manage"`
+	TenantID  *string    `json:"tenantId" validate:"omitempty,max=63"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type ApiKeyResponse struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	TenantID   *string    `json:"tenantId"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+}
+
+func NewApiKeyResponse(res *entities.ApiKey) *ApiKeyResponse {
+	return &ApiKeyResponse{
+		ID:         res.ID,
+		Name:       res.Name,
+		Prefix:     res.Prefix,
+		Scopes:     res.Scopes,
+		TenantID:   res.TenantID,
+		CreatedAt:  res.CreatedAt,
+		ExpiresAt:  res.ExpiresAt,
+		LastUsedAt: res.LastUsedAt,
+		RevokedAt:  res.RevokedAt,
+	}
+}
+
+func NewApiKeysListResponse(res *[]entities.ApiKey) []*ApiKeyResponse {
+	var apiKeys []*ApiKeyResponse
+	for _, apiKey := range *res {
+		apiKeys = append(apiKeys, NewApiKeyResponse(&apiKey))
+	}
+	return apiKeys
+}
+
+type CreateApiKeyResponse struct {
+	ApiKeyResponse
+	Key string `json:"key"`
+}
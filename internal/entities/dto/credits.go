@@ -0,0 +1,77 @@
+package dto
+
+import (
+	"effective-mobile-test/internal/entities"
+	"time"
+)
+
+type PersonRequest struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type GetPeopleRequest struct {
+	Name string `schema:"name"`
+}
+
+type PersonResponse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func NewPersonResponse(res *entities.Person) *PersonResponse {
+	return &PersonResponse{
+		ID:        res.ID,
+		Name:      res.Name,
+		CreatedAt: res.CreatedAt,
+	}
+}
+
+func NewPeopleListResponse(res *[]entities.Person) []*PersonResponse {
+	people := make([]*PersonResponse, 0, len(*res))
+	for _, person := range *res {
+		people = append(people, NewPersonResponse(&person))
+	}
+	return people
+}
+
+type GetSongCreditsRequest struct {
+	Group string `schema:"group" validate:"required,max=255"`
+	Song  string `schema:"song" validate:"required,max=255"`
+}
+
+type AddSongCreditRequest struct {
+	Group  string `json:"group" validate:"required,max=255"`
+	Song   string `json:"song" validate:"required,max=255"`
+	Person string `json:"person" validate:"required,max=255"`
+	Role   string `json:"role" validate:"required,oneof=primary featured writer composer producer"`
+}
+
+type RemoveSongCreditRequest struct {
+	Group  string `json:"group" validate:"required,max=255"`
+	Song   string `json:"song" validate:"required,max=255"`
+	Person string `json:"person" validate:"required,max=255"`
+	Role   string `json:"role" validate:"omitempty,oneof=primary featured writer composer producer"`
+}
+
+type CreditResponse struct {
+	PersonID int    `json:"personId" yaml:"personId" xml:"personId,attr"`
+	Name     string `json:"name" yaml:"name" xml:"name"`
+	Role     string `json:"role" yaml:"role" xml:"role"`
+}
+
+func NewCreditResponse(res *entities.SongCredit) *CreditResponse {
+	return &CreditResponse{
+		PersonID: res.PersonID,
+		Name:     res.Name,
+		Role:     res.Role,
+	}
+}
+
+func NewCreditsListResponse(res *[]entities.SongCredit) []*CreditResponse {
+	credits := make([]*CreditResponse, 0, len(*res))
+	for _, credit := range *res {
+		credits = append(credits, NewCreditResponse(&credit))
+	}
+	return credits
+}
@@ -0,0 +1,70 @@
+package dto
+
+import "effective-mobile-test/internal/entities"
+
+type GetDuplicatesRequest struct {
+	MinScore *float64 `schema:"minScore" validate:"omitempty,min=0,max=1"`
+}
+
+type DuplicateSongResponse struct {
+	ID          int     `json:"id"`
+	Group       string  `json:"group"`
+	Song        string  `json:"song"`
+	ReleaseDate *string `json:"releaseDate"`
+	Link        *string `json:"link"`
+}
+
+type DuplicatePairResponse struct {
+	Songs  []*DuplicateSongResponse `json:"songs"`
+	Score  float64                  `json:"score"`
+	Names  float64                  `json:"names"`
+	Lyrics *float64                 `json:"lyrics"`
+	Link   *float64                 `json:"link"`
+}
+
+func NewDuplicatesResponse(res []entities.DuplicatePair) []*DuplicatePairResponse {
+	pairs := make([]*DuplicatePairResponse, 0, len(res))
+	for _, pair := range res {
+		songs := make([]*DuplicateSongResponse, 0, 2)
+		for _, song := range []entities.Song{pair.First, pair.Second} {
+			songs = append(songs, &DuplicateSongResponse{
+				ID:          song.ID,
+				Group:       song.Group,
+				Song:        song.Song,
+				ReleaseDate: song.ReleaseDate,
+				Link:        song.Link,
+			})
+		}
+
+		pairs = append(pairs, &DuplicatePairResponse{
+			Songs:  songs,
+			Score:  pair.Score,
+			Names:  pair.Names,
+			Lyrics: pair.Lyrics,
+			Link:   pair.Link,
+		})
+	}
+	return pairs
+}
+
+// MergeFields picks the song whose value each field keeps, by default the
+// survivor's unless it has none.
+type MergeFields struct {
+	ReleaseDate string `json:"releaseDate" validate:"omitempty,oneof=survivor loser"`
+	Link        string `json:"link" validate:"omitempty,oneof=survivor loser"`
+	Text        string `json:"text" validate:"omitempty,oneof=survivor loser"`
+}
+
+// MergeSongRef refers to a song by its id or by its names. The id reaches the
+// songs flagged with name_collision, whose names resolve to another song.
+type MergeSongRef struct {
+	ID    *int   `json:"id" validate:"omitempty,min=1"`
+	Group string `json:"group" validate:"required_without=ID,max=255"`
+	Song  string `json:"song" validate:"required_without=ID,max=255"`
+}
+
+type MergeSongsRequest struct {
+	Survivor MergeSongRef `json:"survivor" validate:"required"`
+	Loser    MergeSongRef `json:"loser" validate:"required"`
+	Fields   MergeFields  `json:"fields"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+type GetExplicitReportRequest struct {
+	Group string `schema:"group" validate:"required,max=255"`
+	Song  string `schema:"song" validate:"required,max=255"`
+}
+
+type SetExplicitRequest struct {
+	Group    string `json:"group" validate:"required,max=255"`
+	Song     string `json:"song" validate:"required,max=255"`
+	Explicit *bool  `json:"explicit"`
+}
+
+// ExplicitLineResponse is a line of the lyrics with explicit words, Paragraph
+// is the offset of GET /v1/songs/text and Line counts within it, both from 0.
+type ExplicitLineResponse struct {
+	Paragraph int      `json:"paragraph"`
+	Line      int      `json:"line"`
+	Text      string   `json:"text"`
+	Words     []string `json:"words"`
+}
+
+type ExplicitReportResponse struct {
+	Explicit bool                    `json:"explicit"`
+	Detected bool                    `json:"detected"`
+	Override *bool                   `json:"override"`
+	Lines    []*ExplicitLineResponse `json:"lines"`
+}
@@ -0,0 +1,58 @@
+package dto
+
+import (
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/lyrics"
+)
+
+type GetSongLyricsStatsRequest struct {
+	Group string `schema:"group" validate:"required,max=255"`
+	Song  string `schema:"song" validate:"required,max=255"`
+	Limit int    `schema:"limit" validate:"omitempty,min=1,max=500"`
+}
+
+type GetGroupLyricsStatsRequest struct {
+	Group    string `schema:"group" validate:"required,max=255"`
+	FromYear *int   `schema:"fromYear"`
+	ToYear   *int   `schema:"toYear"`
+	Limit    int    `schema:"limit" validate:"omitempty,min=1,max=500"`
+}
+
+type TermCountResponse struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+type LyricsStatsResponse struct {
+	Words            int                  `json:"words"`
+	UniqueWords      int                  `json:"uniqueWords"`
+	LexicalDiversity float64              `json:"lexicalDiversity"`
+	Terms            []*TermCountResponse `json:"terms"`
+}
+
+type GroupLyricsStatsResponse struct {
+	Songs int `json:"songs"`
+	LyricsStatsResponse
+}
+
+func NewLyricsStatsResponse(res *entities.TextStats) *LyricsStatsResponse {
+	stats := &LyricsStatsResponse{
+		Words:            res.Words,
+		UniqueWords:      res.UniqueWords,
+		LexicalDiversity: lyrics.Diversity(res.Words, res.UniqueWords),
+		Terms:            make([]*TermCountResponse, 0, len(res.Terms)),
+	}
+
+	for _, term := range res.Terms {
+		stats.Terms = append(stats.Terms, &TermCountResponse{Term: term.Term, Count: term.Count})
+	}
+
+	return stats
+}
+
+func NewGroupLyricsStatsResponse(res *entities.TextStats) *GroupLyricsStatsResponse {
+	return &GroupLyricsStatsResponse{
+		Songs:               res.Songs,
+		LyricsStatsResponse: *NewLyricsStatsResponse(res),
+	}
+}
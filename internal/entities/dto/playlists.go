@@ -0,0 +1,98 @@
+package dto
+
+import (
+	"effective-mobile-test/internal/entities"
+	"time"
+)
+
+type CreatePlaylistRequest struct {
+	Name            string `json:"name" validate:"required,max=255"`
+	Description     string `json:"description"`
+	Visibility      string `json:"visibility" validate:"omitempty,oneof=private public"`
+	AllowDuplicates bool   `json:"allowDuplicates"`
+}
+
+type UpdatePlaylistRequest struct {
+	Name            *string `json:"name" validate:"omitempty,min=1,max=255" db:"name"`
+	Description     *string `json:"description" db:"description"`
+	Visibility      *string `json:"visibility" validate:"omitempty,oneof=private public" db:"visibility"`
+	AllowDuplicates *bool   `json:"allowDuplicates" db:"allow_duplicates"`
+}
+
+type PlaylistResponse struct {
+	ID              int       `json:"id"`
+	OwnerUserID     *int      `json:"ownerUserId"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	Visibility      string    `json:"visibility"`
+	AllowDuplicates bool      `json:"allowDuplicates"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+func NewPlaylistResponse(res *entities.Playlist) *PlaylistResponse {
+	return &PlaylistResponse{
+		ID:              res.ID,
+		OwnerUserID:     res.OwnerUserID,
+		Name:            res.Name,
+		Description:     res.Description,
+		Visibility:      res.Visibility,
+		AllowDuplicates: res.AllowDuplicates,
+		CreatedAt:       res.CreatedAt,
+		UpdatedAt:       res.UpdatedAt,
+	}
+}
+
+func NewPlaylistsListResponse(res *[]entities.Playlist) []*PlaylistResponse {
+	playlists := make([]*PlaylistResponse, 0, len(*res))
+	for _, playlist := range *res {
+		playlists = append(playlists, NewPlaylistResponse(&playlist))
+	}
+	return playlists
+}
+
+type PlaylistWithEntriesResponse struct {
+	PlaylistResponse
+	Entries []*PlaylistEntryResponse `json:"entries"`
+}
+
+func NewPlaylistWithEntriesResponse(playlist *entities.Playlist, entries *[]entities.PlaylistEntry) *PlaylistWithEntriesResponse {
+	res := &PlaylistWithEntriesResponse{
+		PlaylistResponse: *NewPlaylistResponse(playlist),
+		Entries:          make([]*PlaylistEntryResponse, 0, len(*entries)),
+	}
+
+	for _, entry := range *entries {
+		res.Entries = append(res.Entries, NewPlaylistEntryResponse(&entry))
+	}
+
+	return res
+}
+
+type AddPlaylistEntryRequest struct {
+	Group    string `json:"group" validate:"required,max=255"`
+	Song     string `json:"song" validate:"required,max=255"`
+	Position *int   `json:"position" validate:"omitempty,min=0"`
+}
+
+type MovePlaylistEntryRequest struct {
+	Position *int `json:"position" validate:"required,min=0"`
+}
+
+type PlaylistEntryResponse struct {
+	ID       int       `json:"id"`
+	Position int       `json:"position"`
+	Group    string    `json:"group"`
+	Song     string    `json:"song"`
+	AddedAt  time.Time `json:"addedAt"`
+}
+
+func NewPlaylistEntryResponse(res *entities.PlaylistEntry) *PlaylistEntryResponse {
+	return &PlaylistEntryResponse{
+		ID:       res.ID,
+		Position: res.Position,
+		Group:    res.Group,
+		Song:     res.Song,
+		AddedAt:  res.AddedAt,
+	}
+}
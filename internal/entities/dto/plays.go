@@ -0,0 +1,72 @@
+package dto
+
+import (
+	"effective-mobile-test/internal/entities"
+	"time"
+)
+
+type PlayEvent struct {
+	Group string `json:"group" validate:"required,max=255"`
+	Song  string `json:"song" validate:"required,max=255"`
+}
+
+type PlayEventsRequest struct {
+	Events []PlayEvent `json:"events" validate:"required,min=1,max=100,dive"`
+}
+
+type GetTrendingRequest struct {
+	Window string `schema:"window"`
+}
+
+type TrendingSongResponse struct {
+	GetSongsListResponse
+	Score float64 `json:"score"`
+	Plays int64   `json:"plays"`
+}
+
+func NewTrendingSongsListResponse(res *[]entities.TrendingSong) []*TrendingSongResponse {
+	songs := make([]*TrendingSongResponse, 0, len(*res))
+	for _, song := range *res {
+		songs = append(songs, &TrendingSongResponse{
+			GetSongsListResponse: *NewSongResponse(&song.Song),
+			Score:                song.Score,
+			Plays:                song.Plays,
+		})
+	}
+	return songs
+}
+
+type GetPlayStatsRequest struct {
+	Group       string     `schema:"group" validate:"required,max=255"`
+	Song        string     `schema:"song" validate:"required,max=255"`
+	Granularity string     `schema:"granularity" validate:"omitempty,oneof=hour day"`
+	Since       *time.Time `schema:"since"`
+	Until       *time.Time `schema:"until"`
+}
+
+type PlayStatsResponse struct {
+	Group       string                `json:"group"`
+	Song        string                `json:"song"`
+	Granularity string                `json:"granularity"`
+	Total       int64                 `json:"total"`
+	Buckets     []entities.PlayBucket `json:"buckets"`
+}
+
+func NewPlayStatsResponse(song *entities.Song, granularity string, buckets *[]entities.PlayBucket) *PlayStatsResponse {
+	res := &PlayStatsResponse{
+		Group:       song.Group,
+		Song:        song.Song,
+		Granularity: granularity,
+		Buckets:     *buckets,
+	}
+
+	if res.Buckets == nil {
+		res.Buckets = []entities.PlayBucket{}
+	}
+
+	for _, bucket := range res.Buckets {
+		res.Total += bucket.Plays
+	}
+
+	return res
+}
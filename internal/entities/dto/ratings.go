@@ -0,0 +1,98 @@
+package dto
+
+import (
+	"effective-mobile-test/internal/entities"
+	"time"
+)
+
+type RateSongRequest struct {
+	Group  string  `json:"group" validate:"required,max=255"`
+	Song   string  `json:"song" validate:"required,max=255"`
+	Rating int     `json:"rating" validate:"required,min=1,max=5"`
+	Review *string `json:"review" validate:"omitempty,max=1000"`
+}
+
+type UpdateRatingRequest struct {
+	Group  string  `json:"group" validate:"required,max=255"`
+	Song   string  `json:"song" validate:"required,max=255"`
+	Rating *int    `json:"rating" validate:"omitempty,min=1,max=5" db:"rating"`
+	Review *string `json:"review" validate:"omitempty,max=1000" db:"review"`
+}
+
+type DeleteRatingRequest struct {
+	Group string `json:"group" validate:"required,max=255"`
+	Song  string `json:"song" validate:"required,max=255"`
+}
+
+type RatingResponse struct {
+	ID           int       `json:"id"`
+	Group        string    `json:"group"`
+	Song         string    `json:"song"`
+	Rating       int       `json:"rating"`
+	Review       *string   `json:"review"`
+	ReviewStatus string    `json:"reviewStatus"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func NewRatingResponse(res *entities.Rating) *RatingResponse {
+	return &RatingResponse{
+		ID:           res.ID,
+		Group:        res.Group,
+		Song:         res.Song,
+		Rating:       res.Rating,
+		Review:       res.Review,
+		ReviewStatus: res.ReviewStatus,
+		CreatedAt:    res.CreatedAt,
+		UpdatedAt:    res.UpdatedAt,
+	}
+}
+
+func NewRatingsListResponse(res *[]entities.Rating) []*RatingResponse {
+	ratings := make([]*RatingResponse, 0, len(*res))
+	for _, rating := range *res {
+		ratings = append(ratings, NewRatingResponse(&rating))
+	}
+	return ratings
+}
+
+type GetReviewsRequest struct {
+	Group string `schema:"group" validate:"required,max=255"`
+	Song  string `schema:"song" validate:"required,max=255"`
+}
+
+type ReviewResponse struct {
+	ID           int       `json:"id"`
+	Group        string    `json:"group"`
+	Song         string    `json:"song"`
+	Author       string    `json:"author"`
+	Rating       int       `json:"rating"`
+	Review       string    `json:"review"`
+	ReviewStatus string    `json:"reviewStatus"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+func NewReviewsListResponse(res *[]entities.Rating) []*ReviewResponse {
+	reviews := make([]*ReviewResponse, 0, len(*res))
+	for _, rating := range *res {
+		review := &ReviewResponse{
+			ID:           rating.ID,
+			Group:        rating.Group,
+			Song:         rating.Song,
+			Author:       rating.Author,
+			Rating:       rating.Rating,
+			ReviewStatus: rating.ReviewStatus,
+			UpdatedAt:    rating.UpdatedAt,
+		}
+		if rating.Review != nil {
+			review.Review = *rating.Review
+		}
+
+		reviews = append(reviews, review)
+	}
+	return reviews
+}
+
+type ModerateReviewRequest struct {
+	Status string `json:"status" validate:"required,oneof=published rejected"`
+}
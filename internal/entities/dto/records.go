@@ -0,0 +1,133 @@
+package dto
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The responses below render as CSV besides JSON, XML and YAML. Lists are
+// joined into a cell with "; ".
+
+// SongsListResponse is the songs list, rendered as a <songs> element in XML
+// and as a row per song in CSV.
+type SongsListResponse []*GetSongsListResponse
+
+func (res SongsListResponse) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Local: "songs"}}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, song := range res {
+		if err := e.Encode(song); err != nil {
+			return err
+		}
+	}
+
+	return e.EncodeToken(start.End())
+}
+
+func (res SongsListResponse) Records() [][]string {
+	records := [][]string{{
+		"group", "song", "releaseDate", "link", "text", "language", "languageConfidence", "explicit",
+		"createdAt", "updatedAt", "ratingAvg", "ratingCount", "tags", "credits", "favorited",
+	}}
+
+	for _, song := range res {
+		records = append(records, []string{
+			song.Group,
+			song.Song,
+			stringCell(song.ReleaseDate),
+			stringCell(song.Link),
+			stringCell(song.Text),
+			stringCell(song.Language),
+			floatCell(song.LanguageConfidence),
+			strconv.FormatBool(song.Explicit),
+			timeCell(song.CreatedAt),
+			timeCell(song.UpdatedAt),
+			floatCell(song.RatingAvg),
+			strconv.Itoa(song.RatingCount),
+			strings.Join(song.Tags, "; "),
+			creditsCell(song.Credits),
+			boolCell(song.Favorited),
+		})
+	}
+
+	return records
+}
+
+func (res *GetSongResponse) Records() [][]string {
+	return [][]string{
+		{
+			"releaseDate", "link", "text", "language", "languageConfidence", "explicit", "createdAt",
+			"updatedAt", "ratingAvg", "ratingCount", "tags", "credits", "originals", "versions", "favorited",
+		},
+		{
+			stringCell(res.ReleaseDate),
+			stringCell(res.Link),
+			stringCell(res.Text),
+			stringCell(res.Language),
+			floatCell(res.LanguageConfidence),
+			strconv.FormatBool(res.Explicit),
+			timeCell(res.CreatedAt),
+			timeCell(res.UpdatedAt),
+			floatCell(res.RatingAvg),
+			strconv.Itoa(res.RatingCount),
+			strings.Join(res.Tags, "; "),
+			creditsCell(res.Credits),
+			relatedCell(res.Originals),
+			relatedCell(res.Versions),
+			boolCell(res.Favorited),
+		},
+	}
+}
+
+func (res *GetTextResponse) Records() [][]string {
+	return [][]string{{"text"}, {res.Text}}
+}
+
+func stringCell(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func floatCell(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+func boolCell(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return strconv.FormatBool(*b)
+}
+
+func timeCell(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// creditsCell lists the credits as "name (role)".
+func creditsCell(credits []*CreditResponse) string {
+	cells := make([]string, len(credits))
+	for i, credit := range credits {
+		cells[i] = credit.Name + " (" + credit.Role + ")"
+	}
+	return strings.Join(cells, "; ")
+}
+
+// relatedCell lists the related songs as "kind: group - song".
+func relatedCell(songs []*RelatedSongResponse) string {
+	cells := make([]string, len(songs))
+	for i, song := range songs {
+		cells[i] = song.Kind + ": " + song.Group + " - " + song.Song
+	}
+	return strings.Join(cells, "; ")
+}
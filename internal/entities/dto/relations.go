@@ -0,0 +1,33 @@
+package dto
+
+import "effective-mobile-test/internal/entities"
+
+type SongRef struct {
+	Group string `json:"group" validate:"required,max=255"`
+	Song  string `json:"song" validate:"required,max=255"`
+}
+
+type SongRelationRequest struct {
+	Group    string  `json:"group" validate:"required,max=255"`
+	Song     string  `json:"song" validate:"required,max=255"`
+	Kind     string  `json:"kind" validate:"required,oneof=cover remix live sample translation"`
+	Original SongRef `json:"original" validate:"required"`
+}
+
+type RelatedSongResponse struct {
+	Kind  string `json:"kind" yaml:"kind" xml:"kind,attr"`
+	Group string `json:"group" yaml:"group" xml:"group"`
+	Song  string `json:"song" yaml:"song" xml:"song"`
+}
+
+func NewRelatedSongsResponse(res *[]entities.SongRelation) []*RelatedSongResponse {
+	var songs []*RelatedSongResponse
+	for _, relation := range *res {
+		songs = append(songs, &RelatedSongResponse{
+			Kind:  relation.Kind,
+			Group: relation.Group,
+			Song:  relation.Song,
+		})
+	}
+	return songs
+}
@@ -0,0 +1,49 @@
+package dto
+
+import (
+	"effective-mobile-test/internal/entities"
+	"time"
+)
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,max=64,excludesall=/ "`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"required,min=1,dive,oneof=songs:read songs:create songs:update songs:delete roles:manage tenants:manage playlists:manage reviews:moderate tags:manage"`
+}
+
+type UpdateRoleRequest struct {
+	Description *string   `json:"description" validate:"omitempty,max=255" db:"description"`
+	Permissions *[]string `json:"permissions" validate:"omitempty,min=1,dive,oneof=songs:read songs:create songs:update songs:delete roles:manage tenants:manage playlists:manage reviews:moderate tags:manage" db:"permissions"`
+}
+
+type RoleResponse struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	Builtin     bool      `json:"builtin"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func NewRoleResponse(res *entities.Role) *RoleResponse {
+	return &RoleResponse{
+		Name:        res.Name,
+		Description: res.Description,
+		Permissions: res.Permissions,
+		Builtin:     res.Builtin,
+		CreatedAt:   res.CreatedAt,
+	}
+}
+
+func NewRolesListResponse(res *[]entities.Role) []*RoleResponse {
+	roles := make([]*RoleResponse, 0, len(*res))
+	for _, role := range *res {
+		roles = append(roles, NewRoleResponse(&role))
+	}
+	return roles
+}
+
+type SubjectRolesResponse struct {
+	Subject     string          `json:"subject"`
+	Roles       []*RoleResponse `json:"roles"`
+	Permissions []string        `json:"permissions"`
+}
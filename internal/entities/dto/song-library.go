@@ -2,77 +2,134 @@ package dto
 
 import (
 	"effective-mobile-test/internal/entities"
+	"encoding/xml"
+	"time"
 )
 
 type CreateSongRequest struct {
-	Group string `json:"group" validate:"required"`
-	Song  string `json:"song" validate:"required"`
+	Group string `json:"group" validate:"required,max=255"`
+	Song  string `json:"song" validate:"required,max=255"`
 }
 
 type UpdateSongRequest struct {
-	Group       string  `json:"group" validate:"required"`
-	Song        string  `json:"song" validate:"required"`
-	ReleaseDate *string `json:"releaseDate" db:"release_date"`
-	Link        *string `json:"link" db:"link"`
+	Group       string  `json:"group" validate:"required,max=255"`
+	Song        string  `json:"song" validate:"required,max=255"`
+	ReleaseDate *string `json:"releaseDate" validate:"omitempty,datetime=02.01.2006" db:"release_date"`
+	Link        *string `json:"link" validate:"omitempty,url" db:"link"`
 	Text        *string `json:"text" db:"text"`
 }
 
 type DeleteSongRequest struct {
-	Group string `json:"group" validate:"required"`
-	Song  string `json:"song" validate:"required"`
+	Group string `json:"group" validate:"required,max=255"`
+	Song  string `json:"song" validate:"required,max=255"`
 }
 
 type GetTextRequest struct {
-	Group string `schema:"group" validate:"required"`
-	Song  string `schema:"song" validate:"required"`
+	Group string `schema:"group" validate:"required,max=255"`
+	Song  string `schema:"song" validate:"required,max=255"`
+	Clean bool   `schema:"clean"`
 }
 
 type GetTextResponse struct {
-	Text string `json:"text"`
+	XMLName   xml.Name  `json:"-" yaml:"-" xml:"lyrics"`
+	Text      string    `json:"text" yaml:"text" xml:"text"`
+	UpdatedAt time.Time `json:"-" yaml:"-" xml:"-"`
 }
 
 type GetSongRequest struct {
-	Group string `schema:"group" validate:"required"`
-	Song  string `schema:"song" validate:"required"`
+	Group string `schema:"group" validate:"required,max=255"`
+	Song  string `schema:"song" validate:"required,max=255"`
 }
 
 type GetSongResponse struct {
-	ReleaseDate *string `json:"releaseDate"`
-	Link        *string `json:"link"`
-	Text        *string `json:"text"`
+	XMLName            xml.Name               `json:"-" yaml:"-" xml:"song"`
+	ReleaseDate        *string                `json:"releaseDate" yaml:"releaseDate" xml:"releaseDate,omitempty"`
+	Link               *string                `json:"link" yaml:"link" xml:"link,omitempty"`
+	Text               *string                `json:"text" yaml:"text" xml:"text,omitempty"`
+	Language           *string                `json:"language" yaml:"language" xml:"language,omitempty"`
+	LanguageConfidence *float64               `json:"languageConfidence" yaml:"languageConfidence" xml:"languageConfidence,omitempty"`
+	Explicit           bool                   `json:"explicit" yaml:"explicit" xml:"explicit"`
+	CreatedAt          time.Time              `json:"createdAt" yaml:"createdAt" xml:"createdAt"`
+	UpdatedAt          time.Time              `json:"updatedAt" yaml:"updatedAt" xml:"updatedAt"`
+	RatingAvg          *float64               `json:"ratingAvg" yaml:"ratingAvg" xml:"ratingAvg,omitempty"`
+	RatingCount        int                    `json:"ratingCount" yaml:"ratingCount" xml:"ratingCount"`
+	RatedAt            *time.Time             `json:"-" yaml:"-" xml:"-"`
+	Tags               []string               `json:"tags,omitempty" yaml:"tags,omitempty" xml:"tag,omitempty"`
+	Credits            []*CreditResponse      `json:"credits,omitempty" yaml:"credits,omitempty" xml:"credit,omitempty"`
+	Originals          []*RelatedSongResponse `json:"originals,omitempty" yaml:"originals,omitempty" xml:"original,omitempty"`
+	Versions           []*RelatedSongResponse `json:"versions,omitempty" yaml:"versions,omitempty" xml:"version,omitempty"`
+	Favorited          *bool                  `json:"favorited,omitempty" yaml:"favorited,omitempty" xml:"favorited,omitempty"`
 }
 
 func NewGetSongResponse(res *entities.Song) *GetSongResponse {
 	return &GetSongResponse{
-		ReleaseDate: res.ReleaseDate,
-		Link:        res.Link,
-		Text:        res.Text,
+		ReleaseDate:        res.ReleaseDate,
+		Link:               res.Link,
+		Text:               res.Text,
+		Language:           res.Language,
+		LanguageConfidence: res.LanguageConfidence,
+		Explicit:           res.IsExplicit(),
+		CreatedAt:          res.CreatedAt,
+		UpdatedAt:          res.UpdatedAt,
+		RatingAvg:          res.RatingAvg,
+		RatingCount:        res.RatingCount,
+		RatedAt:            res.RatedAt,
 	}
 }
 
 type GetSongsListRequest struct {
-	Group       string  `schema:"group" db:"group"`
-	Song        string  `schema:"song" db:"song"`
-	ReleaseDate *string `schema:"releaseDate" db:"release_date"`
-	Link        *string `schema:"link" db:"link"`
-	Text        *string `schema:"text" db:"text"`
+	Group        string     `schema:"group" db:"group"`
+	Song         string     `schema:"song" db:"song"`
+	ReleaseDate  *string    `schema:"releaseDate" db:"release_date"`
+	Link         *string    `schema:"link" db:"link"`
+	Text         *string    `schema:"text" db:"text"`
+	UpdatedSince *time.Time `schema:"updatedSince" db:"updated_at"`
+	Sort         string     `schema:"sort" validate:"omitempty,oneof=rating -rating ratingCount -ratingCount"`
+	Tags         []string   `schema:"tags"`
+	TagMatch     string     `schema:"tagMatch" validate:"omitempty,oneof=any all"`
+	Artist       string     `schema:"artist"`
+	Writer       string     `schema:"writer"`
+	Composer     string     `schema:"composer"`
+	Producer     string     `schema:"producer"`
+	Language     string     `schema:"language"`
+	Clean        bool       `schema:"clean"`
 }
 
 type GetSongsListResponse struct {
-	Group       string  `json:"group" db:"group"`
-	Song        string  `json:"song" db:"song"`
-	ReleaseDate *string `json:"releaseDate" db:"release_date"`
-	Link        *string `json:"link" db:"link"`
-	Text        *string `json:"text" db:"text"`
+	XMLName            xml.Name          `json:"-" yaml:"-" xml:"song"`
+	Group              string            `json:"group" yaml:"group" xml:"group" db:"group"`
+	Song               string            `json:"song" yaml:"song" xml:"name" db:"song"`
+	ReleaseDate        *string           `json:"releaseDate" yaml:"releaseDate" xml:"releaseDate,omitempty" db:"release_date"`
+	Link               *string           `json:"link" yaml:"link" xml:"link,omitempty" db:"link"`
+	Text               *string           `json:"text" yaml:"text" xml:"text,omitempty" db:"text"`
+	Language           *string           `json:"language" yaml:"language" xml:"language,omitempty" db:"language"`
+	LanguageConfidence *float64          `json:"languageConfidence" yaml:"languageConfidence" xml:"languageConfidence,omitempty" db:"language_confidence"`
+	Explicit           bool              `json:"explicit" yaml:"explicit" xml:"explicit"`
+	CreatedAt          time.Time         `json:"createdAt" yaml:"createdAt" xml:"createdAt" db:"created_at"`
+	UpdatedAt          time.Time         `json:"updatedAt" yaml:"updatedAt" xml:"updatedAt" db:"updated_at"`
+	RatingAvg          *float64          `json:"ratingAvg" yaml:"ratingAvg" xml:"ratingAvg,omitempty" db:"rating_avg"`
+	RatingCount        int               `json:"ratingCount" yaml:"ratingCount" xml:"ratingCount" db:"rating_count"`
+	RatedAt            *time.Time        `json:"-" yaml:"-" xml:"-" db:"rated_at"`
+	Tags               []string          `json:"tags,omitempty" yaml:"tags,omitempty" xml:"tag,omitempty"`
+	Credits            []*CreditResponse `json:"credits,omitempty" yaml:"credits,omitempty" xml:"credit,omitempty"`
+	Favorited          *bool             `json:"favorited,omitempty" yaml:"favorited,omitempty" xml:"favorited,omitempty"`
 }
 
 func NewSongResponse(res *entities.Song) *GetSongsListResponse {
 	return &GetSongsListResponse{
-		Group:       res.Group,
-		Song:        res.Song,
-		ReleaseDate: res.ReleaseDate,
-		Link:        res.Link,
-		Text:        res.Text,
+		Group:              res.Group,
+		Song:               res.Song,
+		ReleaseDate:        res.ReleaseDate,
+		Link:               res.Link,
+		Text:               res.Text,
+		Language:           res.Language,
+		LanguageConfidence: res.LanguageConfidence,
+		Explicit:           res.IsExplicit(),
+		CreatedAt:          res.CreatedAt,
+		UpdatedAt:          res.UpdatedAt,
+		RatingAvg:          res.RatingAvg,
+		RatingCount:        res.RatingCount,
+		RatedAt:            res.RatedAt,
 	}
 }
 
@@ -83,3 +140,70 @@ func NewGetSongsListResponse(res *[]entities.Song) []*GetSongsListResponse {
 	}
 	return songs
 }
+
+type SyncRequest struct {
+	Token string `schema:"token"`
+}
+
+type SongChangeResponse struct {
+	Type      string                `json:"type"`
+	ChangedAt time.Time             `json:"changedAt"`
+	Group     string                `json:"group"`
+	Song      string                `json:"song"`
+	Data      *GetSongsListResponse `json:"data,omitempty"`
+}
+
+func NewSongChangeResponse(res *entities.SongChange) *SongChangeResponse {
+	change := &SongChangeResponse{
+		Type:      res.Type,
+		ChangedAt: res.ChangedAt,
+		Group:     res.Group,
+		Song:      res.Song.Song,
+	}
+
+	if res.Type != entities.SongChangeDelete {
+		change.Data = NewSongResponse(&res.Song)
+	}
+
+	return change
+}
+
+type SyncResponse struct {
+	Changes   []*SongChangeResponse `json:"changes"`
+	NextToken string                `json:"nextToken"`
+	HasMore   bool                  `json:"hasMore"`
+}
+
+type SongEventsRequest struct {
+	Group       string `schema:"group"`
+	LastEventID *int64 `schema:"lastEventId"`
+}
+
+type SongEventResponse struct {
+	ID       int64                 `json:"-"`
+	Bound    int64                 `json:"-"`
+	Type     string                `json:"type"`
+	Tenant   string                `json:"tenant"`
+	Group    string                `json:"group"`
+	GroupKey string                `json:"-"`
+	Song     string                `json:"song"`
+	Data     *GetSongsListResponse `json:"data,omitempty"`
+}
+
+func NewSongEventResponse(event *entities.SongEvent, song *entities.Song) *SongEventResponse {
+	res := &SongEventResponse{
+		ID:       event.Seq,
+		Bound:    event.Bound,
+		Type:     event.Type,
+		Tenant:   event.Tenant,
+		Group:    event.Group,
+		GroupKey: event.GroupKey,
+		Song:     event.Song,
+	}
+
+	if song != nil {
+		res.Data = NewSongResponse(song)
+	}
+
+	return res
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"effective-mobile-test/internal/entities"
+	"time"
+)
+
+type GroupCountResponse struct {
+	Group string `json:"group"`
+	Songs int    `json:"songs"`
+}
+
+type YearCountResponse struct {
+	Year  int `json:"year"`
+	Songs int `json:"songs"`
+}
+
+type StatsResponse struct {
+	Songs              int                   `json:"songs"`
+	WithoutText        int                   `json:"withoutText"`
+	WithoutLink        int                   `json:"withoutLink"`
+	WithoutReleaseDate int                   `json:"withoutReleaseDate"`
+	AvgTextLength      *float64              `json:"avgTextLength"`
+	Groups             []*GroupCountResponse `json:"groups"`
+	ReleaseYears       []*YearCountResponse  `json:"releaseYears"`
+	GeneratedAt        time.Time             `json:"generatedAt"`
+}
+
+func NewStatsResponse(res *entities.LibraryStats, generatedAt time.Time) *StatsResponse {
+	stats := &StatsResponse{
+		Songs:              res.Songs,
+		WithoutText:        res.WithoutText,
+		WithoutLink:        res.WithoutLink,
+		WithoutReleaseDate: res.WithoutReleaseDate,
+		AvgTextLength:      res.AvgTextLength,
+		Groups:             make([]*GroupCountResponse, 0, len(res.Groups)),
+		ReleaseYears:       make([]*YearCountResponse, 0, len(res.Years)),
+		GeneratedAt:        generatedAt,
+	}
+
+	for _, group := range res.Groups {
+		stats.Groups = append(stats.Groups, &GroupCountResponse{Group: group.Group, Songs: group.Songs})
+	}
+
+	for _, year := range res.Years {
+		stats.ReleaseYears = append(stats.ReleaseYears, &YearCountResponse{Year: year.Year, Songs: year.Songs})
+	}
+
+	return stats
+}
@@ -0,0 +1,71 @@
+package dto
+
+import (
+	"effective-mobile-test/internal/entities"
+	"time"
+)
+
+type CreateTagRequest struct {
+	Name     string `json:"name" validate:"required,max=63"`
+	Kind     string `json:"kind" validate:"omitempty,oneof=genre mood tag"`
+	ParentID *int   `json:"parentId" validate:"omitempty,min=1"`
+}
+
+type UpdateTagRequest struct {
+	Name     *string `json:"name" validate:"omitempty,min=1,max=63"`
+	ParentID *int    `json:"parentId" validate:"omitempty,min=0"`
+}
+
+type GetTagsRequest struct {
+	Kind string `schema:"kind" validate:"omitempty,oneof=genre mood tag"`
+}
+
+type TagResponse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	ParentID  *int      `json:"parentId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func NewTagResponse(res *entities.Tag) *TagResponse {
+	return &TagResponse{
+		ID:        res.ID,
+		Name:      res.Name,
+		Kind:      res.Kind,
+		ParentID:  res.ParentID,
+		CreatedAt: res.CreatedAt,
+	}
+}
+
+func NewTagsListResponse(res *[]entities.Tag) []*TagResponse {
+	tags := make([]*TagResponse, 0, len(*res))
+	for _, tag := range *res {
+		tags = append(tags, NewTagResponse(&tag))
+	}
+	return tags
+}
+
+type TagCountResponse struct {
+	Name  string `json:"name"`
+	Kind  string `json:"kind"`
+	Songs int    `json:"songs"`
+}
+
+func NewTagCloudResponse(res *[]entities.TagCount) []*TagCountResponse {
+	tags := make([]*TagCountResponse, 0, len(*res))
+	for _, tag := range *res {
+		tags = append(tags, &TagCountResponse{
+			Name:  tag.Name,
+			Kind:  tag.Kind,
+			Songs: tag.Songs,
+		})
+	}
+	return tags
+}
+
+type SongTagsRequest struct {
+	Group string   `json:"group" validate:"required,max=255"`
+	Song  string   `json:"song" validate:"required,max=255"`
+	Tags  []string `json:"tags" validate:"required,min=1,dive,required"`
+}
@@ -0,0 +1,33 @@
+package dto
+
+import (
+	"effective-mobile-test/internal/entities"
+	"time"
+)
+
+type CreateTenantRequest struct {
+	ID   string `json:"id" validate:"required,max=63,hostname_rfc1123,lowercase,excludes=."`
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type TenantResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func NewTenantResponse(res *entities.Tenant) *TenantResponse {
+	return &TenantResponse{
+		ID:        res.ID,
+		Name:      res.Name,
+		CreatedAt: res.CreatedAt,
+	}
+}
+
+func NewTenantsListResponse(res *[]entities.Tenant) []*TenantResponse {
+	tenants := make([]*TenantResponse, 0, len(*res))
+	for _, tenant := range *res {
+		tenants = append(tenants, NewTenantResponse(&tenant))
+	}
+	return tenants
+}
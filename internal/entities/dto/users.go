@@ -0,0 +1,64 @@
+package dto
+
+import (
+	"effective-mobile-test/internal/entities"
+	"time"
+)
+
+type RegisterUserRequest struct {
+	Email    string `json:"email" validate:"required,email,max=255"`
+	Name     string `json:"name" validate:"max=255"`
+	Password string `json:"password" validate:"required,min=8,maxbytes=72"`
+}
+
+type UserResponse struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func NewUserResponse(res *entities.User) *UserResponse {
+	return &UserResponse{
+		ID:        res.ID,
+		Email:     res.Email,
+		Name:      res.Name,
+		CreatedAt: res.CreatedAt,
+	}
+}
+
+type CreateSessionRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type SessionResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type FavoriteRequest struct {
+	Group string `json:"group" validate:"required,max=255"`
+	Song  string `json:"song" validate:"required,max=255"`
+}
+
+type FavoriteSongResponse struct {
+	GetSongsListResponse
+	FavoritedAt time.Time `json:"favoritedAt"`
+}
+
+func NewFavoritesListResponse(res *[]entities.FavoriteSong) []*FavoriteSongResponse {
+	favorited := true
+
+	songs := make([]*FavoriteSongResponse, 0, len(*res))
+	for _, song := range *res {
+		favorite := &FavoriteSongResponse{
+			GetSongsListResponse: *NewSongResponse(&song.Song),
+			FavoritedAt:          song.FavoritedAt,
+		}
+		favorite.Favorited = &favorited
+
+		songs = append(songs, favorite)
+	}
+	return songs
+}
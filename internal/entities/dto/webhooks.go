@@ -0,0 +1,102 @@
+package dto
+
+import (
+	"effective-mobile-test/internal/entities"
+	"time"
+)
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url" validate:"required,url"`
+	EventTypes []string `json:"eventTypes" validate:"required,min=1,dive,oneof=created updated deleted"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16"`
+}
+
+type UpdateWebhookRequest struct {
+	URL        *string   `json:"url" validate:"omitempty,url" db:"url"`
+	EventTypes *[]string `json:"eventTypes" validate:"omitempty,min=1,dive,oneof=created updated deleted" db:"event_types"`
+	Secret     *string   `json:"secret" validate:"omitempty,min=16" db:"secret"`
+	Active     *bool     `json:"active" db:"active"`
+}
+
+type WebhookResponse struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"eventTypes"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+func NewWebhookResponse(res *entities.Webhook) *WebhookResponse {
+	return &WebhookResponse{
+		ID:         res.ID,
+		URL:        res.URL,
+		EventTypes: res.EventTypes,
+		Active:     res.Active,
+		CreatedAt:  res.CreatedAt,
+		UpdatedAt:  res.UpdatedAt,
+	}
+}
+
+func NewWebhooksListResponse(res *[]entities.Webhook) []*WebhookResponse {
+	var webhooks []*WebhookResponse
+	for _, webhook := range *res {
+		webhooks = append(webhooks, NewWebhookResponse(&webhook))
+	}
+	return webhooks
+}
+
+type CreateWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+type WebhookPayload struct {
+	ID        int64              `json:"id"`
+	Type      string             `json:"type"`
+	CreatedAt time.Time          `json:"createdAt"`
+	Data      *SongEventResponse `json:"data"`
+}
+
+type WebhookDeliveryResponse struct {
+	ID             int        `json:"id"`
+	EventID        int64      `json:"eventId"`
+	EventType      string     `json:"eventType"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus *int       `json:"responseStatus"`
+	Error          *string    `json:"error"`
+	RedeliveryOf   *int       `json:"redeliveryOf"`
+	NextAttemptAt  *time.Time `json:"nextAttemptAt"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt"`
+}
+
+func NewWebhookDeliveryResponse(res *entities.WebhookDelivery) *WebhookDeliveryResponse {
+	delivery := &WebhookDeliveryResponse{
+		ID:             res.ID,
+		EventID:        res.EventID,
+		EventType:      res.EventType,
+		Status:         res.Status,
+		Attempts:       res.Attempts,
+		ResponseStatus: res.ResponseStatus,
+		Error:          res.Error,
+		RedeliveryOf:   res.RedeliveryOf,
+		CreatedAt:      res.CreatedAt,
+		DeliveredAt:    res.DeliveredAt,
+	}
+
+	if res.Status == entities.DeliveryPending {
+		delivery.NextAttemptAt = &res.NextAttemptAt
+	}
+
+	return delivery
+}
+
+func NewWebhookDeliveriesListResponse(res *[]entities.WebhookDelivery) []*WebhookDeliveryResponse {
+	var deliveries []*WebhookDeliveryResponse
+	for _, delivery := range *res {
+		deliveries = append(deliveries, NewWebhookDeliveryResponse(&delivery))
+	}
+	return deliveries
+}
@@ -0,0 +1,17 @@
+package entities
+
+const (
+	MergeSurvivor = "survivor"
+	MergeLoser    = "loser"
+)
+
+// DuplicatePair is a pair of songs likely to be the same one, scored from 0
+// to 1. Lyrics and Link are nil when one of the songs has nothing to compare.
+type DuplicatePair struct {
+	First  Song
+	Second Song
+	Score  float64
+	Names  float64
+	Lyrics *float64
+	Link   *float64
+}
@@ -0,0 +1,14 @@
+package entities
+
+import "time"
+
+type IdempotencyKey struct {
+	Key         string `db:"key"`
+	Fingerprint string `db:"fingerprint"`
+	StatusCode  *int   `db:"status_code"`
+	// Headers is the JSON object of the replayed headers.
+	Headers   []byte    `db:"headers"`
+	Body      []byte    `db:"body"`
+	CreatedAt time.Time `db:"created_at"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
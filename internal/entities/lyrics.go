@@ -0,0 +1,28 @@
+package entities
+
+import "time"
+
+type TermCount struct {
+	Term     string `json:"term" db:"term"`
+	Count    int    `json:"count" db:"count"`
+	Stopword bool   `json:"stopword" db:"stopword"`
+}
+
+// TextStats is the vocabulary of the lyrics of one or more songs, AnalyzedAt
+// is the update time of the song version the terms were counted from.
+type TextStats struct {
+	SongID      int       `json:"songId" db:"song_id"`
+	Songs       int       `json:"songs" db:"songs"`
+	Words       int       `json:"words" db:"words"`
+	UniqueWords int       `json:"uniqueWords" db:"unique_words"`
+	AnalyzedAt  time.Time `json:"analyzedAt" db:"analyzed_at"`
+	Terms       []TermCount
+}
+
+// LyricsScope selects the songs of a group, released within the years when
+// they are set.
+type LyricsScope struct {
+	Group    string
+	FromYear *int
+	ToYear   *int
+}
@@ -0,0 +1,26 @@
+package entities
+
+import "time"
+
+const (
+	PlaysHourly = "hour"
+	PlaysDaily  = "day"
+)
+
+// PlayCount is the number of plays of the song within the hour.
+type PlayCount struct {
+	SongID int
+	Hour   time.Time
+	Plays  int64
+}
+
+type PlayBucket struct {
+	At    time.Time `json:"at" db:"at"`
+	Plays int64     `json:"plays" db:"plays"`
+}
+
+type TrendingSong struct {
+	Score float64 `json:"score" db:"score"`
+	Plays int64   `json:"plays" db:"plays"`
+	Song
+}
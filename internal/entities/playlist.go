@@ -0,0 +1,35 @@
+package entities
+
+import "time"
+
+const (
+	PlaylistPrivate = "private"
+	PlaylistPublic  = "public"
+)
+
+// Playlist is owned by the user who created it, or by the tenant when
+// OwnerUserID is nil.
+type Playlist struct {
+	ID              int       `json:"id" db:"id"`
+	TenantID        string    `json:"tenantId" db:"tenant_id"`
+	OwnerUserID     *int      `json:"ownerUserId" db:"owner_user_id"`
+	Name            string    `json:"name" db:"name"`
+	Description     string    `json:"description" db:"description"`
+	Visibility      string    `json:"visibility" db:"visibility"`
+	AllowDuplicates bool      `json:"allowDuplicates" db:"allow_duplicates"`
+	CreatedAt       time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// PlaylistEntry is ordered by Rank, sparse so that moving an entry rewrites
+// only its own row. Position is its 0-based index in the playlist.
+type PlaylistEntry struct {
+	ID         int       `json:"id" db:"id"`
+	PlaylistID int       `json:"playlistId" db:"playlist_id"`
+	SongID     int       `json:"songId" db:"song_id"`
+	Rank       int64     `json:"rank" db:"rank"`
+	Position   int       `json:"position" db:"position"`
+	Group      string    `json:"group" db:"group"`
+	Song       string    `json:"song" db:"song"`
+	AddedAt    time.Time `json:"addedAt" db:"added_at"`
+}
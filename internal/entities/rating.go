@@ -0,0 +1,25 @@
+package entities
+
+import "time"
+
+const (
+	ReviewPublished = "published"
+	ReviewPending   = "pending"
+	ReviewRejected  = "rejected"
+)
+
+// Rating is the rating of a song by a user with an optional review, which is
+// shown to the others only once published.
+type Rating struct {
+	ID           int       `json:"id" db:"id"`
+	SongID       int       `json:"songId" db:"song_id"`
+	UserID       int       `json:"userId" db:"user_id"`
+	Rating       int       `json:"rating" db:"rating"`
+	Review       *string   `json:"review" db:"review"`
+	ReviewStatus string    `json:"reviewStatus" db:"review_status"`
+	Group        string    `json:"group" db:"group"`
+	Song         string    `json:"song" db:"song"`
+	Author       string    `json:"author" db:"author"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+const (
+	RelationCover       = "cover"
+	RelationRemix       = "remix"
+	RelationLive        = "live"
+	RelationSample      = "sample"
+	RelationTranslation = "translation"
+)
+
+// SongRelation tells that the song is a version of the related one, or
+// samples it. Group and Song name the other song of the relation.
+type SongRelation struct {
+	ID        int       `json:"id" db:"id"`
+	SongID    int       `json:"songId" db:"song_id"`
+	RelatedID int       `json:"relatedId" db:"related_id"`
+	Kind      string    `json:"kind" db:"kind"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	Group     string    `json:"group" db:"group"`
+	Song      string    `json:"song" db:"song"`
+}
@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+const (
+	PermSongsRead   = "songs:read"
+	PermSongsCreate = "songs:create"
+	PermSongsUpdate = "songs:update"
+	PermSongsDelete = "songs:delete"
+	PermRolesManage = "roles:manage"
+
+	PermTenantsManage   = "tenants:manage"
+	PermPlaylistsManage = "playlists:manage"
+	PermReviewsModerate = "reviews:moderate"
+	PermTagsManage      = "tags:manage"
+
+	RoleAdmin = "admin"
+)
+
+type Role struct {
+	ID          int            `json:"id" db:"id"`
+	Name        string         `json:"name" db:"name"`
+	Description string         `json:"description" db:"description"`
+	Permissions pq.StringArray `json:"permissions" db:"permissions"`
+	Builtin     bool           `json:"builtin" db:"builtin"`
+	CreatedAt   time.Time      `json:"createdAt" db:"created_at"`
+}
@@ -1,10 +1,85 @@
 package entities
 
+import "time"
+
 type Song struct {
-	ID          int     `json:"id" db:"id"`
-	Group       string  `json:"group" db:"group"`
-	Song        string  `json:"song" db:"song"`
-	ReleaseDate *string `json:"releaseDate" db:"release_date"`
-	Link        *string `json:"link" db:"link"`
-	Text        *string `json:"text" db:"text"`
+	ID          int       `json:"id" db:"id"`
+	TenantID    string    `json:"tenantId" db:"tenant_id"`
+	Group       string    `json:"group" db:"group"`
+	Song        string    `json:"song" db:"song"`
+	ReleaseDate *string   `json:"releaseDate" db:"release_date"`
+	Link        *string   `json:"link" db:"link"`
+	Text        *string   `json:"text" db:"text"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+
+	// LanguageConfidence is from 0 to 1, see lyrics.DetectLanguage.
+	Language           *string  `json:"language" db:"language"`
+	LanguageConfidence *float64 `json:"languageConfidence" db:"language_confidence"`
+
+	// Explicit is detected from the lyrics, ExplicitOverride set by hand wins
+	// over it.
+	Explicit         bool  `json:"explicit" db:"explicit"`
+	ExplicitOverride *bool `json:"explicitOverride" db:"explicit_override"`
+
+	RatingAvg   *float64   `json:"ratingAvg" db:"rating_avg"`
+	RatingCount int        `json:"ratingCount" db:"rating_count"`
+	RatedAt     *time.Time `json:"ratedAt" db:"rated_at"`
+}
+
+// IsExplicit reports whether the song is explicit, by hand or detected.
+func (s *Song) IsExplicit() bool {
+	if s.ExplicitOverride != nil {
+		return *s.ExplicitOverride
+	}
+	return s.Explicit
+}
+
+// CleanFilter leaves the explicit songs out.
+type CleanFilter struct{}
+
+// LanguageFilter matches the songs detected to be in any of the languages.
+type LanguageFilter []string
+
+const (
+	SongChangeUpsert = "upsert"
+	SongChangeDelete = "delete"
+)
+
+// ChangePosition orders the changes by their transactions, see
+// song_library.change_xid, then by their seqs.
+type ChangePosition struct {
+	Xid int64
+	Seq int64
+}
+
+type SongChange struct {
+	Seq int64 `json:"seq" db:"change_seq"`
+	Xid int64 `json:"xid" db:"change_xid"`
+	// Bound is the oldest transaction running when the change was read.
+	Bound     int64     `json:"bound" db:"bound"`
+	Type      string    `json:"type" db:"type"`
+	ChangedAt time.Time `json:"changedAt" db:"changed_at"`
+	GroupKey  string    `json:"groupKey" db:"group_key"`
+	Song
+}
+
+const (
+	SongEventCreated = "created"
+	SongEventUpdated = "updated"
+	SongEventDeleted = "deleted"
+)
+
+type SongEvent struct {
+	Seq int64 `json:"seq"`
+	// Bound is the oldest transaction running when the event was written,
+	// the events of the ones below it were published before it.
+	Bound  int64  `json:"bound"`
+	Type   string `json:"type"`
+	Tenant string `json:"tenant"`
+	ID     int    `json:"id"`
+	Group  string `json:"group"`
+	// GroupKey is the song_name_key of the group.
+	GroupKey string `json:"groupKey"`
+	Song     string `json:"song"`
 }
@@ -0,0 +1,24 @@
+package entities
+
+type GroupCount struct {
+	Group string `json:"group" db:"group"`
+	Songs int    `json:"songs" db:"songs"`
+}
+
+type YearCount struct {
+	Year  int `json:"year" db:"year"`
+	Songs int `json:"songs" db:"songs"`
+}
+
+// LibraryStats aggregates the songs matching a filter. The release years are
+// taken from the dates that have one, AvgTextLength is in characters over
+// the songs with lyrics.
+type LibraryStats struct {
+	Songs              int      `json:"songs" db:"songs"`
+	WithoutText        int      `json:"withoutText" db:"without_text"`
+	WithoutLink        int      `json:"withoutLink" db:"without_link"`
+	WithoutReleaseDate int      `json:"withoutReleaseDate" db:"without_release_date"`
+	AvgTextLength      *float64 `json:"avgTextLength" db:"avg_text_length"`
+	Groups             []GroupCount
+	Years              []YearCount
+}
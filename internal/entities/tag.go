@@ -0,0 +1,33 @@
+package entities
+
+import "time"
+
+const (
+	TagGenre = "genre"
+	TagMood  = "mood"
+	TagTag   = "tag"
+
+	TagMatchAny = "any"
+	TagMatchAll = "all"
+)
+
+// Tag classifies the songs of a tenant. Only genres have a parent, a genre
+// matches the songs tagged with its subgenres too.
+type Tag struct {
+	ID        int       `json:"id" db:"id"`
+	TenantID  string    `json:"tenantId" db:"tenant_id"`
+	Name      string    `json:"name" db:"name"`
+	Kind      string    `json:"kind" db:"kind"`
+	ParentID  *int      `json:"parentId" db:"parent_id"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+type TagCount struct {
+	Tag
+	Songs int `json:"songs" db:"songs"`
+}
+
+type SongTag struct {
+	SongID int    `json:"songId" db:"song_id"`
+	Name   string `json:"name" db:"name"`
+}
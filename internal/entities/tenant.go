@@ -0,0 +1,11 @@
+package entities
+
+import "time"
+
+const DefaultTenant = "default"
+
+type Tenant struct {
+	ID        string    `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
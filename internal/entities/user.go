@@ -0,0 +1,27 @@
+package entities
+
+import "time"
+
+const SessionTokenPrefix = "st_"
+
+type User struct {
+	ID           int       `json:"id" db:"id"`
+	TenantID     string    `json:"tenantId" db:"tenant_id"`
+	Email        string    `json:"email" db:"email"`
+	Name         string    `json:"name" db:"name"`
+	PasswordHash string    `json:"-" db:"password_hash"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
+type Session struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"userId" db:"user_id"`
+	TokenHash string    `json:"-" db:"token_hash"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	ExpiresAt time.Time `json:"expiresAt" db:"expires_at"`
+}
+
+type FavoriteSong struct {
+	FavoritedAt time.Time `db:"favorited_at"`
+	Song
+}
@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"github.com/lib/pq"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+type Webhook struct {
+	ID         int            `json:"id" db:"id"`
+	TenantID   string         `json:"tenantId" db:"tenant_id"`
+	URL        string         `json:"url" db:"url"`
+	EventTypes pq.StringArray `json:"eventTypes" db:"event_types"`
+	Secret     string         `json:"secret" db:"secret"`
+	Active     bool           `json:"active" db:"active"`
+	CreatedAt  time.Time      `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time      `json:"updatedAt" db:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             int        `json:"id" db:"id"`
+	WebhookID      int        `json:"webhookId" db:"webhook_id"`
+	EventID        int64      `json:"eventId" db:"event_id"`
+	EventBound     int64      `json:"-" db:"event_bound"`
+	EventType      string     `json:"eventType" db:"event_type"`
+	Payload        string     `json:"payload" db:"payload"`
+	Status         string     `json:"status" db:"status"`
+	Attempts       int        `json:"attempts" db:"attempts"`
+	ResponseStatus *int       `json:"responseStatus" db:"response_status"`
+	Error          *string    `json:"error" db:"error"`
+	RedeliveryOf   *int       `json:"redeliveryOf" db:"redelivery_of"`
+	NextAttemptAt  time.Time  `json:"nextAttemptAt" db:"next_attempt_at"`
+	CreatedAt      time.Time  `json:"createdAt" db:"created_at"`
+	DeliveredAt    *time.Time `json:"deliveredAt" db:"delivered_at"`
+}
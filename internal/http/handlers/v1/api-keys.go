@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type apiKeys struct {
+	akuc *usecases.ApiKeys
+	log  *slog.Logger
+}
+
+func newApiKeys(akuc *usecases.ApiKeys, log *slog.Logger) *apiKeys {
+	return &apiKeys{
+		akuc: akuc,
+		log:  log,
+	}
+}
+
+// @Summary API keys
+// @Tags api-keys
+// @Description Issue an API key, the key itself is only returned once. Keys belong to the tenant of the request,
+// @Description callers bound to no tenant may leave tenantId out to issue keys bound to none. The scopes are limited
+// @Description to the ones of the caller
+// @ID create-api-key
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param input body dto.CreateApiKeyRequest true "api key info"
+// @Success 201 {object} dto.CreateApiKeyResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/keys [post]
+func (ak *apiKeys) create(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.apiKeys.create"
+
+	ak.log = ak.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.CreateApiKeyRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		ak.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	ak.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	apiKey, err := ak.akuc.Create(r.Context(), &req)
+	if err != nil {
+		ak.log.Error("failed to create api key", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "the key can only be issued for the tenant of the request")
+
+			return
+		}
+
+		if errors.Is(err, usecases.ErrUnknownTenant) {
+			response.RenderError(w, r, response.CodeUnknownTenant, "unknown tenant")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, apiKey)
+}
+
+// @Summary API keys
+// @Tags api-keys
+// @Description Get a list of the API keys of the tenant
+// @ID get-api-keys-list
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param offset query int false "paginate through the api keys list"
+// @Param limit query int false "sets the list limit"
+// @Success 200 {array} dto.ApiKeyResponse
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/keys [get]
+func (ak *apiKeys) getList(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.apiKeys.getList"
+
+	ak.log = ak.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	apiKeys, err := ak.akuc.GetList(r.Context(), pagination.Get(r.Context()))
+	if err != nil {
+		ak.log.Error("failed to get list of api keys", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "api keys not found")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, apiKeys)
+}
+
+// @Summary API keys
+// @Tags api-keys
+// @Description Revoke a specific API key
+// @ID revoke-api-key
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path int true "api key id"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/keys/{id} [delete]
+func (ak *apiKeys) revoke(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.apiKeys.revoke"
+
+	ak.log = ak.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	err = ak.akuc.Revoke(r.Context(), id)
+	if err != nil {
+		ak.log.Error("failed to revoke api key", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "active api key is not found")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+	"log/slog"
+	"net/http"
+)
+
+type credits struct {
+	cruc *usecases.Credits
+	log  *slog.Logger
+}
+
+func newCredits(cruc *usecases.Credits, log *slog.Logger) *credits {
+	return &credits{
+		cruc: cruc,
+		log:  log,
+	}
+}
+
+// @Summary Credits
+// @Tags credits
+// @Description Get the people of the tenant by name
+// @ID get-people-list
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param name query string false "only the people whose name starts with it, case insensitive"
+// @Param offset query int false "paginate through the people list"
+// @Param limit query int false "sets the list limit"
+// @Success 200 {array} dto.PersonResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use"
+// @Failure 422 {object} response.Problem "unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/people [get]
+func (cr *credits) getPeople(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.credits.getPeople"
+
+	cr.log = cr.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.GetPeopleRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		cr.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return
+	}
+
+	res, err := cr.cruc.GetPeople(r.Context(), req.Name, pagination.Get(r.Context()))
+	if err != nil {
+		cr.log.Error("failed to get list of people", slog.String("error", err.Error()))
+
+		cr.renderError(w, r, err, "")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Credits
+// @Tags credits
+// @Description Add a person to the tenant
+// @ID create-person
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.PersonRequest true "person info"
+// @Success 201 {object} dto.PersonResponse
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/people [post]
+func (cr *credits) createPerson(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.credits.createPerson"
+
+	cr.log = cr.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.PersonRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		cr.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	cr.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := cr.cruc.CreatePerson(r.Context(), &req)
+	if err != nil {
+		cr.log.Error("failed to create person", slog.String("error", err.Error()))
+
+		cr.renderError(w, r, err, "")
+
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, res)
+}
+
+// @Summary Credits
+// @Tags credits
+// @Description Rename the person on every song they are credited on
+// @ID update-person
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "person id"
+// @Param input body dto.PersonRequest true "the new name"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/people/{id} [put]
+func (cr *credits) updatePerson(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.credits.updatePerson"
+
+	cr.log = cr.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	var req dto.PersonRequest
+
+	err = render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		cr.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	cr.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = cr.cruc.UpdatePerson(r.Context(), id, &req)
+	if err != nil {
+		cr.log.Error("failed to update person", slog.String("error", err.Error()))
+
+		cr.renderError(w, r, err, "person for update is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Credits
+// @Tags credits
+// @Description Delete the person, the ones still credited on a song can't be deleted
+// @ID delete-person
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "person id"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/people/{id} [delete]
+func (cr *credits) deletePerson(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.credits.deletePerson"
+
+	cr.log = cr.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	err = cr.cruc.DeletePerson(r.Context(), id)
+	if err != nil {
+		cr.log.Error("failed to delete person", slog.String("error", err.Error()))
+
+		cr.renderError(w, r, err, "person for deletion is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Credits
+// @Tags credits
+// @Description Get the credits of the song in the order they were added
+// @ID get-song-credits
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param group query string true "group name"
+// @Param song query string true "song name"
+// @Success 200 {array} dto.CreditResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/credits [get]
+func (cr *credits) getSongCredits(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.credits.getSongCredits"
+
+	cr.log = cr.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.GetSongCreditsRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		cr.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return
+	}
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := cr.cruc.GetSongCredits(r.Context(), req.Group, req.Song)
+	if err != nil {
+		cr.log.Error("failed to get song credits", slog.String("error", err.Error()))
+
+		cr.renderError(w, r, err, "song is not found")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Credits
+// @Tags credits
+// @Description Credit the person on the song, the person is created when there is no one by the name yet
+// @ID add-song-credit
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.AddSongCreditRequest true "song info, the person name and the role"
+// @Success 201 {object} dto.CreditResponse
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/credits [post]
+func (cr *credits) addCredit(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.credits.addCredit"
+
+	cr.log = cr.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.AddSongCreditRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		cr.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	cr.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := cr.cruc.AddCredit(r.Context(), &req)
+	if err != nil {
+		cr.log.Error("failed to add song credit", slog.String("error", err.Error()))
+
+		cr.renderError(w, r, err, "song is not found")
+
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, res)
+}
+
+// @Summary Credits
+// @Tags credits
+// @Description Remove the person from the song, from every role unless one is given
+// @ID remove-song-credit
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.RemoveSongCreditRequest true "song info, the person name and optionally the role"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/credits [delete]
+func (cr *credits) removeCredit(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.credits.removeCredit"
+
+	cr.log = cr.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.RemoveSongCreditRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		cr.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	cr.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = cr.cruc.RemoveCredit(r.Context(), &req)
+	if err != nil {
+		cr.log.Error("failed to remove song credit", slog.String("error", err.Error()))
+
+		cr.renderError(w, r, err, "song or credit is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+func (cr *credits) renderError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	if errors.Is(err, usecases.ErrForbidden) {
+		response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+		return
+	} else if errors.Is(err, usecases.ErrAlreadyExists) {
+		response.RenderError(w, r, response.CodeAlreadyExists, "already exists")
+
+		return
+	} else if errors.Is(err, usecases.ErrInUse) {
+		response.RenderError(w, r, response.CodeInUse, "person is still credited on songs")
+
+		return
+	} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+		response.RenderError(w, r, response.CodeNotFound, notFound)
+
+		return
+	}
+
+	response.RenderError(w, r, response.CodeInternal, "internal error")
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+	"log/slog"
+	"net/http"
+)
+
+type duplicates struct {
+	dpuc *usecases.Duplicates
+	log  *slog.Logger
+}
+
+func newDuplicates(dpuc *usecases.Duplicates, log *slog.Logger) *duplicates {
+	return &duplicates{
+		dpuc: dpuc,
+		log:  log,
+	}
+}
+
+// @Summary Duplicates
+// @Tags duplicates
+// @Description Get the pairs of songs likely to be duplicates, the likeliest first. Pairs share the group, up to case, spacing and punctuation, or the link, and are scored from the names, the lyrics and the links
+// @ID get-duplicates
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param minScore query number false "the lowest score reported, 0.8 by default" minimum(0) maximum(1)
+// @Param offset query int false "paginate through the pairs"
+// @Param limit query int false "sets the list limit"
+// @Success 200 {array} dto.DuplicatePairResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, same-song"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/duplicates [get]
+func (dp *duplicates) getList(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.duplicates.getList"
+
+	dp.log = dp.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.GetDuplicatesRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		dp.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return
+	}
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := dp.dpuc.Find(r.Context(), req.MinScore, pagination.Get(r.Context()))
+	if err != nil {
+		dp.log.Error("failed to find duplicates", slog.String("error", err.Error()))
+
+		dp.renderError(w, r, err)
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Duplicates
+// @Tags duplicates
+// @Description Merge the loser into the survivor. Each field keeps the value of the chosen song, the survivor's unless it has none by default. The loser's favorites, ratings, playlist entries, plays, tags, credits and relations move to the survivor, the loser is deleted and its name keeps resolving to the survivor
+// @ID merge-songs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.MergeSongsRequest true "the survivor and the loser, by id or by names, and the field choices"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, same-song, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/merge [post]
+func (dp *duplicates) merge(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.duplicates.merge"
+
+	dp.log = dp.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.MergeSongsRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		dp.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	dp.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = dp.dpuc.Merge(r.Context(), &req)
+	if err != nil {
+		dp.log.Error("failed to merge songs", slog.String("error", err.Error()))
+
+		dp.renderError(w, r, err)
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+func (dp *duplicates) renderError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, usecases.ErrForbidden) {
+		response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+		return
+	} else if errors.Is(err, usecases.ErrSameSong) {
+		response.RenderError(w, r, response.CodeSameSong, "survivor and loser are the same song")
+
+		return
+	} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+		response.RenderError(w, r, response.CodeNotFound, "song for merge is not found")
+
+		return
+	}
+
+	response.RenderError(w, r, response.CodeInternal, "internal error")
+}
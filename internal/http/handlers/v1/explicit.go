@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+	"log/slog"
+	"net/http"
+)
+
+type explicit struct {
+	exuc *usecases.Explicit
+	log  *slog.Logger
+}
+
+func newExplicit(exuc *usecases.Explicit, log *slog.Logger) *explicit {
+	return &explicit{
+		exuc: exuc,
+		log:  log,
+	}
+}
+
+// @Summary Explicit
+// @Tags explicit
+// @Description Get whether the song is explicit, detected from the EXPLICIT_WORDLIST or set by hand, and the lines of the lyrics with the listed words
+// @ID get-explicit-report
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param group query string true "group name"
+// @Param song query string true "song name"
+// @Success 200 {object} dto.ExplicitReportResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/explicit [get]
+func (ex *explicit) getReport(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.explicit.getReport"
+
+	ex.log = ex.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.GetExplicitReportRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		ex.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return
+	}
+
+	ex.log.Info("request query decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := ex.exuc.GetReport(r.Context(), req.Group, req.Song)
+	if err != nil {
+		ex.log.Error("failed to get explicit report", slog.String("error", err.Error()))
+
+		ex.renderError(w, r, err)
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Explicit
+// @Tags explicit
+// @Description Mark the song explicit or clean by hand whatever its lyrics, null goes back to the detected flag
+// @ID set-explicit
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.SetExplicitRequest true "song info and the flag"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/explicit [put]
+func (ex *explicit) setOverride(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.explicit.setOverride"
+
+	ex.log = ex.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.SetExplicitRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		ex.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	ex.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = ex.exuc.SetOverride(r.Context(), &req)
+	if err != nil {
+		ex.log.Error("failed to set explicit override", slog.String("error", err.Error()))
+
+		ex.renderError(w, r, err)
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+func (ex *explicit) renderError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, usecases.ErrForbidden) {
+		response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+		return
+	} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+		response.RenderError(w, r, response.CodeNotFound, "song is not found")
+
+		return
+	}
+
+	response.RenderError(w, r, response.CodeInternal, "internal error")
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type favorites struct {
+	fvuc *usecases.Favorites
+	log  *slog.Logger
+}
+
+func newFavorites(fvuc *usecases.Favorites, log *slog.Logger) *favorites {
+	return &favorites{
+		fvuc: fvuc,
+		log:  log,
+	}
+}
+
+// @Summary Favorites
+// @Tags favorites
+// @Description Get the favorite songs of the signed in user, the latest added first
+// @ID get-favorites-list
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param offset query int false "paginate through the favorites list"
+// @Param limit query int false "sets the list limit"
+// @Success 200 {array} dto.FavoriteSongResponse
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/favorites [get]
+func (fv *favorites) getList(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.favorites.getList"
+
+	fv.log = fv.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	songs, err := fv.fvuc.GetList(r.Context(), pagination.Get(r.Context()))
+	if err != nil {
+		fv.log.Error("failed to get list of favorites", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "only available to users")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, songs)
+}
+
+// @Summary Favorites
+// @Tags favorites
+// @Description Add the song to the favorites of the signed in user
+// @ID add-favorite
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body dto.FavoriteRequest true "song info"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "validation-failed"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/favorites [post]
+func (fv *favorites) add(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.favorites.add"
+
+	fv.log = fv.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.FavoriteRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		fv.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	fv.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = fv.fvuc.Add(r.Context(), req.Group, req.Song)
+	if err != nil {
+		fv.log.Error("failed to add favorite", slog.String("error", err.Error()))
+
+		fv.renderError(w, r, err, "song is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Favorites
+// @Tags favorites
+// @Description Remove the song from the favorites of the signed in user
+// @ID remove-favorite
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param input body dto.FavoriteRequest true "song info"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "validation-failed"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/favorites [delete]
+func (fv *favorites) remove(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.favorites.remove"
+
+	fv.log = fv.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.FavoriteRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		fv.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	fv.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = fv.fvuc.Remove(r.Context(), req.Group, req.Song)
+	if err != nil {
+		fv.log.Error("failed to remove favorite", slog.String("error", err.Error()))
+
+		fv.renderError(w, r, err, "song is not in the favorites")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+func (fv *favorites) renderError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	if errors.Is(err, usecases.ErrForbidden) {
+		response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+		return
+	} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+		response.RenderError(w, r, response.CodeNotFound, notFound)
+
+		return
+	}
+
+	response.RenderError(w, r, response.CodeInternal, "internal error")
+}
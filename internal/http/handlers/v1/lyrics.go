@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+	"log/slog"
+	"net/http"
+)
+
+type lyrics struct {
+	lyuc *usecases.Lyrics
+	log  *slog.Logger
+}
+
+func newLyrics(lyuc *usecases.Lyrics, log *slog.Logger) *lyrics {
+	return &lyrics{
+		lyuc: lyuc,
+		log:  log,
+	}
+}
+
+// @Summary Lyrics
+// @Tags lyrics
+// @Description Get the word statistics of the song lyrics: the number of words, of distinct ones, the lexical diversity (distinct over all words) and the most used words, stopwords left out. The words are lowercased, ё is counted as е
+// @ID get-song-lyrics-stats
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param group query string true "group name"
+// @Param song query string true "song name"
+// @Param limit query int false "number of the most used words, 20 by default"
+// @Success 200 {object} dto.LyricsStatsResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/text/stats [get]
+func (ly *lyrics) getSong(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.lyrics.getSong"
+
+	ly.log = ly.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.GetSongLyricsStatsRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		ly.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return
+	}
+
+	ly.log.Info("request query decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := ly.lyuc.GetSong(r.Context(), &req)
+	if err != nil {
+		ly.log.Error("failed to get song lyrics stats", slog.String("error", err.Error()))
+
+		ly.renderError(w, r, err)
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Lyrics
+// @Tags lyrics
+// @Description Get the word statistics of the lyrics of the group, of the songs released within the years when they are set. The songs are indexed in the background and show up here up to LYRICS_INDEX_INTERVAL after their lyrics change
+// @ID get-group-lyrics-stats
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param group query string true "group name"
+// @Param fromYear query int false "only the songs released in or after the year"
+// @Param toYear query int false "only the songs released in or before the year"
+// @Param limit query int false "number of the most used words, 20 by default"
+// @Success 200 {object} dto.GroupLyricsStatsResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/stats/lyrics [get]
+func (ly *lyrics) getGroup(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.lyrics.getGroup"
+
+	ly.log = ly.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.GetGroupLyricsStatsRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		ly.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return
+	}
+
+	ly.log.Info("request query decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := ly.lyuc.GetGroup(r.Context(), &req)
+	if err != nil {
+		ly.log.Error("failed to get group lyrics stats", slog.String("error", err.Error()))
+
+		ly.renderError(w, r, err)
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+func (ly *lyrics) renderError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, usecases.ErrForbidden) {
+		response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+		return
+	} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+		response.RenderError(w, r, response.CodeNotFound, "song is not found")
+
+		return
+	}
+
+	response.RenderError(w, r, response.CodeInternal, "internal error")
+}
@@ -0,0 +1,522 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type playlists struct {
+	pluc *usecases.Playlists
+	log  *slog.Logger
+}
+
+func newPlaylists(pluc *usecases.Playlists, log *slog.Logger) *playlists {
+	return &playlists{
+		pluc: pluc,
+		log:  log,
+	}
+}
+
+// @Summary Playlists
+// @Tags playlists
+// @Description Get the playlists of the tenant: the public ones, your own and, with playlists:manage, the private ones of the tenant
+// @ID get-playlists-list
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param offset query int false "paginate through the playlists list"
+// @Param limit query int false "sets the list limit"
+// @Success 200 {array} dto.PlaylistResponse
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/playlists [get]
+func (pl *playlists) getList(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.playlists.getList"
+
+	pl.log = pl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	res, err := pl.pluc.GetList(r.Context(), pagination.Get(r.Context()))
+	if err != nil {
+		pl.log.Error("failed to get list of playlists", slog.String("error", err.Error()))
+
+		pl.renderError(w, r, err, "")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Playlists
+// @Tags playlists
+// @Description Create a playlist, owned by the signed in user or, for the other credentials with playlists:manage, by the tenant
+// @ID create-playlist
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.CreatePlaylistRequest true "playlist info, private by default"
+// @Success 201 {object} dto.PlaylistResponse
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/playlists [post]
+func (pl *playlists) create(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.playlists.create"
+
+	pl.log = pl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.CreatePlaylistRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		pl.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	pl.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := pl.pluc.Create(r.Context(), &req)
+	if err != nil {
+		pl.log.Error("failed to create playlist", slog.String("error", err.Error()))
+
+		pl.renderError(w, r, err, "")
+
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, res)
+}
+
+// @Summary Playlists
+// @Tags playlists
+// @Description Get a specific playlist with its entries in order
+// @ID get-playlist
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param id path int true "playlist id"
+// @Success 200 {object} dto.PlaylistWithEntriesResponse
+// @Failure 400 {object} response.Problem "invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/playlists/{id} [get]
+func (pl *playlists) get(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.playlists.get"
+
+	pl.log = pl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	res, err := pl.pluc.Get(r.Context(), id)
+	if err != nil {
+		pl.log.Error("failed to get playlist", slog.String("error", err.Error()))
+
+		pl.renderError(w, r, err, "playlist not found")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Playlists
+// @Tags playlists
+// @Description Update a specific playlist, omitted fields are kept
+// @ID update-playlist
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "playlist id"
+// @Param input body dto.UpdatePlaylistRequest true "the fields to update"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, nothing-to-update, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/playlists/{id} [put]
+func (pl *playlists) update(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.playlists.update"
+
+	pl.log = pl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	var req dto.UpdatePlaylistRequest
+
+	err = render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		pl.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	pl.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = pl.pluc.Update(r.Context(), id, &req)
+	if err != nil {
+		pl.log.Error("failed to update playlist", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrNullFields) {
+			response.RenderError(w, r, response.CodeNothingToUpdate, "nothing to update")
+
+			return
+		}
+
+		pl.renderError(w, r, err, "playlist for update is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Playlists
+// @Tags playlists
+// @Description Delete a specific playlist with its entries
+// @ID delete-playlist
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "playlist id"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/playlists/{id} [delete]
+func (pl *playlists) delete(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.playlists.delete"
+
+	pl.log = pl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	err = pl.pluc.Delete(r.Context(), id)
+	if err != nil {
+		pl.log.Error("failed to delete playlist", slog.String("error", err.Error()))
+
+		pl.renderError(w, r, err, "playlist for deletion is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Playlists
+// @Tags playlists
+// @Description Add the song to the playlist at the position, at the end without one. When the playlist doesn't allow duplicates and already has the song, its existing entry is returned with 200
+// @ID add-playlist-entry
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "playlist id"
+// @Param input body dto.AddPlaylistEntryRequest true "song info and the 0-based position"
+// @Success 200 {object} dto.PlaylistEntryResponse
+// @Success 201 {object} dto.PlaylistEntryResponse
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/playlists/{id}/entries [post]
+func (pl *playlists) addEntry(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.playlists.addEntry"
+
+	pl.log = pl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	var req dto.AddPlaylistEntryRequest
+
+	err = render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		pl.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	pl.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, added, err := pl.pluc.AddEntry(r.Context(), id, &req)
+	if err != nil {
+		pl.log.Error("failed to add playlist entry", slog.String("error", err.Error()))
+
+		pl.renderError(w, r, err, "playlist or song is not found")
+
+		return
+	}
+
+	if added {
+		render.Status(r, http.StatusCreated)
+	} else {
+		render.Status(r, http.StatusOK)
+	}
+	render.JSON(w, r, res)
+}
+
+// @Summary Playlists
+// @Tags playlists
+// @Description Move the entry to the 0-based position, to the end when it's past the last entry
+// @ID move-playlist-entry
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "playlist id"
+// @Param entryId path int true "entry id"
+// @Param input body dto.MovePlaylistEntryRequest true "the new position"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/playlists/{id}/entries/{entryId} [put]
+func (pl *playlists) moveEntry(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.playlists.moveEntry"
+
+	pl.log = pl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	entryID, err := urlParamID(r, "entryId")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	var req dto.MovePlaylistEntryRequest
+
+	err = render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		pl.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	pl.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = pl.pluc.MoveEntry(r.Context(), id, entryID, &req)
+	if err != nil {
+		pl.log.Error("failed to move playlist entry", slog.String("error", err.Error()))
+
+		pl.renderError(w, r, err, "playlist entry is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Playlists
+// @Tags playlists
+// @Description Remove the entry from the playlist
+// @ID remove-playlist-entry
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "playlist id"
+// @Param entryId path int true "entry id"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/playlists/{id}/entries/{entryId} [delete]
+func (pl *playlists) removeEntry(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.playlists.removeEntry"
+
+	pl.log = pl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	entryID, err := urlParamID(r, "entryId")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	err = pl.pluc.RemoveEntry(r.Context(), id, entryID)
+	if err != nil {
+		pl.log.Error("failed to remove playlist entry", slog.String("error", err.Error()))
+
+		pl.renderError(w, r, err, "playlist entry is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+func (pl *playlists) renderError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	if errors.Is(err, usecases.ErrForbidden) {
+		response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+		return
+	} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+		response.RenderError(w, r, response.CodeNotFound, notFound)
+
+		return
+	}
+
+	response.RenderError(w, r, response.CodeInternal, "internal error")
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+	"log/slog"
+	"net/http"
+)
+
+type plays struct {
+	pyuc *usecases.Plays
+	log  *slog.Logger
+}
+
+func newPlays(pyuc *usecases.Plays, log *slog.Logger) *plays {
+	return &plays{
+		pyuc: pyuc,
+		log:  log,
+	}
+}
+
+// @Summary Plays
+// @Tags plays
+// @Description Report the plays of the songs, they are counted in batches and show up in the stats within PLAYS_FLUSH_INTERVAL. Reading the lyrics via /v1/songs/text counts as a play on its own
+// @ID ingest-plays
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.PlayEventsRequest true "up to 100 plays"
+// @Success 202 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/plays [post]
+func (py *plays) ingest(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.plays.ingest"
+
+	py.log = py.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.PlayEventsRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		py.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	py.log.Info("request body decoded", slog.Int("events", len(req.Events)))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = py.pyuc.Ingest(r.Context(), &req)
+	if err != nil {
+		py.log.Error("failed to ingest plays", slog.String("error", err.Error()))
+
+		py.renderError(w, r, err, "song not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusAccepted, "")
+}
+
+// @Summary Plays
+// @Tags plays
+// @Description Get the songs trending within the window, scored by their plays with a play weighing half as much every quarter of the window
+// @ID get-trending-songs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param window query string false "one of TRENDING_WINDOWS, TRENDING_DEFAULT_WINDOW by default"
+// @Param offset query int false "paginate through the songs list"
+// @Param limit query int false "sets the list limit"
+// @Success 200 {array} dto.TrendingSongResponse
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/trending [get]
+func (py *plays) getTrending(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.plays.getTrending"
+
+	py.log = py.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.GetTrendingRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		py.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return
+	}
+
+	py.log.Info("request query decoded", slog.Any("request", req))
+
+	res, err := py.pyuc.GetTrending(r.Context(), req.Window, pagination.Get(r.Context()))
+	if err != nil {
+		py.log.Error("failed to get trending songs", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrUnknownWindow) {
+			response.RenderError(w, r, response.CodeInvalidParameter, "unknown window")
+
+			return
+		}
+
+		py.renderError(w, r, err, "")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Plays
+// @Tags plays
+// @Description Get the play counters of the song, hourly for the last day or daily for the last 30 days by default
+// @ID get-play-stats
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param group query string true "group name"
+// @Param song query string true "song name"
+// @Param granularity query string false "counters granularity" Enums(hour, day)
+// @Param since query string false "start of the range" format(date-time)
+// @Param until query string false "end of the range, now by default" format(date-time)
+// @Success 200 {object} dto.PlayStatsResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/plays [get]
+func (py *plays) getStats(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.plays.getStats"
+
+	py.log = py.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.GetPlayStatsRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		py.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return
+	}
+
+	py.log.Info("request query decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := py.pyuc.GetStats(r.Context(), &req)
+	if err != nil {
+		py.log.Error("failed to get play stats", slog.String("error", err.Error()))
+
+		py.renderError(w, r, err, "song not found")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+func (py *plays) renderError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	if errors.Is(err, usecases.ErrForbidden) {
+		response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+		return
+	} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+		response.RenderError(w, r, response.CodeNotFound, notFound)
+
+		return
+	}
+
+	response.RenderError(w, r, response.CodeInternal, "internal error")
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/http/response"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"net/http"
+)
+
+type problems struct{}
+
+func newProblems() *problems {
+	return &problems{}
+}
+
+// @Summary Problems
+// @Tags problems
+// @Description Get the catalogue of the codes the errors are reported with
+// @ID get-problems
+// @Produce json
+// @Success 200 {array} response.ProblemType
+// @Router /problems [get]
+func (pr *problems) getList(w http.ResponseWriter, r *http.Request) {
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, response.ProblemTypes)
+}
+
+// @Summary Problems
+// @Tags problems
+// @Description Get the problem type the type URI of an error refers to
+// @ID get-problem
+// @Produce json
+// @Param code path string true "problem code"
+// @Success 200 {object} response.ProblemType
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure default {object} response.Problem
+// @Router /problems/{code} [get]
+func (pr *problems) get(w http.ResponseWriter, r *http.Request) {
+	code := response.Code(chi.URLParam(r, "code"))
+
+	for _, pt := range response.ProblemTypes {
+		if pt.Code == code {
+			render.Status(r, http.StatusOK)
+			render.JSON(w, r, pt)
+
+			return
+		}
+	}
+
+	response.RenderError(w, r, response.CodeNotFound, "unknown problem code")
+}
+
+func (pr *problems) notFound(w http.ResponseWriter, r *http.Request) {
+	response.RenderError(w, r, response.CodeNotFound, "route not found")
+}
+
+func (pr *problems) methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	response.RenderError(w, r, response.CodeMethodNotAllowed, "method not allowed")
+}
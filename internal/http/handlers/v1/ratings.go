@@ -0,0 +1,446 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+	"log/slog"
+	"net/http"
+)
+
+type ratings struct {
+	rtuc *usecases.Ratings
+	log  *slog.Logger
+}
+
+func newRatings(rtuc *usecases.Ratings, log *slog.Logger) *ratings {
+	return &ratings{
+		rtuc: rtuc,
+		log:  log,
+	}
+}
+
+// @Summary Ratings
+// @Tags ratings
+// @Description Get the ratings of the signed in user, the latest changed first
+// @ID get-ratings-list
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param offset query int false "paginate through the ratings list"
+// @Param limit query int false "sets the list limit"
+// @Success 200 {array} dto.RatingResponse
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/ratings [get]
+func (rt *ratings) getList(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.ratings.getList"
+
+	rt.log = rt.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	res, err := rt.rtuc.GetList(r.Context(), pagination.Get(r.Context()))
+	if err != nil {
+		rt.log.Error("failed to get list of ratings", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "only available to users")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Ratings
+// @Tags ratings
+// @Description Rate the song from 1 to 5 with an optional review. Reviews with blocked words are held for moderation
+// @ID create-rating
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.RateSongRequest true "rating info"
+// @Success 201 {object} dto.RatingResponse
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/ratings [post]
+func (rt *ratings) create(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.ratings.create"
+
+	rt.log = rt.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.RateSongRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		rt.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	rt.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := rt.rtuc.Create(r.Context(), &req)
+	if err != nil {
+		rt.log.Error("failed to create rating", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrAlreadyExists) {
+			response.RenderError(w, r, response.CodeAlreadyExists, "song is already rated")
+
+			return
+		}
+
+		rt.renderError(w, r, err, "song is not found")
+
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, res)
+}
+
+// @Summary Ratings
+// @Tags ratings
+// @Description Update the rating of the song, omitted fields are kept. A changed review is moderated again
+// @ID update-rating
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.UpdateRatingRequest true "the fields to update, an empty review removes it"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, nothing-to-update, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/ratings [put]
+func (rt *ratings) update(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.ratings.update"
+
+	rt.log = rt.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.UpdateRatingRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		rt.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	rt.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = rt.rtuc.Update(r.Context(), &req)
+	if err != nil {
+		rt.log.Error("failed to update rating", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrNullFields) {
+			response.RenderError(w, r, response.CodeNothingToUpdate, "nothing to update")
+
+			return
+		}
+
+		rt.renderError(w, r, err, "rating for update is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Ratings
+// @Tags ratings
+// @Description Delete the rating of the song with its review
+// @ID delete-rating
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.DeleteRatingRequest true "song info"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/ratings [delete]
+func (rt *ratings) delete(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.ratings.delete"
+
+	rt.log = rt.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.DeleteRatingRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		rt.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	rt.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = rt.rtuc.Delete(r.Context(), req.Group, req.Song)
+	if err != nil {
+		rt.log.Error("failed to delete rating", slog.String("error", err.Error()))
+
+		rt.renderError(w, r, err, "rating for deletion is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Reviews
+// @Tags ratings
+// @Description Get the published reviews of the song, the latest changed first
+// @ID get-reviews-list
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param group query string true "group name"
+// @Param song query string true "song name"
+// @Param offset query int false "paginate through the reviews list"
+// @Param limit query int false "sets the list limit"
+// @Success 200 {array} dto.ReviewResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/reviews [get]
+func (rt *ratings) getReviews(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.ratings.getReviews"
+
+	rt.log = rt.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.GetReviewsRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		rt.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return
+	}
+
+	rt.log.Info("request query decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := rt.rtuc.GetReviews(r.Context(), req.Group, req.Song, pagination.Get(r.Context()))
+	if err != nil {
+		rt.log.Error("failed to get list of reviews", slog.String("error", err.Error()))
+
+		rt.renderError(w, r, err, "song not found")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Reviews
+// @Tags ratings
+// @Description Get the reviews of the tenant awaiting moderation, requires reviews:moderate
+// @ID get-pending-reviews-list
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param offset query int false "paginate through the reviews list"
+// @Param limit query int false "sets the list limit"
+// @Success 200 {array} dto.ReviewResponse
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/reviews/pending [get]
+func (rt *ratings) getPending(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.ratings.getPending"
+
+	rt.log = rt.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	res, err := rt.rtuc.GetPending(r.Context(), pagination.Get(r.Context()))
+	if err != nil {
+		rt.log.Error("failed to get list of pending reviews", slog.String("error", err.Error()))
+
+		rt.renderError(w, r, err, "")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Reviews
+// @Tags ratings
+// @Description Publish or reject the review, requires reviews:moderate
+// @ID moderate-review
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "review id"
+// @Param input body dto.ModerateReviewRequest true "the decision"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/reviews/{id}/status [put]
+func (rt *ratings) moderate(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.ratings.moderate"
+
+	rt.log = rt.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	var req dto.ModerateReviewRequest
+
+	err = render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		rt.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	rt.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = rt.rtuc.Moderate(r.Context(), id, req.Status)
+	if err != nil {
+		rt.log.Error("failed to moderate review", slog.String("error", err.Error()))
+
+		rt.renderError(w, r, err, "review is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+func (rt *ratings) renderError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	if errors.Is(err, usecases.ErrForbidden) {
+		response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+		return
+	} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+		response.RenderError(w, r, response.CodeNotFound, notFound)
+
+		return
+	}
+
+	response.RenderError(w, r, response.CodeInternal, "internal error")
+}
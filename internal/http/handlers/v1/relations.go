@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type relations struct {
+	rluc *usecases.Relations
+	log  *slog.Logger
+}
+
+func newRelations(rluc *usecases.Relations, log *slog.Logger) *relations {
+	return &relations{
+		rluc: rluc,
+		log:  log,
+	}
+}
+
+// @Summary Relations
+// @Tags relations
+// @Description Mark the song as a cover, remix, live version or translation of the original, or as sampling it
+// @ID link-songs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.SongRelationRequest true "the derived song, the kind and the original"
+// @Success 201 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, invalid-relation, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/relations [post]
+func (rl *relations) link(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.relations.link"
+
+	rl.log = rl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	req, ok := rl.decode(w, r)
+	if !ok {
+		return
+	}
+
+	err := rl.rluc.Link(r.Context(), req)
+	if err != nil {
+		rl.log.Error("failed to link songs", slog.String("error", err.Error()))
+
+		rl.renderError(w, r, err, "song or original is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusCreated, "")
+}
+
+// @Summary Relations
+// @Tags relations
+// @Description Remove the relation between the song and the original
+// @ID unlink-songs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.SongRelationRequest true "the derived song, the kind and the original"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, invalid-relation, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/relations [delete]
+func (rl *relations) unlink(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.relations.unlink"
+
+	rl.log = rl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	req, ok := rl.decode(w, r)
+	if !ok {
+		return
+	}
+
+	err := rl.rluc.Unlink(r.Context(), req)
+	if err != nil {
+		rl.log.Error("failed to unlink songs", slog.String("error", err.Error()))
+
+		rl.renderError(w, r, err, "relation is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+func (rl *relations) decode(w http.ResponseWriter, r *http.Request) (*dto.SongRelationRequest, bool) {
+	var req dto.SongRelationRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		rl.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return nil, false
+	}
+
+	rl.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return nil, false
+	}
+
+	return &req, true
+}
+
+func (rl *relations) renderError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	if errors.Is(err, usecases.ErrForbidden) {
+		response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+		return
+	} else if errors.Is(err, usecases.ErrAlreadyExists) {
+		response.RenderError(w, r, response.CodeAlreadyExists, "song is already related to this or another original")
+
+		return
+	} else if errors.Is(err, usecases.ErrInvalidRelation) {
+		response.RenderError(w, r, response.CodeInvalidRelation, "song can't derive from itself or from its own version")
+
+		return
+	} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+		response.RenderError(w, r, response.CodeNotFound, notFound)
+
+		return
+	}
+
+	response.RenderError(w, r, response.CodeInternal, "internal error")
+}
@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type roles struct {
+	rluc *usecases.Roles
+	log  *slog.Logger
+}
+
+func newRoles(rluc *usecases.Roles, log *slog.Logger) *roles {
+	return &roles{
+		rluc: rluc,
+		log:  log,
+	}
+}
+
+// @Summary Roles
+// @Tags roles
+// @Description Create a custom role
+// @ID create-role
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param input body dto.CreateRoleRequest true "role info"
+// @Success 201 {object} dto.RoleResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 409 {object} response.Problem "already-exists"
+// @Failure 422 {object} response.Problem "validation-failed"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/roles [post]
+func (rl *roles) create(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.roles.create"
+
+	rl.log = rl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.CreateRoleRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		rl.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	rl.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	role, err := rl.rluc.Create(r.Context(), &req)
+	if err != nil {
+		rl.log.Error("failed to create role", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		} else if errors.Is(err, usecases.ErrAlreadyExists) {
+			response.RenderError(w, r, response.CodeAlreadyExists, "this role is already exists")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, role)
+}
+
+// @Summary Roles
+// @Tags roles
+// @Description Get a list of roles
+// @ID get-roles-list
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Success 200 {array} dto.RoleResponse
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/roles [get]
+func (rl *roles) getList(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.roles.getList"
+
+	rl.log = rl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	roles, err := rl.rluc.GetList(r.Context())
+	if err != nil {
+		rl.log.Error("failed to get list of roles", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, roles)
+}
+
+// @Summary Roles
+// @Tags roles
+// @Description Update a custom role, omitted fields are kept
+// @ID update-role
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param name path string true "role name"
+// @Param input body dto.UpdateRoleRequest true "the fields to update"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "built-in"
+// @Failure 422 {object} response.Problem "validation-failed, nothing-to-update"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/roles/{name} [put]
+func (rl *roles) update(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.roles.update"
+
+	rl.log = rl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.UpdateRoleRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		rl.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	rl.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = rl.rluc.Update(r.Context(), chi.URLParam(r, "name"), &req)
+	if err != nil {
+		rl.log.Error("failed to update role", slog.String("error", err.Error()))
+
+		rl.renderError(w, r, err, "role for update is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Roles
+// @Tags roles
+// @Description Delete a custom role, unassigning it from every subject
+// @ID delete-role
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param name path string true "role name"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "built-in"
+// @Failure 422 {object} response.Problem "nothing-to-update"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/roles/{name} [delete]
+func (rl *roles) delete(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.roles.delete"
+
+	rl.log = rl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	err := rl.rluc.Delete(r.Context(), chi.URLParam(r, "name"))
+	if err != nil {
+		rl.log.Error("failed to delete role", slog.String("error", err.Error()))
+
+		rl.renderError(w, r, err, "role for deletion is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Roles
+// @Tags roles
+// @Description Get the roles and the resulting permissions of the subject
+// @ID get-subject-roles
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param subject path string true "subject, e.g. the JWT subject or api-key:<id>"
+// @Success 200 {object} dto.SubjectRolesResponse
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/subjects/{subject}/roles [get]
+func (rl *roles) getSubjectRoles(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.roles.getSubjectRoles"
+
+	rl.log = rl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	roles, err := rl.rluc.GetSubjectRoles(r.Context(), chi.URLParam(r, "subject"))
+	if err != nil {
+		rl.log.Error("failed to get subject roles", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, roles)
+}
+
+// @Summary Roles
+// @Tags roles
+// @Description Assign the role to the subject
+// @ID assign-role
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param subject path string true "subject, e.g. the JWT subject or api-key:<id>"
+// @Param name path string true "role name"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "built-in"
+// @Failure 422 {object} response.Problem "nothing-to-update"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/subjects/{subject}/roles/{name} [put]
+func (rl *roles) assign(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.roles.assign"
+
+	rl.log = rl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	err := rl.rluc.Assign(r.Context(), chi.URLParam(r, "subject"), chi.URLParam(r, "name"))
+	if err != nil {
+		rl.log.Error("failed to assign role", slog.String("error", err.Error()))
+
+		rl.renderError(w, r, err, "role not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Roles
+// @Tags roles
+// @Description Unassign the role from the subject
+// @ID unassign-role
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param subject path string true "subject, e.g. the JWT subject or api-key:<id>"
+// @Param name path string true "role name"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "built-in"
+// @Failure 422 {object} response.Problem "nothing-to-update"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/subjects/{subject}/roles/{name} [delete]
+func (rl *roles) unassign(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.roles.unassign"
+
+	rl.log = rl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	err := rl.rluc.Unassign(r.Context(), chi.URLParam(r, "subject"), chi.URLParam(r, "name"))
+	if err != nil {
+		rl.log.Error("failed to unassign role", slog.String("error", err.Error()))
+
+		rl.renderError(w, r, err, "role assignment not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+func (rl *roles) renderError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	if errors.Is(err, usecases.ErrForbidden) {
+		response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+		return
+	} else if errors.Is(err, usecases.ErrBuiltin) {
+		response.RenderError(w, r, response.CodeBuiltin, "built-in roles can't be changed")
+
+		return
+	} else if errors.Is(err, usecases.ErrNullFields) {
+		response.RenderError(w, r, response.CodeNothingToUpdate, "nothing to update")
+
+		return
+	} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+		response.RenderError(w, r, response.CodeNotFound, notFound)
+
+		return
+	}
+
+	response.RenderError(w, r, response.CodeInternal, "internal error")
+}
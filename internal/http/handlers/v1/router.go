@@ -1,41 +1,336 @@
 package handlers
 
 import (
+	"effective-mobile-test/internal/config"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/http/middlewares/auth"
+	"effective-mobile-test/internal/http/middlewares/caching"
+	"effective-mobile-test/internal/http/middlewares/idempotency"
+	"effective-mobile-test/internal/http/middlewares/negotiation"
 	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/middlewares/tenant"
 	"effective-mobile-test/internal/usecases"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"log/slog"
+	"net/http"
 )
 
-func NewRouter(log *slog.Logger, r *chi.Mux, sluc *usecases.SongLibrary) {
+func NewRouter(
+	log *slog.Logger,
+	r *chi.Mux,
+	cfg *config.Config,
+	sluc *usecases.SongLibrary,
+	seuc *usecases.SongEvents,
+	whuc *usecases.Webhooks,
+	akuc *usecases.ApiKeys,
+	tkuc *usecases.Tokens,
+	rluc *usecases.Roles,
+	tnuc *usecases.Tenants,
+	usuc *usecases.Users,
+	fvuc *usecases.Favorites,
+	pluc *usecases.Playlists,
+	rtuc *usecases.Ratings,
+	pyuc *usecases.Plays,
+	tguc *usecases.Tags,
+	cruc *usecases.Credits,
+	rsuc *usecases.Relations,
+	dpuc *usecases.Duplicates,
+	stuc *usecases.Stats,
+	lyuc *usecases.Lyrics,
+	exuc *usecases.Explicit,
+	idem *idempotency.Idempotency,
+) {
+	var tokens auth.Authenticator
+	if tkuc != nil {
+		tokens = tkuc
+	}
+
+	// The responses carry the plaintext API key and the session token.
+	idem.Exclude(http.MethodPost, "/v1/keys")
+	idem.Exclude(http.MethodPost, "/v1/sessions")
+
 	r.Use(
 		middleware.RequestID,
 		middleware.Recoverer,
 		middleware.URLFormat,
+		auth.SetPrincipalContextMiddleware(akuc, usuc, tokens, log),
+		tenant.SetTenantContextMiddleware(tnuc, log),
+		idem.Middleware,
 	)
 
 	sl := newSongLibrary(sluc, log)
+	se := newSongEvents(seuc, cfg.EventsHeartbeat, log)
+	wh := newWebhooks(whuc, log)
+	ak := newApiKeys(akuc, log)
+	rl := newRoles(rluc, log)
+	tn := newTenants(tnuc, log)
+	us := newUsers(usuc, log)
+	fv := newFavorites(fvuc, log)
+	pl := newPlaylists(pluc, log)
+	rt := newRatings(rtuc, log)
+	py := newPlays(pyuc, log)
+	tg := newTags(tguc, log)
+	cr := newCredits(cruc, log)
+	rs := newRelations(rsuc, log)
+	dp := newDuplicates(dpuc, log)
+	st := newStats(stuc, log)
+	ly := newLyrics(lyuc, log)
+	ex := newExplicit(exuc, log)
+	pr := newProblems()
+
+	r.NotFound(pr.notFound)
+	r.MethodNotAllowed(pr.methodNotAllowed)
 
 	r.Route("/v1", func(r chi.Router) {
 		r.Route("/songs", func(r chi.Router) {
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireScope(entities.ScopeSongsRead))
+
+				r.
+					With(
+						negotiation.SetFormatContextMiddleware,
+						caching.ConditionalGet(cfg.CacheControl.List),
+						pagination.SetPaginationContextMiddleware,
+					).
+					Get("/", sl.getList)
+
+				r.
+					With(pagination.SetPaginationContextMiddleware).
+					Get("/sync", sl.sync)
+
+				r.Get("/events", se.stream)
+
+				r.
+					With(pagination.SetPaginationContextMiddleware).
+					Get("/trending", py.getTrending)
+
+				r.Get("/credits", cr.getSongCredits)
+
+				r.Get("/explicit", ex.getReport)
+
+				r.Get("/plays", py.getStats)
+				r.Post("/plays", py.ingest)
+
+				r.Route("/text", func(r chi.Router) {
+					r.
+						With(
+							negotiation.SetFormatContextMiddleware,
+							caching.ConditionalGet(cfg.CacheControl.Text),
+							pagination.SetPaginationContextMiddleware,
+						).
+						Get("/", sl.getText)
+
+					r.Get("/stats", ly.getSong)
+				})
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireScope(entities.ScopeSongsWrite))
+
+				r.Post("/", sl.create)
+				r.Put("/", sl.update)
+				r.Delete("/", sl.delete)
+
+				r.Post("/tags", tg.tagSong)
+				r.Delete("/tags", tg.untagSong)
+
+				r.Post("/credits", cr.addCredit)
+				r.Delete("/credits", cr.removeCredit)
+
+				r.Put("/explicit", ex.setOverride)
+
+				r.Post("/relations", rs.link)
+				r.Delete("/relations", rs.unlink)
+
+				r.
+					With(pagination.SetPaginationContextMiddleware).
+					Get("/duplicates", dp.getList)
+
+				r.Post("/merge", dp.merge)
+			})
+		})
+
+		r.Route("/tags", func(r chi.Router) {
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireScope(entities.ScopeSongsRead))
+
+				r.
+					With(pagination.SetPaginationContextMiddleware).
+					Get("/", tg.getList)
+
+				r.Get("/cloud", tg.getCloud)
+			})
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireScope(entities.ScopeSongsWrite))
+
+				r.Post("/", tg.create)
+				r.Put("/{id}", tg.update)
+				r.Delete("/{id}", tg.delete)
+			})
+		})
+
+		r.Route("/stats", func(r chi.Router) {
+			r.Use(auth.RequireScope(entities.ScopeSongsRead))
+
+			r.Get("/", st.get)
+			r.Get("/lyrics", ly.getGroup)
+		})
+
+		r.Route("/people", func(r chi.Router) {
+			r.
+				With(
+					auth.RequireScope(entities.ScopeSongsRead),
+					pagination.SetPaginationContextMiddleware,
+				).
+				Get("/", cr.getPeople)
+
+			r.Group(func(r chi.Router) {
+				r.Use(auth.RequireScope(entities.ScopeSongsWrite))
+
+				r.Post("/", cr.createPerson)
+				r.Put("/{id}", cr.updatePerson)
+				r.Delete("/{id}", cr.deletePerson)
+			})
+		})
+
+		r.Route("/favorites", func(r chi.Router) {
+			r.Use(auth.RequireScope(entities.ScopeSongsRead))
+
 			r.
 				With(pagination.SetPaginationContextMiddleware).
-				Get("/", sl.getList)
+				Get("/", fv.getList)
 
-			r.Post("/", sl.create)
-			r.Put("/", sl.update)
-			r.Delete("/", sl.delete)
+			r.Post("/", fv.add)
+			r.Delete("/", fv.remove)
+		})
+
+		r.Route("/ratings", func(r chi.Router) {
+			r.Use(auth.RequireScope(entities.ScopeSongsRead))
+
+			r.
+				With(pagination.SetPaginationContextMiddleware).
+				Get("/", rt.getList)
+
+			r.Post("/", rt.create)
+			r.Put("/", rt.update)
+			r.Delete("/", rt.delete)
+		})
+
+		r.Route("/reviews", func(r chi.Router) {
+			r.Use(auth.RequireScope(entities.ScopeSongsRead))
+
+			r.
+				With(pagination.SetPaginationContextMiddleware).
+				Get("/", rt.getReviews)
+
+			r.
+				With(pagination.SetPaginationContextMiddleware).
+				Get("/pending", rt.getPending)
+
+			r.Put("/{id}/status", rt.moderate)
+		})
+
+		r.Route("/playlists", func(r chi.Router) {
+			r.Use(auth.RequireScope(entities.ScopeSongsRead))
+
+			r.
+				With(pagination.SetPaginationContextMiddleware).
+				Get("/", pl.getList)
+
+			r.Post("/", pl.create)
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", pl.get)
+				r.Put("/", pl.update)
+				r.Delete("/", pl.delete)
+
+				r.Post("/entries", pl.addEntry)
+				r.Put("/entries/{entryId}", pl.moveEntry)
+				r.Delete("/entries/{entryId}", pl.removeEntry)
+			})
+		})
+
+		r.Post("/users", us.register)
+		r.Post("/sessions", us.login)
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequirePrincipal)
+
+			r.Get("/users/me", us.me)
+			r.Delete("/sessions", us.logout)
+		})
+
+		r.Route("/webhooks", func(r chi.Router) {
+			r.Use(auth.RequireScope(entities.ScopeWebhooksManage))
+
+			r.
+				With(pagination.SetPaginationContextMiddleware).
+				Get("/", wh.getList)
+
+			r.Post("/", wh.create)
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", wh.get)
+				r.Put("/", wh.update)
+				r.Delete("/", wh.delete)
 
-			r.Route("/text", func(r chi.Router) {
 				r.
 					With(pagination.SetPaginationContextMiddleware).
-					Get("/", sl.getText)
+					Get("/deliveries", wh.getDeliveries)
+
+				r.Post("/deliveries/{deliveryId}/redeliver", wh.redeliver)
 			})
 		})
+
+		r.Route("/keys", func(r chi.Router) {
+			r.Use(auth.RequireScope(entities.ScopeKeysManage))
+
+			r.
+				With(pagination.SetPaginationContextMiddleware).
+				Get("/", ak.getList)
+
+			r.Post("/", ak.create)
+			r.Delete("/{id}", ak.revoke)
+		})
+
+		r.Group(func(r chi.Router) {
+			r.Use(auth.RequirePrincipal)
+
+			r.Route("/roles", func(r chi.Router) {
+				r.Get("/", rl.getList)
+				r.Post("/", rl.create)
+				r.Put("/{name}", rl.update)
+				r.Delete("/{name}", rl.delete)
+			})
+
+			r.Route("/subjects/{subject}/roles", func(r chi.Router) {
+				r.Get("/", rl.getSubjectRoles)
+				r.Put("/{name}", rl.assign)
+				r.Delete("/{name}", rl.unassign)
+			})
+
+			r.Route("/tenants", func(r chi.Router) {
+				r.Get("/", tn.getList)
+				r.Post("/", tn.create)
+				r.Delete("/{id}", tn.delete)
+			})
+		})
+	})
+
+	r.Route("/problems", func(r chi.Router) {
+		r.Get("/", pr.getList)
+		r.Get("/{code}", pr.get)
 	})
 
 	r.Route("/info", func(r chi.Router) {
-		r.Get("/", sl.get)
+		r.
+			With(
+				auth.RequireScope(entities.ScopeSongsRead),
+				negotiation.SetFormatContextMiddleware,
+				caching.ConditionalGet(cfg.CacheControl.Info),
+			).
+			Get("/", sl.get)
 	})
 }
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/usecases"
+	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gorilla/schema"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type songEvents struct {
+	seuc      *usecases.SongEvents
+	heartbeat time.Duration
+	log       *slog.Logger
+}
+
+func newSongEvents(seuc *usecases.SongEvents, heartbeat time.Duration, log *slog.Logger) *songEvents {
+	return &songEvents{
+		seuc:      seuc,
+		heartbeat: heartbeat,
+		log:       log,
+	}
+}
+
+// @Summary Song Library
+// @Tags song-library
+// @Description Stream the created, updated and deleted songs as server-sent events in the commit order. The event ids are the positions to resume from, the events around them may come again
+// @ID stream-song-events
+// @Produce text/event-stream
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param group query string false "only the events of this group"
+// @Param lastEventId query int false "resume from this event id"
+// @Param Last-Event-ID header int false "resume from this event id"
+// @Success 200 {object} dto.SongEventResponse
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 422 {object} response.Problem "unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/events [get]
+func (se *songEvents) stream(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.songEvents.stream"
+
+	se.log = se.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.SongEventsRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		se.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return
+	}
+
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		lastEventID, err := strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			response.RenderError(w, r, response.CodeInvalidParameter, "invalid Last-Event-ID header")
+
+			return
+		}
+		req.LastEventID = &lastEventID
+	}
+
+	se.log.Info("request query decoded", slog.Any("request", req))
+
+	var lastEventID int64
+	if req.LastEventID != nil {
+		lastEventID = *req.LastEventID
+	}
+
+	events, err := se.seuc.Subscribe(r.Context(), req.Group, lastEventID)
+	if err != nil {
+		se.log.Error("failed to subscribe to song events", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if err = rc.Flush(); err != nil {
+		se.log.Error("streaming is not supported", slog.String("error", err.Error()))
+
+		return
+	}
+
+	heartbeat := time.NewTicker(se.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(w, ": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+
+			var data []byte
+			data, err = json.Marshal(event)
+			if err != nil {
+				se.log.Error("failed to encode event", slog.String("error", err.Error()))
+
+				return
+			}
+
+			_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Bound, event.Type, data)
+		}
+
+		if err == nil {
+			err = rc.Flush()
+		}
+
+		if err != nil {
+			se.log.Info("song events stream closed", slog.String("error", err.Error()))
+
+			return
+		}
+	}
+}
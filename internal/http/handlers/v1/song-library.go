@@ -2,13 +2,14 @@ package handlers
 
 import (
 	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/caching"
 	"effective-mobile-test/internal/http/middlewares/pagination"
 	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
 	"effective-mobile-test/internal/usecases"
 	"errors"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
-	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/schema"
 	"log/slog"
 	"net/http"
@@ -32,11 +33,20 @@ func newSongLibrary(sluc *usecases.SongLibrary, log *slog.Logger) *songLibrary {
 // @ID create-song
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
 // @Param input body dto.CreateSongRequest true "song info"
 // @Success 201 {object} response.Response
-// @Failure 400 {object} response.Response
-// @Failure 500 {object} response.Response
-// @Failure default {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 409 {object} response.Problem "already-exists, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
 // @Router /v1/songs [post]
 func (sl *songLibrary) create(w http.ResponseWriter, r *http.Request) {
 	const fn = "http.handlers.songLibrary.create"
@@ -52,29 +62,33 @@ func (sl *songLibrary) create(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		sl.log.Error("failed to decode request body", slog.String("error", err.Error()))
 
-		response.RenderError(w, r, http.StatusBadRequest, "")
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
 
 		return
 	}
 
 	sl.log.Info("request body decoded", slog.Any("request", req))
 
-	if err = validator.New().Struct(req); err != nil {
-		response.RenderError(w, r, http.StatusBadRequest, err.Error())
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
 
 		return
 	}
 
-	if err = sl.sluc.Create(req.Group, req.Song); err != nil {
+	if err = sl.sluc.Create(r.Context(), req.Group, req.Song); err != nil {
 		sl.log.Error("failed to create song", slog.String("error", err.Error()))
 
-		if errors.Is(err, usecases.ErrAlreadyExists) {
-			response.RenderError(w, r, http.StatusBadRequest, "this song is already exists")
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		} else if errors.Is(err, usecases.ErrAlreadyExists) {
+			response.RenderError(w, r, response.CodeAlreadyExists, "this song is already exists")
 
 			return
 		}
 
-		response.RenderError(w, r, http.StatusInternalServerError, "internal error")
+		response.RenderError(w, r, response.CodeInternal, "internal error")
 
 		return
 	}
@@ -87,14 +101,28 @@ func (sl *songLibrary) create(w http.ResponseWriter, r *http.Request) {
 // @Description Get the lyrics of the song
 // @ID get-song-lyrics
 // @Accept json
-// @Produce json
+// @Produce json,application/xml,text/csv,application/yaml
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
 // @Param offset query int false "paginate through the song lyrics paragraphs"
 // @Param group query string true "group name"
 // @Param song query string true "song name"
+// @Param clean query bool false "mask the explicit words, the songs marked explicit by hand are refused"
+// @Param If-None-Match header string false "entity tag of the cached response"
+// @Param If-Modified-Since header string false "date of the cached response"
 // @Success 200 {object} dto.GetTextResponse
-// @Failure 400 {object} response.Response
-// @Failure 500 {object} response.Response
-// @Failure default {object} response.Response
+// @Header 200 {string} ETag "strong entity tag of the response"
+// @Header 200 {string} Cache-Control "caching policy of the route"
+// @Success 304 "not modified"
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found, missing-lyrics"
+// @Failure 406 {object} response.Problem "not-acceptable"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, explicit"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
 // @Router /v1/songs/text [get]
 func (sl *songLibrary) getText(w http.ResponseWriter, r *http.Request) {
 	const fn = "http.handlers.songLibrary.getText"
@@ -112,54 +140,76 @@ func (sl *songLibrary) getText(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		sl.log.Error("failed to decode request query", slog.String("error", err.Error()))
 
-		response.RenderError(w, r, http.StatusBadRequest, "")
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
 
 		return
 	}
 
 	sl.log.Info("request query decoded", slog.Any("request", req))
 
-	if err = validator.New().Struct(req); err != nil {
-		response.RenderError(w, r, http.StatusBadRequest, err.Error())
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
 
 		return
 	}
 
-	textRes, err := sl.sluc.GetText(req.Group, req.Song, pagination.Get(r.Context()))
+	textRes, err := sl.sluc.GetText(r.Context(), req.Group, req.Song, req.Clean, pagination.Get(r.Context()))
 	if err != nil {
 		sl.log.Error("failed to get text of the song", slog.String("error", err.Error()))
 
-		if errors.Is(err, usecases.ErrNullFields) {
-			response.RenderError(w, r, http.StatusBadRequest, "this song doesn't have text yet")
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		} else if errors.Is(err, usecases.ErrNullFields) {
+			response.RenderError(w, r, response.CodeMissingLyrics, "this song doesn't have text yet")
+
+			return
+		} else if errors.Is(err, usecases.ErrExplicit) {
+			response.RenderError(w, r, response.CodeExplicit, "the song is marked explicit")
 
 			return
 		} else if errors.Is(err, usecases.ErrNoRowsAffected) {
-			response.RenderError(w, r, http.StatusBadRequest, "song not found")
+			response.RenderError(w, r, response.CodeNotFound, "song not found")
 
 			return
 		}
 
-		response.RenderError(w, r, http.StatusInternalServerError, "internal error")
+		response.RenderError(w, r, response.CodeInternal, "internal error")
 
 		return
 	}
 
-	render.Status(r, http.StatusOK)
-	render.JSON(w, r, textRes)
+	caching.SetLastModified(w, textRes.UpdatedAt)
+
+	response.Render(w, r, http.StatusOK, textRes)
 }
 
 // @Summary Song Library
 // @Tags song-library
-// @Description Get the song info
+// @Description Get the song info along with the originals it derives from and its own versions
 // @ID get-song-info
 // @Accept json
-// @Produce json
+// @Produce json,application/xml,text/csv,application/yaml
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
 // @Param group query string true "group name"
 // @Param song query string true "song name"
+// @Param If-None-Match header string false "entity tag of the cached response"
+// @Param If-Modified-Since header string false "date of the cached response"
 // @Success 200 {object} dto.GetSongResponse
-// @Failure 400 {object} response.Response
-// @Failure 500 {object} response.Response
-// @Failure default {object} response.Response
+// @Header 200 {string} ETag "strong entity tag of the response"
+// @Header 200 {string} Cache-Control "caching policy of the route"
+// @Success 304 "not modified"
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 406 {object} response.Problem "not-acceptable"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
 // @Router /info [get]
 func (sl *songLibrary) get(w http.ResponseWriter, r *http.Request) {
 	const fn = "http.handlers.songLibrary.get"
@@ -177,36 +227,44 @@ func (sl *songLibrary) get(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		sl.log.Error("failed to decode request query", slog.String("error", err.Error()))
 
-		response.RenderError(w, r, http.StatusBadRequest, "")
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
 
 		return
 	}
 
 	sl.log.Info("request query decoded", slog.Any("request", req))
 
-	if err = validator.New().Struct(req); err != nil {
-		response.RenderError(w, r, http.StatusBadRequest, err.Error())
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
 
 		return
 	}
 
-	textRes, err := sl.sluc.Get(req.Group, req.Song)
+	textRes, err := sl.sluc.Get(r.Context(), req.Group, req.Song)
 	if err != nil {
 		sl.log.Error("failed to get song", slog.String("error", err.Error()))
 
-		if errors.Is(err, usecases.ErrNoRowsAffected) {
-			response.RenderError(w, r, http.StatusBadRequest, "song not found")
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "song not found")
 
 			return
 		}
 
-		response.RenderError(w, r, http.StatusInternalServerError, "internal error")
+		response.RenderError(w, r, response.CodeInternal, "internal error")
 
 		return
 	}
 
-	render.Status(r, http.StatusOK)
-	render.JSON(w, r, textRes)
+	caching.SetLastModified(w, textRes.UpdatedAt)
+	if textRes.RatedAt != nil {
+		caching.SetLastModified(w, *textRes.RatedAt)
+	}
+
+	response.Render(w, r, http.StatusOK, textRes)
 }
 
 // @Summary Song Library
@@ -214,7 +272,10 @@ func (sl *songLibrary) get(w http.ResponseWriter, r *http.Request) {
 // @Description Get a list of songs
 // @ID get-songs-list
 // @Accept json
-// @Produce json
+// @Produce json,application/xml,text/csv,application/yaml
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
 // @Param offset query int false "paginate through the songs list"
 // @Param limit query int false "sets the list limit"
 // @Param group query string false "group name"
@@ -222,10 +283,30 @@ func (sl *songLibrary) get(w http.ResponseWriter, r *http.Request) {
 // @Param releaseDate query string false "release date" format(date)
 // @Param link query string false "link"
 // @Param text query string false "lyrics"
+// @Param updatedSince query string false "only songs updated at or after this moment" format(date-time)
+// @Param sort query string false "order by the average rating or the number of ratings, descending with -" Enums(rating, -rating, ratingCount, -ratingCount)
+// @Param tags query []string false "tag names, each may list alternatives separated by commas, genres include their subgenres" collectionFormat(multi)
+// @Param tagMatch query string false "whether songs need all the tags or any of them, any by default" Enums(any, all)
+// @Param artist query string false "only the songs of the primary or featured artist"
+// @Param writer query string false "only the songs written by the person"
+// @Param composer query string false "only the songs composed by the person"
+// @Param producer query string false "only the songs produced by the person"
+// @Param language query string false "only the songs whose lyrics are detected to be in the language, an ISO 639-1 code, alternatives separated by commas"
+// @Param clean query bool false "leave the explicit songs out"
+// @Param If-None-Match header string false "entity tag of the cached response"
+// @Param If-Modified-Since header string false "date of the cached response"
 // @Success 200 {array} dto.GetSongsListResponse
-// @Failure 400 {object} response.Response
-// @Failure 500 {object} response.Response
-// @Failure default {object} response.Response
+// @Header 200 {string} ETag "strong entity tag of the response"
+// @Header 200 {string} Cache-Control "caching policy of the route"
+// @Success 304 "not modified"
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 406 {object} response.Problem "not-acceptable"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
 // @Router /v1/songs [get]
 func (sl *songLibrary) getList(w http.ResponseWriter, r *http.Request) {
 	const fn = "http.handlers.songLibrary.getList"
@@ -243,36 +324,111 @@ func (sl *songLibrary) getList(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		sl.log.Error("failed to decode request query", slog.String("error", err.Error()))
 
-		response.RenderError(w, r, http.StatusBadRequest, "")
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
 
 		return
 	}
 
 	sl.log.Info("request query decoded", slog.Any("request", req))
 
-	if err = validator.New().Struct(req); err != nil {
-		response.RenderError(w, r, http.StatusBadRequest, err.Error())
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
 
 		return
 	}
 
-	songs, err := sl.sluc.GetList(&req, pagination.Get(r.Context()))
+	songs, err := sl.sluc.GetList(r.Context(), &req, pagination.Get(r.Context()))
 	if err != nil {
 		sl.log.Error("failed to get list of songs", slog.String("error", err.Error()))
 
-		if errors.Is(err, usecases.ErrNoRowsAffected) {
-			response.RenderError(w, r, http.StatusBadRequest, "songs not found")
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "songs not found")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	for _, song := range songs {
+		caching.SetLastModified(w, song.UpdatedAt)
+		if song.RatedAt != nil {
+			caching.SetLastModified(w, *song.RatedAt)
+		}
+	}
+
+	response.Render(w, r, http.StatusOK, dto.SongsListResponse(songs))
+}
+
+// @Summary Song Library
+// @Tags song-library
+// @Description Get the changes of the library since the sync token, the ones of the transactions still running are held back until they finish
+// @ID sync-songs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param token query string false "sync token from the previous response, empty for a full sync"
+// @Param limit query int false "sets the maximum number of changes"
+// @Success 200 {object} dto.SyncResponse
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 422 {object} response.Problem "unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/sync [get]
+func (sl *songLibrary) sync(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.songLibrary.sync"
+
+	sl.log = sl.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.SyncRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		sl.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return
+	}
+
+	sl.log.Info("request query decoded", slog.Any("request", req))
+
+	syncRes, err := sl.sluc.Sync(r.Context(), req.Token, pagination.Get(r.Context()))
+	if err != nil {
+		sl.log.Error("failed to sync songs", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		} else if errors.Is(err, usecases.ErrInvalidToken) {
+			response.RenderError(w, r, response.CodeInvalidParameter, "invalid sync token")
 
 			return
 		}
 
-		response.RenderError(w, r, http.StatusInternalServerError, "internal error")
+		response.RenderError(w, r, response.CodeInternal, "internal error")
 
 		return
 	}
 
 	render.Status(r, http.StatusOK)
-	render.JSON(w, r, songs)
+	render.JSON(w, r, syncRes)
 }
 
 // @Summary Song Library
@@ -281,11 +437,21 @@ func (sl *songLibrary) getList(w http.ResponseWriter, r *http.Request) {
 // @ID update-song
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
 // @Param input body dto.UpdateSongRequest true "song info and the fields to update"
 // @Success 200 {object} response.Response
-// @Failure 400 {object} response.Response
-// @Failure 500 {object} response.Response
-// @Failure default {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
 // @Router /v1/songs [put]
 func (sl *songLibrary) update(w http.ResponseWriter, r *http.Request) {
 	const fn = "http.handlers.songLibrary.update"
@@ -301,30 +467,34 @@ func (sl *songLibrary) update(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		sl.log.Error("failed to decode request body", slog.String("error", err.Error()))
 
-		response.RenderError(w, r, http.StatusBadRequest, "")
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
 
 		return
 	}
 
 	sl.log.Info("request body decoded", slog.Any("request", req))
 
-	if err = validator.New().Struct(req); err != nil {
-		response.RenderError(w, r, http.StatusBadRequest, err.Error())
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
 
 		return
 	}
 
-	err = sl.sluc.Update(&req)
+	err = sl.sluc.Update(r.Context(), &req)
 	if err != nil {
 		sl.log.Error("failed to update song", slog.String("error", err.Error()))
 
-		if errors.Is(err, usecases.ErrNoRowsAffected) {
-			response.RenderError(w, r, http.StatusBadRequest, "song for update is not found")
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "song for update is not found")
 
 			return
 		}
 
-		response.RenderError(w, r, http.StatusInternalServerError, "internal error")
+		response.RenderError(w, r, response.CodeInternal, "internal error")
 
 		return
 	}
@@ -338,11 +508,21 @@ func (sl *songLibrary) update(w http.ResponseWriter, r *http.Request) {
 // @ID delete-song
 // @Accept json
 // @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
 // @Param input body dto.DeleteSongRequest true "song info"
 // @Success 200 {object} response.Response
-// @Failure 400 {object} response.Response
-// @Failure 500 {object} response.Response
-// @Failure default {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
 // @Router /v1/songs [delete]
 func (sl *songLibrary) delete(w http.ResponseWriter, r *http.Request) {
 	const fn = "http.handlers.songLibrary.delete"
@@ -358,30 +538,34 @@ func (sl *songLibrary) delete(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		sl.log.Error("failed to decode request body", slog.String("error", err.Error()))
 
-		response.RenderError(w, r, http.StatusBadRequest, "")
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
 
 		return
 	}
 
 	sl.log.Info("request body decoded", slog.Any("request", req))
 
-	if err = validator.New().Struct(req); err != nil {
-		response.RenderError(w, r, http.StatusBadRequest, err.Error())
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
 
 		return
 	}
 
-	err = sl.sluc.Delete(req.Group, req.Song)
+	err = sl.sluc.Delete(r.Context(), req.Group, req.Song)
 	if err != nil {
 		sl.log.Error("failed to delete song", slog.String("error", err.Error()))
 
-		if errors.Is(err, usecases.ErrNoRowsAffected) {
-			response.RenderError(w, r, http.StatusBadRequest, "song for deletion is not found")
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "song for deletion is not found")
 
 			return
 		}
 
-		response.RenderError(w, r, http.StatusInternalServerError, "internal error")
+		response.RenderError(w, r, response.CodeInternal, "internal error")
 
 		return
 	}
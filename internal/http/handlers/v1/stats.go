@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+	"log/slog"
+	"net/http"
+)
+
+type stats struct {
+	stuc *usecases.Stats
+	log  *slog.Logger
+}
+
+func newStats(stuc *usecases.Stats, log *slog.Logger) *stats {
+	return &stats{
+		stuc: stuc,
+		log:  log,
+	}
+}
+
+// @Summary Stats
+// @Tags stats
+// @Description Get the library statistics of the songs matching the filters of the songs list: songs per group and per release year, how many lack the lyrics, the link or the release date and the average lyrics length. The statistics are cached for STATS_CACHE_TTL, see generatedAt
+// @ID get-stats
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param group query string false "group name"
+// @Param song query string false " song name"
+// @Param releaseDate query string false "release date" format(date)
+// @Param link query string false "link"
+// @Param text query string false "lyrics"
+// @Param updatedSince query string false "only songs updated at or after this moment" format(date-time)
+// @Param tags query []string false "tag names, each may list alternatives separated by commas, genres include their subgenres" collectionFormat(multi)
+// @Param tagMatch query string false "whether songs need all the tags or any of them, any by default" Enums(any, all)
+// @Param artist query string false "only the songs of the primary or featured artist"
+// @Param writer query string false "only the songs written by the person"
+// @Param composer query string false "only the songs composed by the person"
+// @Param producer query string false "only the songs produced by the person"
+// @Param language query string false "only the songs whose lyrics are detected to be in the language, an ISO 639-1 code, alternatives separated by commas"
+// @Param clean query bool false "leave the explicit songs out"
+// @Success 200 {object} dto.StatsResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/stats [get]
+func (st *stats) get(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.stats.get"
+
+	st.log = st.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.GetSongsListRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		st.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return
+	}
+
+	st.log.Info("request query decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := st.stuc.Get(r.Context(), &req)
+	if err != nil {
+		st.log.Error("failed to get stats", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "tags not found")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/gorilla/schema"
+	"log/slog"
+	"net/http"
+)
+
+type tags struct {
+	tguc *usecases.Tags
+	log  *slog.Logger
+}
+
+func newTags(tguc *usecases.Tags, log *slog.Logger) *tags {
+	return &tags{
+		tguc: tguc,
+		log:  log,
+	}
+}
+
+// @Summary Tags
+// @Tags tags
+// @Description Get the tags of the tenant by name
+// @ID get-tags-list
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param kind query string false "only the tags of the kind" Enums(genre, mood, tag)
+// @Param offset query int false "paginate through the tags list"
+// @Param limit query int false "sets the list limit"
+// @Success 200 {array} dto.TagResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, invalid-parent"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/tags [get]
+func (tg *tags) getList(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.tags.getList"
+
+	tg.log = tg.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	req, ok := tg.decodeKind(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := tg.tguc.GetList(r.Context(), req.Kind, pagination.Get(r.Context()))
+	if err != nil {
+		tg.log.Error("failed to get list of tags", slog.String("error", err.Error()))
+
+		tg.renderError(w, r, err, "")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Tags
+// @Tags tags
+// @Description Get the tag cloud: the tags of the tenant with the number of the songs tagged with each, the most used first
+// @ID get-tag-cloud
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param kind query string false "only the tags of the kind" Enums(genre, mood, tag)
+// @Success 200 {array} dto.TagCountResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, invalid-parent"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/tags/cloud [get]
+func (tg *tags) getCloud(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.tags.getCloud"
+
+	tg.log = tg.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	req, ok := tg.decodeKind(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := tg.tguc.GetCloud(r.Context(), req.Kind)
+	if err != nil {
+		tg.log.Error("failed to get tag cloud", slog.String("error", err.Error()))
+
+		tg.renderError(w, r, err, "")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, res)
+}
+
+// @Summary Tags
+// @Tags tags
+// @Description Create a tag, only genres may have a parent genre
+// @ID create-tag
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.CreateTagRequest true "tag info, a plain tag by default"
+// @Success 201 {object} dto.TagResponse
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, invalid-parent, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/tags [post]
+func (tg *tags) create(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.tags.create"
+
+	tg.log = tg.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.CreateTagRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		tg.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	tg.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	res, err := tg.tguc.Create(r.Context(), &req)
+	if err != nil {
+		tg.log.Error("failed to create tag", slog.String("error", err.Error()))
+
+		tg.renderError(w, r, err, "")
+
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, res)
+}
+
+// @Summary Tags
+// @Tags tags
+// @Description Rename the tag or move it under another genre, a zero parentId makes it a top-level one
+// @ID update-tag
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "tag id"
+// @Param input body dto.UpdateTagRequest true "the fields to update"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, nothing-to-update, unknown-tenant, invalid-parent, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/tags/{id} [put]
+func (tg *tags) update(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.tags.update"
+
+	tg.log = tg.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	var req dto.UpdateTagRequest
+
+	err = render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		tg.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	tg.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = tg.tguc.Update(r.Context(), id, &req)
+	if err != nil {
+		tg.log.Error("failed to update tag", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrNullFields) {
+			response.RenderError(w, r, response.CodeNothingToUpdate, "nothing to update")
+
+			return
+		}
+
+		tg.renderError(w, r, err, "tag for update is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Tags
+// @Tags tags
+// @Description Delete the tag and untag the songs, genres with subgenres can't be deleted
+// @ID delete-tag
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "tag id"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "unknown-tenant, invalid-parent, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/tags/{id} [delete]
+func (tg *tags) delete(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.tags.delete"
+
+	tg.log = tg.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	err = tg.tguc.Delete(r.Context(), id)
+	if err != nil {
+		tg.log.Error("failed to delete tag", slog.String("error", err.Error()))
+
+		tg.renderError(w, r, err, "tag for deletion is not found")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Tags
+// @Tags tags
+// @Description Tag the song, the tags it already has are kept
+// @ID tag-song
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.SongTagsRequest true "song info and the tag names"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, invalid-parent, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/tags [post]
+func (tg *tags) tagSong(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.tags.tagSong"
+
+	tg.log = tg.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	req, ok := tg.decodeSongTags(w, r)
+	if !ok {
+		return
+	}
+
+	err := tg.tguc.TagSong(r.Context(), req)
+	if err != nil {
+		tg.log.Error("failed to tag song", slog.String("error", err.Error()))
+
+		tg.renderError(w, r, err, "")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Tags
+// @Tags tags
+// @Description Remove the tags from the song
+// @ID untag-song
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param input body dto.SongTagsRequest true "song info and the tag names"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "already-exists, in-use, request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, invalid-parent, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/songs/tags [delete]
+func (tg *tags) untagSong(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.tags.untagSong"
+
+	tg.log = tg.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	req, ok := tg.decodeSongTags(w, r)
+	if !ok {
+		return
+	}
+
+	err := tg.tguc.UntagSong(r.Context(), req)
+	if err != nil {
+		tg.log.Error("failed to untag song", slog.String("error", err.Error()))
+
+		tg.renderError(w, r, err, "the song doesn't have these tags")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+func (tg *tags) decodeKind(w http.ResponseWriter, r *http.Request) (*dto.GetTagsRequest, bool) {
+	var req dto.GetTagsRequest
+
+	decoder := schema.NewDecoder()
+	decoder.IgnoreUnknownKeys(true)
+	err := decoder.Decode(&req, r.URL.Query())
+	if err != nil {
+		tg.log.Error("failed to decode request query", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request query is malformed: "+err.Error())
+
+		return nil, false
+	}
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return nil, false
+	}
+
+	return &req, true
+}
+
+func (tg *tags) decodeSongTags(w http.ResponseWriter, r *http.Request) (*dto.SongTagsRequest, bool) {
+	var req dto.SongTagsRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		tg.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return nil, false
+	}
+
+	tg.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// renderError reports ErrNoRowsAffected with notFound, or with the error
+// itself when notFound is empty, as it names the unknown song or tags.
+func (tg *tags) renderError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	if errors.Is(err, usecases.ErrForbidden) {
+		response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+		return
+	} else if errors.Is(err, usecases.ErrAlreadyExists) {
+		response.RenderError(w, r, response.CodeAlreadyExists, "tag already exists")
+
+		return
+	} else if errors.Is(err, usecases.ErrInvalidParent) {
+		response.RenderError(w, r, response.CodeInvalidParent, "only genres have a parent, which must be another genre and not a subgenre")
+
+		return
+	} else if errors.Is(err, usecases.ErrInUse) {
+		response.RenderError(w, r, response.CodeInUse, "genre still has subgenres")
+
+		return
+	} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+		if notFound == "" {
+			notFound = "song or tags not found"
+		}
+		response.RenderError(w, r, response.CodeNotFound, notFound)
+
+		return
+	}
+
+	response.RenderError(w, r, response.CodeInternal, "internal error")
+}
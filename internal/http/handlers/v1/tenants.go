@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type tenants struct {
+	tnuc *usecases.Tenants
+	log  *slog.Logger
+}
+
+func newTenants(tnuc *usecases.Tenants, log *slog.Logger) *tenants {
+	return &tenants{
+		tnuc: tnuc,
+		log:  log,
+	}
+}
+
+// @Summary Tenants
+// @Tags tenants
+// @Description Provision a tenant with its own song library
+// @ID create-tenant
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param input body dto.CreateTenantRequest true "tenant info"
+// @Success 201 {object} dto.TenantResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 409 {object} response.Problem "already-exists"
+// @Failure 422 {object} response.Problem "validation-failed"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/tenants [post]
+func (tn *tenants) create(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.tenants.create"
+
+	tn.log = tn.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.CreateTenantRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		tn.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	tn.log.Info("request body decoded", slog.Any("request", req))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	tenant, err := tn.tnuc.Create(r.Context(), &req)
+	if err != nil {
+		tn.log.Error("failed to create tenant", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		} else if errors.Is(err, usecases.ErrAlreadyExists) {
+			response.RenderError(w, r, response.CodeAlreadyExists, "this tenant is already exists")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, tenant)
+}
+
+// @Summary Tenants
+// @Tags tenants
+// @Description Get a list of tenants
+// @ID get-tenants-list
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Success 200 {array} dto.TenantResponse
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/tenants [get]
+func (tn *tenants) getList(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.tenants.getList"
+
+	tn.log = tn.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	tenants, err := tn.tnuc.GetList(r.Context())
+	if err != nil {
+		tn.log.Error("failed to get list of tenants", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, tenants)
+}
+
+// @Summary Tenants
+// @Tags tenants
+// @Description Delete a tenant, it has to be emptied first
+// @ID delete-tenant
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param id path string true "tenant id"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "in-use, built-in"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/tenants/{id} [delete]
+func (tn *tenants) delete(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.tenants.delete"
+
+	tn.log = tn.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	err := tn.tnuc.Delete(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		tn.log.Error("failed to delete tenant", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "forbidden")
+
+			return
+		} else if errors.Is(err, usecases.ErrBuiltin) {
+			response.RenderError(w, r, response.CodeBuiltin, "the default tenant can't be deleted")
+
+			return
+		} else if errors.Is(err, usecases.ErrInUse) {
+			response.RenderError(w, r, response.CodeInUse, "the tenant still has songs, webhooks or api keys")
+
+			return
+		} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "tenant for deletion is not found")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
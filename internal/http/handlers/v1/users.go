@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+)
+
+type users struct {
+	usuc *usecases.Users
+	log  *slog.Logger
+}
+
+func newUsers(usuc *usecases.Users, log *slog.Logger) *users {
+	return &users{
+		usuc: usuc,
+		log:  log,
+	}
+}
+
+// @Summary Users
+// @Tags users
+// @Description Register a user in the tenant
+// @ID register-user
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param input body dto.RegisterUserRequest true "user info"
+// @Success 201 {object} dto.UserResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 409 {object} response.Problem "already-exists"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/users [post]
+func (us *users) register(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.users.register"
+
+	us.log = us.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.RegisterUserRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		us.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	us.log.Info("request body decoded", slog.String("email", req.Email))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	user, err := us.usuc.Register(r.Context(), &req)
+	if err != nil {
+		us.log.Error("failed to register user", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrAlreadyExists) {
+			response.RenderError(w, r, response.CodeAlreadyExists, "this email is already registered")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, user)
+}
+
+// @Summary Users
+// @Tags users
+// @Description Get the signed in user
+// @ID get-current-user
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.UserResponse
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/users/me [get]
+func (us *users) me(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.users.me"
+
+	us.log = us.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	user, err := us.usuc.Me(r.Context())
+	if err != nil {
+		us.log.Error("failed to get current user", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "only available to users")
+
+			return
+		} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "user is not found")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, user)
+}
+
+// @Summary Sessions
+// @Tags users
+// @Description Sign in, the token goes to the Authorization: Bearer header
+// @ID create-session
+// @Accept json
+// @Produce json
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param input body dto.CreateSessionRequest true "credentials"
+// @Success 201 {object} dto.SessionResponse
+// @Failure 400 {object} response.Problem "malformed-request"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/sessions [post]
+func (us *users) login(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.users.login"
+
+	us.log = us.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	var req dto.CreateSessionRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		us.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	us.log.Info("request body decoded", slog.String("email", req.Email))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	session, err := us.usuc.Login(r.Context(), &req)
+	if err != nil {
+		us.log.Info("failed to sign in", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrUnauthorized) {
+			response.RenderError(w, r, response.CodeUnauthorized, "invalid email or password")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, session)
+}
+
+// @Summary Sessions
+// @Tags users
+// @Description Sign out, ending the session of the request
+// @ID delete-session
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/sessions [delete]
+func (us *users) logout(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.users.logout"
+
+	us.log = us.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	err := us.usuc.Logout(r.Context())
+	if err != nil {
+		us.log.Error("failed to sign out", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrForbidden) {
+			response.RenderError(w, r, response.CodeForbidden, "only available to users")
+
+			return
+		} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "session is not found")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
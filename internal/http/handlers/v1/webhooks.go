@@ -0,0 +1,465 @@
+package handlers
+
+import (
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/idempotency"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/response"
+	"effective-mobile-test/internal/http/validation"
+	"effective-mobile-test/internal/usecases"
+	"errors"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+type webhooks struct {
+	whuc *usecases.Webhooks
+	log  *slog.Logger
+}
+
+func newWebhooks(whuc *usecases.Webhooks, log *slog.Logger) *webhooks {
+	return &webhooks{
+		whuc: whuc,
+		log:  log,
+	}
+}
+
+func urlParamID(r *http.Request, key string) (int, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, key))
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid " + key)
+	}
+	return id, nil
+}
+
+// @Summary Webhooks
+// @Tags webhooks
+// @Description Subscribe a URL to the song events
+// @ID create-webhook
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "holds the retries with the same key while the request is in progress, the response carrying the secret isn't replayed"
+// @Param input body dto.CreateWebhookRequest true "webhook info, the url must be https and the secret is generated when empty"
+// @Success 201 {object} dto.CreateWebhookResponse
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/webhooks [post]
+func (wh *webhooks) create(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.webhooks.create"
+
+	wh.log = wh.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	// The response carries the secret.
+	idempotency.NoStore(r)
+
+	var req dto.CreateWebhookRequest
+
+	err := render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		wh.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	wh.log.Info("request body decoded", slog.String("url", req.URL), slog.Any("eventTypes", req.EventTypes))
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	webhook, err := wh.whuc.Create(r.Context(), &req)
+	if err != nil {
+		wh.log.Error("failed to create webhook", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrInsecureURL) {
+			response.RenderError(w, r, response.CodeValidationFailed, "webhook url must be https")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusCreated)
+	render.JSON(w, r, webhook)
+}
+
+// @Summary Webhooks
+// @Tags webhooks
+// @Description Get a list of webhooks
+// @ID get-webhooks-list
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param offset query int false "paginate through the webhooks list"
+// @Param limit query int false "sets the list limit"
+// @Success 200 {array} dto.WebhookResponse
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/webhooks [get]
+func (wh *webhooks) getList(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.webhooks.getList"
+
+	wh.log = wh.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	webhooks, err := wh.whuc.GetList(r.Context(), pagination.Get(r.Context()))
+	if err != nil {
+		wh.log.Error("failed to get list of webhooks", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "webhooks not found")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, webhooks)
+}
+
+// @Summary Webhooks
+// @Tags webhooks
+// @Description Get the webhook info
+// @ID get-webhook
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param id path int true "webhook id"
+// @Success 200 {object} dto.WebhookResponse
+// @Failure 400 {object} response.Problem "invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/webhooks/{id} [get]
+func (wh *webhooks) get(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.webhooks.get"
+
+	wh.log = wh.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	webhook, err := wh.whuc.Get(r.Context(), id)
+	if err != nil {
+		wh.log.Error("failed to get webhook", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "webhook not found")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, webhook)
+}
+
+// @Summary Webhooks
+// @Tags webhooks
+// @Description Update a specific webhook, omitted fields are kept
+// @ID update-webhook
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "webhook id"
+// @Param input body dto.UpdateWebhookRequest true "the fields to update"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "validation-failed, nothing-to-update, unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/webhooks/{id} [put]
+func (wh *webhooks) update(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.webhooks.update"
+
+	wh.log = wh.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	var req dto.UpdateWebhookRequest
+
+	err = render.DecodeJSON(r.Body, &req)
+	if err != nil {
+		wh.log.Error("failed to decode request body", slog.String("error", err.Error()))
+
+		response.RenderError(w, r, response.CodeMalformedRequest, "request body isn't valid JSON")
+
+		return
+	}
+
+	if err = validation.Struct(req); err != nil {
+		validation.RenderError(w, r, err)
+
+		return
+	}
+
+	err = wh.whuc.Update(r.Context(), id, &req)
+	if err != nil {
+		wh.log.Error("failed to update webhook", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrNullFields) {
+			response.RenderError(w, r, response.CodeNothingToUpdate, "nothing to update")
+
+			return
+		} else if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "webhook for update is not found")
+
+			return
+		} else if errors.Is(err, usecases.ErrInsecureURL) {
+			response.RenderError(w, r, response.CodeValidationFailed, "webhook url must be https")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Webhooks
+// @Tags webhooks
+// @Description Delete a specific webhook with its delivery log
+// @ID delete-webhook
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "webhook id"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/webhooks/{id} [delete]
+func (wh *webhooks) delete(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.webhooks.delete"
+
+	wh.log = wh.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	err = wh.whuc.Delete(r.Context(), id)
+	if err != nil {
+		wh.log.Error("failed to delete webhook", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "webhook for deletion is not found")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	response.RenderSuccess(w, r, http.StatusOK, "")
+}
+
+// @Summary Webhooks
+// @Tags webhooks
+// @Description Get the delivery log of the webhook, newest first
+// @ID get-webhook-deliveries
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param id path int true "webhook id"
+// @Param offset query int false "paginate through the deliveries"
+// @Param limit query int false "sets the list limit"
+// @Success 200 {array} dto.WebhookDeliveryResponse
+// @Failure 400 {object} response.Problem "invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 422 {object} response.Problem "unknown-tenant"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/webhooks/{id}/deliveries [get]
+func (wh *webhooks) getDeliveries(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.webhooks.getDeliveries"
+
+	wh.log = wh.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	deliveries, err := wh.whuc.GetDeliveries(r.Context(), id, pagination.Get(r.Context()))
+	if err != nil {
+		wh.log.Error("failed to get webhook deliveries", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "webhook not found")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []*dto.WebhookDeliveryResponse{}
+	}
+
+	render.Status(r, http.StatusOK)
+	render.JSON(w, r, deliveries)
+}
+
+// @Summary Webhooks
+// @Tags webhooks
+// @Description Queue the delivery to be sent again
+// @ID redeliver-webhook-delivery
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Param X-Tenant-ID header string false "tenant, defaults to the one of the credentials or default"
+// @Param Idempotency-Key header string false "replays the stored response for the retries with the same key"
+// @Param id path int true "webhook id"
+// @Param deliveryId path int true "delivery id"
+// @Success 202 {object} dto.WebhookDeliveryResponse
+// @Failure 400 {object} response.Problem "malformed-request, invalid-parameter"
+// @Failure 401 {object} response.Problem "unauthorized"
+// @Failure 403 {object} response.Problem "forbidden"
+// @Failure 404 {object} response.Problem "not-found"
+// @Failure 409 {object} response.Problem "request-in-progress"
+// @Failure 413 {object} response.Problem "request-too-large"
+// @Failure 422 {object} response.Problem "unknown-tenant, idempotency-key-reused"
+// @Failure 500 {object} response.Problem "internal-error"
+// @Failure default {object} response.Problem
+// @Router /v1/webhooks/{id}/deliveries/{deliveryId}/redeliver [post]
+func (wh *webhooks) redeliver(w http.ResponseWriter, r *http.Request) {
+	const fn = "http.handlers.webhooks.redeliver"
+
+	wh.log = wh.log.With(
+		slog.String("fn", fn),
+		slog.String("request_id", middleware.GetReqID(r.Context())),
+	)
+
+	id, err := urlParamID(r, "id")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	deliveryID, err := urlParamID(r, "deliveryId")
+	if err != nil {
+		response.RenderError(w, r, response.CodeInvalidParameter, err.Error())
+
+		return
+	}
+
+	delivery, err := wh.whuc.Redeliver(r.Context(), id, deliveryID)
+	if err != nil {
+		wh.log.Error("failed to redeliver", slog.String("error", err.Error()))
+
+		if errors.Is(err, usecases.ErrNoRowsAffected) {
+			response.RenderError(w, r, response.CodeNotFound, "delivery not found")
+
+			return
+		}
+
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+
+		return
+	}
+
+	render.Status(r, http.StatusAccepted)
+	render.JSON(w, r, delivery)
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"context"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/http/response"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+type Authenticator interface {
+	Authenticate(key string) (*entities.Principal, error)
+}
+
+const (
+	kindApiKey = iota
+	kindToken
+	kindSession
+)
+
+// SetPrincipalContextMiddleware authenticates the API key, the session or the
+// bearer token of the request, if any. Bearer tokens are only accepted when
+// tokens is set. Requests without credentials pass through anonymously, it's
+// up to RequireScope to reject them.
+func SetPrincipalContextMiddleware(apiKeys, sessions, tokens Authenticator, log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const fn = "http.middlewares.auth.SetPrincipalContextMiddleware"
+
+			credentials, kind := credentials(r)
+			if credentials == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var authn Authenticator
+			switch kind {
+			case kindApiKey:
+				authn = apiKeys
+			case kindSession:
+				authn = sessions
+			case kindToken:
+				authn = tokens
+			}
+
+			if authn == nil {
+				unauthorized(w, r)
+				return
+			}
+
+			principal, err := authn.Authenticate(credentials)
+			if err != nil {
+				log.Info("authentication failed",
+					slog.String("fn", fn),
+					slog.String("error", err.Error()),
+				)
+
+				unauthorized(w, r)
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(With(r.Context(), principal)))
+		})
+	}
+}
+
+// RequirePrincipal rejects the anonymous requests, leaving the permission
+// checks to the usecases.
+func RequirePrincipal(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Get(r.Context()) == nil {
+			unauthorized(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func RequireScope(scope string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := Get(r.Context())
+			if principal == nil {
+				unauthorized(w, r)
+				return
+			}
+
+			if !principal.HasScope(scope) {
+				response.RenderError(w, r, response.CodeForbidden, "missing scope "+scope)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func With(ctx context.Context, principal *entities.Principal) context.Context {
+	return context.WithValue(ctx, "principal", principal)
+}
+
+func Get(ctx context.Context) *entities.Principal {
+	val := ctx.Value("principal")
+	if principal, ok := val.(*entities.Principal); ok {
+		return principal
+	}
+	return nil
+}
+
+// credentials returns the credentials of the request and their kind. A bearer
+// credential is treated as a JWT when it has the three dot-separated parts and
+// as a session token when it has the session prefix.
+func credentials(r *http.Request) (string, int) {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key, kindApiKey
+	}
+
+	scheme, credentials, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok {
+		return "", kindApiKey
+	}
+	credentials = strings.TrimSpace(credentials)
+
+	switch {
+	case strings.EqualFold(scheme, "ApiKey"):
+		return credentials, kindApiKey
+	case strings.EqualFold(scheme, "Bearer"):
+		switch {
+		case strings.Count(credentials, ".") == 2:
+			return credentials, kindToken
+		case strings.HasPrefix(credentials, entities.SessionTokenPrefix):
+			return credentials, kindSession
+		}
+		return credentials, kindApiKey
+	}
+
+	return "", kindApiKey
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("WWW-Authenticate", `ApiKey realm="songs"`)
+	w.Header().Add("WWW-Authenticate", `Bearer realm="songs"`)
+	response.RenderError(w, r, response.CodeUnauthorized, "invalid or missing credentials")
+}
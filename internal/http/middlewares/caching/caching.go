@@ -0,0 +1,127 @@
+package caching
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	return rw.buf.Write(b)
+}
+
+// ConditionalGet tags the 200 responses with an ETag, answers the conditional
+// requests with 304 and sets the Cache-Control policy on both, so that errors
+// aren't cached.
+func ConditionalGet(policy string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rw := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+
+			if rw.status != http.StatusOK {
+				w.WriteHeader(rw.status)
+				_, _ = w.Write(rw.buf.Bytes())
+				return
+			}
+
+			if policy != "" {
+				w.Header().Set("Cache-Control", policy)
+			}
+
+			sum := sha256.Sum256(rw.buf.Bytes())
+			etag := `"` + hex.EncodeToString(sum[:]) + `"`
+			w.Header().Set("ETag", etag)
+
+			if notModified(r, etag, w.Header().Get("Last-Modified")) {
+				h := w.Header()
+				h.Del("Content-Type")
+				h.Del("Content-Length")
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.WriteHeader(rw.status)
+			if r.Method != http.MethodHead {
+				_, _ = w.Write(rw.buf.Bytes())
+			}
+		})
+	}
+}
+
+func SetLastModified(w http.ResponseWriter, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+
+	lastModified := t.UTC().Truncate(time.Second)
+	if prev, err := http.ParseTime(w.Header().Get("Last-Modified")); err == nil && !lastModified.After(prev) {
+		return
+	}
+
+	w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+}
+
+func notModified(r *http.Request, etag, lastModified string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatch(inm, etag)
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified == "" {
+		return false
+	}
+
+	since, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+
+	modified, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+
+	return !modified.After(since)
+}
+
+func etagMatch(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
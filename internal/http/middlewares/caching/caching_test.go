@@ -0,0 +1,200 @@
+package caching
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const body = `{"song":"Supermassive Black Hole"}`
+
+var (
+	etag         = tagOf(body)
+	lastModified = time.Date(2024, 12, 15, 12, 0, 0, 0, time.UTC)
+)
+
+func tagOf(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func TestConditionalGet(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		header     map[string]string
+		status     int
+		wantStatus int
+		wantBody   bool
+		wantTagged bool
+	}{
+		{
+			name:       "plain get",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantBody:   true,
+			wantTagged: true,
+		},
+		{
+			name:       "matching etag",
+			method:     http.MethodGet,
+			header:     map[string]string{"If-None-Match": etag},
+			wantStatus: http.StatusNotModified,
+			wantTagged: true,
+		},
+		{
+			name:       "weak matching etag among others",
+			method:     http.MethodGet,
+			header:     map[string]string{"If-None-Match": `"other", W/` + etag},
+			wantStatus: http.StatusNotModified,
+			wantTagged: true,
+		},
+		{
+			name:       "any etag",
+			method:     http.MethodGet,
+			header:     map[string]string{"If-None-Match": "*"},
+			wantStatus: http.StatusNotModified,
+			wantTagged: true,
+		},
+		{
+			name:       "stale etag",
+			method:     http.MethodGet,
+			header:     map[string]string{"If-None-Match": `"other"`},
+			wantStatus: http.StatusOK,
+			wantBody:   true,
+			wantTagged: true,
+		},
+		{
+			name:       "not modified since",
+			method:     http.MethodGet,
+			header:     map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)},
+			wantStatus: http.StatusNotModified,
+			wantTagged: true,
+		},
+		{
+			name:       "modified since",
+			method:     http.MethodGet,
+			header:     map[string]string{"If-Modified-Since": lastModified.Add(-time.Second).Format(http.TimeFormat)},
+			wantStatus: http.StatusOK,
+			wantBody:   true,
+			wantTagged: true,
+		},
+		{
+			name:   "etag wins over the date",
+			method: http.MethodGet,
+			header: map[string]string{
+				"If-None-Match":     `"other"`,
+				"If-Modified-Since": lastModified.Format(http.TimeFormat),
+			},
+			wantStatus: http.StatusOK,
+			wantBody:   true,
+			wantTagged: true,
+		},
+		{
+			name:       "head",
+			method:     http.MethodHead,
+			wantStatus: http.StatusOK,
+			wantTagged: true,
+		},
+		{
+			name:       "error",
+			method:     http.MethodGet,
+			header:     map[string]string{"If-None-Match": "*"},
+			status:     http.StatusNotFound,
+			wantStatus: http.StatusNotFound,
+			wantBody:   true,
+		},
+		{
+			name:       "write",
+			method:     http.MethodPost,
+			header:     map[string]string{"If-None-Match": "*"},
+			wantStatus: http.StatusOK,
+			wantBody:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := ConditionalGet("private, max-age=60")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				SetLastModified(w, lastModified.Add(-time.Hour))
+				SetLastModified(w, lastModified.Add(500*time.Millisecond))
+				w.Header().Set("Content-Type", "application/json")
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				_, _ = w.Write([]byte(body))
+			}))
+
+			r := httptest.NewRequest(tt.method, "/v1/songs", nil)
+			for name, value := range tt.header {
+				r.Header.Set(name, value)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			if got := w.Body.Len() > 0; got != tt.wantBody {
+				t.Errorf("body written = %t, want %t", got, tt.wantBody)
+			}
+
+			if got := w.Header().Get("ETag") == etag; got != tt.wantTagged {
+				t.Errorf("ETag = %q, tagged %t, want %t", w.Header().Get("ETag"), got, tt.wantTagged)
+			}
+
+			if got := w.Header().Get("Cache-Control") != ""; got != tt.wantTagged {
+				t.Errorf("Cache-Control = %q, want it set %t", w.Header().Get("Cache-Control"), tt.wantTagged)
+			}
+		})
+	}
+}
+
+func TestSetLastModified(t *testing.T) {
+	tests := []struct {
+		name  string
+		times []time.Time
+		want  string
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:  "zero",
+			times: []time.Time{{}},
+		},
+		{
+			name:  "truncated to the second",
+			times: []time.Time{lastModified.Add(999 * time.Millisecond)},
+			want:  lastModified.Format(http.TimeFormat),
+		},
+		{
+			name:  "newest wins",
+			times: []time.Time{lastModified.Add(-time.Hour), lastModified, lastModified.Add(-time.Minute)},
+			want:  lastModified.Format(http.TimeFormat),
+		},
+		{
+			name:  "in utc",
+			times: []time.Time{lastModified.In(time.FixedZone("MSK", 3*60*60))},
+			want:  lastModified.Format(http.TimeFormat),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			for _, modified := range tt.times {
+				SetLastModified(w, modified)
+			}
+
+			if got := w.Header().Get("Last-Modified"); got != tt.want {
+				t.Errorf("Last-Modified = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/http/middlewares/auth"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"effective-mobile-test/internal/http/response"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderKey      = "Idempotency-Key"
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+
+	noStoreKey = "idempotency_no_store"
+)
+
+// replayedHeaders are stored along with the response.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+type Store interface {
+	Reserve(key, fingerprint string, ttl time.Duration) (*entities.IdempotencyKey, bool, error)
+	Complete(key string, statusCode int, headers, body []byte) error
+	Release(key string) error
+	DeleteExpired() (int64, error)
+}
+
+type Idempotency struct {
+	store    Store
+	ttl      time.Duration
+	maxBody  int64
+	excluded map[string]struct{}
+	log      *slog.Logger
+}
+
+// New reads up to maxBody bytes of the requests with a key, the larger ones
+// are refused.
+func New(store Store, ttl time.Duration, maxBody int64, log *slog.Logger) *Idempotency {
+	return &Idempotency{
+		store:    store,
+		ttl:      ttl,
+		maxBody:  maxBody,
+		excluded: make(map[string]struct{}),
+		log:      log,
+	}
+}
+
+// Exclude leaves the route out, for the responses carrying secrets that must
+// not be stored. It's meant to be called while the routes are set up.
+func (i *Idempotency) Exclude(method, path string) {
+	i.excluded[route(method, path)] = struct{}{}
+}
+
+// NoStore keeps the response to the request from being stored, for the
+// handlers whose responses only carry secrets at times. The key is released
+// and the retries are handled anew.
+func NoStore(r *http.Request) {
+	if noStore, ok := r.Context().Value(noStoreKey).(*bool); ok {
+		*noStore = true
+	}
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+func (rw *responseWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+}
+
+func (rw *responseWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	return rw.buf.Write(b)
+}
+
+// Middleware stores the first response of every write request carrying an
+// Idempotency-Key header and replays it for the retries with the same key.
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderKey)
+		if key == "" || !isWriteMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, ok := i.excluded[route(r.Method, r.URL.Path)]; ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		const fn = "http.middlewares.idempotency.Middleware"
+
+		log := i.log.With(
+			slog.String("fn", fn),
+			slog.String("key", key),
+		)
+
+		if len(key) > maxKeyLength {
+			response.RenderError(w, r, response.CodeInvalidParameter, "idempotency key is too long")
+
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, i.maxBody))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				response.RenderError(w, r, response.CodeRequestTooLarge, "request body is too large")
+
+				return
+			}
+
+			response.RenderError(w, r, response.CodeMalformedRequest, "failed to read request body")
+
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := fingerprint(r, body)
+
+		// The same key sent by different principals or to different tenants
+		// stands for different requests.
+		key = tenant.Get(r.Context()) + ":" + scope(r, key)
+
+		record, reserved, err := i.store.Reserve(key, sum, i.ttl)
+		if err != nil {
+			log.Error("failed to reserve idempotency key", slog.String("error", err.Error()))
+
+			response.RenderError(w, r, response.CodeInternal, "internal error")
+
+			return
+		}
+
+		if !reserved {
+			switch {
+			case record.Fingerprint != sum:
+				response.RenderError(w, r, response.CodeIdempotencyKeyReused, "idempotency key is already used for another request")
+			case record.StatusCode == nil:
+				response.RenderError(w, r, response.CodeRequestInProgress, "request with this idempotency key is in progress")
+			default:
+				replay(w, record)
+			}
+
+			return
+		}
+
+		rw := &responseWriter{ResponseWriter: w}
+
+		var noStore bool
+		r = r.WithContext(context.WithValue(r.Context(), noStoreKey, &noStore))
+
+		defer func() {
+			if p := recover(); p != nil {
+				_ = i.store.Release(key)
+				panic(p)
+			}
+
+			if rw.status == 0 {
+				rw.status = http.StatusOK
+			}
+
+			if noStore || !isStored(rw.status) {
+				err = i.store.Release(key)
+			} else {
+				err = i.store.Complete(key, rw.status, storedHeaders(w.Header()), rw.buf.Bytes())
+			}
+
+			if err != nil {
+				log.Error("failed to store idempotent response", slog.String("error", err.Error()))
+			}
+
+			w.WriteHeader(rw.status)
+			_, _ = w.Write(rw.buf.Bytes())
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+func (i *Idempotency) RunCleanup(ctx context.Context, interval time.Duration) {
+	const fn = "http.middlewares.idempotency.RunCleanup"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := i.store.DeleteExpired(); err != nil {
+				i.log.Error("failed to delete expired idempotency keys",
+					slog.String("fn", fn),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+func replay(w http.ResponseWriter, record *entities.IdempotencyKey) {
+	var headers map[string]string
+	_ = json.Unmarshal(record.Headers, &headers)
+
+	for name, value := range headers {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set(HeaderReplayed, "true")
+	w.WriteHeader(*record.StatusCode)
+	_, _ = w.Write(record.Body)
+}
+
+// storedHeaders encodes the replayed headers the response sets.
+func storedHeaders(header http.Header) []byte {
+	headers := make(map[string]string, len(replayedHeaders))
+	for _, name := range replayedHeaders {
+		if value := header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+
+	b, _ := json.Marshal(headers)
+
+	return b
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.RawQuery))
+	h.Write([]byte{0})
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// scope hashes the key along with the subject of the principal, which keeps
+// it within the length of the column.
+func scope(r *http.Request, key string) string {
+	var subject string
+	if principal := auth.Get(r.Context()); principal != nil {
+		subject = principal.Subject
+	}
+
+	sum := sha256.Sum256([]byte(subject + "\x00" + key))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// isStored tells whether the response is the outcome of the request rather
+// than of the credentials, which the retries may fix, or of a failure.
+func isStored(status int) bool {
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return false
+	case status >= http.StatusInternalServerError:
+		return false
+	}
+	return true
+}
+
+func route(method, path string) string {
+	return method + " " + strings.TrimSuffix(path, "/")
+}
+
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
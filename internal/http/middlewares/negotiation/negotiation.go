@@ -0,0 +1,151 @@
+package negotiation
+
+import (
+	"effective-mobile-test/internal/http/response"
+	"github.com/go-chi/chi/v5/middleware"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var extensions = map[string]response.Format{
+	"json": response.FormatJSON,
+	"xml":  response.FormatXML,
+	"csv":  response.FormatCSV,
+	"yaml": response.FormatYAML,
+	"yml":  response.FormatYAML,
+}
+
+// mediaTypes are the media types accepted for each of the formats besides the
+// one it is served with.
+var mediaTypes = map[string]response.Format{
+	"text/json":          response.FormatJSON,
+	"text/xml":           response.FormatXML,
+	"application/csv":    response.FormatCSV,
+	"application/x-yaml": response.FormatYAML,
+	"text/yaml":          response.FormatYAML,
+	"text/x-yaml":        response.FormatYAML,
+}
+
+// SetFormatContextMiddleware picks the format the response is rendered in,
+// see response.Render. The extension of the path, as parsed by
+// middleware.URLFormat, takes precedence over the Accept header, and requests
+// without either get JSON. Anything else is answered with 406.
+func SetFormatContextMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		var (
+			format response.Format
+			ok     bool
+		)
+
+		if ext, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); ext != "" {
+			format, ok = extensions[strings.ToLower(ext)]
+		} else {
+			format, ok = negotiate(r.Header.Values("Accept"))
+		}
+
+		if !ok {
+			response.RenderError(w, r, response.CodeNotAcceptable, "supported formats are json, xml, csv and yaml")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(response.WithFormat(r.Context(), format)))
+	})
+}
+
+// negotiate returns the format with the highest quality in the Accept header,
+// the most specific media range of the header decides the quality of each.
+func negotiate(header []string) (response.Format, bool) {
+	ranges := parseAccept(header)
+	if len(ranges) == 0 {
+		return response.FormatJSON, true
+	}
+
+	var (
+		best    response.Format
+		quality float64
+	)
+
+	for _, format := range response.Formats {
+		if q := qualityOf(format, ranges); q > quality {
+			best, quality = format, q
+		}
+	}
+
+	return best, quality > 0
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAccept(header []string) []mediaRange {
+	var ranges []mediaRange
+
+	for _, value := range header {
+		for _, part := range strings.Split(value, ",") {
+			params := strings.Split(part, ";")
+
+			mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+			if mediaType == "" {
+				continue
+			}
+
+			quality := 1.0
+			for _, param := range params[1:] {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.ToLower(name) != "q" {
+					continue
+				}
+
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+
+			ranges = append(ranges, mediaRange{mediaType, quality})
+		}
+	}
+
+	return ranges
+}
+
+// qualityOf returns the quality of the most specific of the ranges matching
+// the format: a media type goes before type/* and */*. Only the media type the
+// format is served with matches type/*, so text/* stands for CSV.
+func qualityOf(format response.Format, ranges []mediaRange) float64 {
+	var (
+		quality     float64
+		specificity int
+	)
+
+	for _, rng := range ranges {
+		s := matches(format, rng.mediaType)
+		if s > specificity {
+			quality, specificity = rng.quality, s
+		}
+	}
+
+	return quality
+}
+
+func matches(format response.Format, mediaType string) int {
+	if mediaType == format.MediaType() || mediaTypes[mediaType] == format {
+		return 3
+	}
+
+	if typ, ok := strings.CutSuffix(mediaType, "/*"); ok {
+		if typ == "*" {
+			return 1
+		}
+
+		if strings.HasPrefix(format.MediaType(), typ+"/") {
+			return 2
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,92 @@
+package tenant
+
+import (
+	"context"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/http/middlewares/auth"
+	"effective-mobile-test/internal/http/response"
+	"log/slog"
+	"net/http"
+)
+
+type Checker interface {
+	CanSwitch(ctx context.Context) (bool, error)
+	Exists(id string) (bool, error)
+}
+
+// SetTenantContextMiddleware resolves the tenant of the request. Callers bound
+// to a tenant always get their own, the ones allowed to manage the tenants
+// may pick one with the X-Tenant-ID header, the others get the default
+// tenant.
+func SetTenantContextMiddleware(tenants Checker, log *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const fn = "http.middlewares.tenant.SetTenantContextMiddleware"
+
+			id := r.Header.Get("X-Tenant-ID")
+
+			principal := auth.Get(r.Context())
+			switch {
+			case principal != nil && principal.Tenant != "":
+				if id != "" && id != principal.Tenant {
+					response.RenderError(w, r, response.CodeForbidden, "the credentials aren't valid for tenant "+id)
+					return
+				}
+				id = principal.Tenant
+			case id == "" || id == entities.DefaultTenant:
+				id = entities.DefaultTenant
+			default:
+				allowed, err := tenants.CanSwitch(r.Context())
+				if err != nil {
+					log.Error("failed to authorize tenant",
+						slog.String("fn", fn),
+						slog.String("tenant", id),
+						slog.String("error", err.Error()),
+					)
+
+					response.RenderError(w, r, response.CodeInternal, "internal error")
+
+					return
+				}
+
+				if !allowed {
+					response.RenderError(w, r, response.CodeForbidden, "the credentials aren't valid for tenant "+id)
+					return
+				}
+			}
+
+			exists, err := tenants.Exists(id)
+			if err != nil {
+				log.Error("failed to check tenant",
+					slog.String("fn", fn),
+					slog.String("tenant", id),
+					slog.String("error", err.Error()),
+				)
+
+				response.RenderError(w, r, response.CodeInternal, "internal error")
+
+				return
+			}
+
+			if !exists {
+				response.RenderError(w, r, response.CodeUnknownTenant, "unknown tenant "+id)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(With(r.Context(), id)))
+		})
+	}
+}
+
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, "tenant", id)
+}
+
+// Get returns the tenant of the request, empty outside of one.
+func Get(ctx context.Context) string {
+	val := ctx.Value("tenant")
+	if id, ok := val.(string); ok {
+		return id
+	}
+	return ""
+}
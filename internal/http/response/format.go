@@ -0,0 +1,111 @@
+package response
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"github.com/go-chi/render"
+	"gopkg.in/yaml.v3"
+	"net/http"
+)
+
+// Format is the representation a response is rendered in, see Render.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatXML  Format = "xml"
+	FormatCSV  Format = "csv"
+	FormatYAML Format = "yaml"
+)
+
+// Formats are the supported formats in the order they are preferred when the
+// client accepts several equally.
+var Formats = []Format{FormatJSON, FormatXML, FormatCSV, FormatYAML}
+
+// MediaType returns the media type the format is served with.
+func (f Format) MediaType() string {
+	switch f {
+	case FormatXML:
+		return "application/xml"
+	case FormatCSV:
+		return "text/csv"
+	case FormatYAML:
+		return "application/yaml"
+	default:
+		return "application/json"
+	}
+}
+
+var errNotTable = errors.New("the response doesn't render as CSV")
+
+// Table is implemented by the responses that render as CSV, the first record
+// is the header.
+type Table interface {
+	Records() [][]string
+}
+
+// WithFormat sets the format Render uses for the request.
+func WithFormat(ctx context.Context, format Format) context.Context {
+	return context.WithValue(ctx, "format", format)
+}
+
+// GetFormat returns the format of the request, JSON unless negotiated.
+func GetFormat(ctx context.Context) Format {
+	val := ctx.Value("format")
+	if format, ok := val.(Format); ok {
+		return format
+	}
+	return FormatJSON
+}
+
+// Render renders v in the format negotiated for the request. XML relies on the
+// xml tags of v, CSV on v being a Table.
+func Render(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	format := GetFormat(r.Context())
+
+	switch format {
+	case FormatXML:
+		render.Status(r, status)
+		render.XML(w, r, v)
+
+		return
+	case FormatJSON:
+		render.Status(r, status)
+		render.JSON(w, r, v)
+
+		return
+	}
+
+	body, err := marshal(format, v)
+	if err != nil {
+		RenderError(w, r, CodeInternal, "internal error")
+
+		return
+	}
+
+	w.Header().Set("Content-Type", format.MediaType()+"; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
+
+func marshal(format Format, v interface{}) ([]byte, error) {
+	if format == FormatYAML {
+		return yaml.Marshal(v)
+	}
+
+	table, ok := v.(Table)
+	if !ok {
+		return nil, errNotTable
+	}
+
+	var buf bytes.Buffer
+
+	writer := csv.NewWriter(&buf)
+	if err := writer.WriteAll(table.Records()); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,154 @@
+package response
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5/middleware"
+	"net/http"
+)
+
+// Problem is an RFC 7807 problem details object, the body of every error
+// response.
+type Problem struct {
+	// Type resolves to the ProblemType of the code.
+	Type   string `json:"type" example:"/problems/not-found"`
+	Title  string `json:"title" example:"Not found"`
+	Status int    `json:"status" example:"404"`
+	Detail string `json:"detail,omitempty" example:"song not found"`
+	// Instance is the path of the request.
+	Instance string `json:"instance,omitempty" example:"/v1/songs/text"`
+	// Code is stable, clients should branch on it rather than on the title or
+	// the detail, see GET /problems for the catalogue.
+	Code      Code   `json:"code" enums:"malformed-request,invalid-parameter,validation-failed,unauthorized,forbidden,not-found,missing-lyrics,method-not-allowed,not-acceptable,already-exists,in-use,built-in,request-in-progress,nothing-to-update,unknown-tenant,invalid-parent,invalid-relation,same-song,explicit,idempotency-key-reused,request-too-large,internal-error"`
+	RequestID string `json:"requestId,omitempty" example:"host/abcdef-000001"`
+	// Errors lists the invalid fields of a validation-failed problem, with the
+	// messages in English or Russian as the Accept-Language header prefers.
+	Errors []*FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is a field of the request breaking a validation rule.
+type FieldError struct {
+	// Field is the JSON or the query key, with the path for nested fields.
+	Field   string `json:"field" example:"group"`
+	Rule    string `json:"rule" example:"required"`
+	Message string `json:"message" example:"group is a required field"`
+}
+
+// Code is the machine-readable code of a problem.
+type Code string
+
+const (
+	CodeMalformedRequest     Code = "malformed-request"
+	CodeInvalidParameter     Code = "invalid-parameter"
+	CodeValidationFailed     Code = "validation-failed"
+	CodeUnauthorized         Code = "unauthorized"
+	CodeForbidden            Code = "forbidden"
+	CodeNotFound             Code = "not-found"
+	CodeMissingLyrics        Code = "missing-lyrics"
+	CodeMethodNotAllowed     Code = "method-not-allowed"
+	CodeNotAcceptable        Code = "not-acceptable"
+	CodeAlreadyExists        Code = "already-exists"
+	CodeInUse                Code = "in-use"
+	CodeBuiltin              Code = "built-in"
+	CodeRequestInProgress    Code = "request-in-progress"
+	CodeNothingToUpdate      Code = "nothing-to-update"
+	CodeUnknownTenant        Code = "unknown-tenant"
+	CodeInvalidParent        Code = "invalid-parent"
+	CodeInvalidRelation      Code = "invalid-relation"
+	CodeSameSong             Code = "same-song"
+	CodeExplicit             Code = "explicit"
+	CodeIdempotencyKeyReused Code = "idempotency-key-reused"
+	CodeRequestTooLarge      Code = "request-too-large"
+	CodeInternal             Code = "internal-error"
+)
+
+// ProblemType documents a code, it is served at its Type.
+type ProblemType struct {
+	Type        string `json:"type" example:"/problems/not-found"`
+	Code        Code   `json:"code" example:"not-found"`
+	Status      int    `json:"status" example:"404"`
+	Title       string `json:"title" example:"Not found"`
+	Description string `json:"description" example:"the song, or another resource the request refers to, doesn't exist"`
+}
+
+// ProblemTypes is the catalogue of the codes. Codes are never renamed or
+// removed, only added.
+var ProblemTypes = []*ProblemType{
+	problemType(CodeMalformedRequest, http.StatusBadRequest, "Malformed request", "the body isn't valid JSON or the query can't be decoded"),
+	problemType(CodeInvalidParameter, http.StatusBadRequest, "Invalid parameter", "a path parameter, header or token of the request is invalid"),
+	problemType(CodeValidationFailed, http.StatusUnprocessableEntity, "Validation failed", "the request is well-formed but its fields break the rules of the route"),
+	problemType(CodeUnauthorized, http.StatusUnauthorized, "Unauthorized", "the credentials are missing or invalid"),
+	problemType(CodeForbidden, http.StatusForbidden, "Forbidden", "the credentials lack the scope, the permission or the tenant the request needs"),
+	problemType(CodeNotFound, http.StatusNotFound, "Not found", "the song, or another resource the request refers to, doesn't exist"),
+	problemType(CodeMissingLyrics, http.StatusNotFound, "Missing lyrics", "the song has no lyrics yet"),
+	problemType(CodeMethodNotAllowed, http.StatusMethodNotAllowed, "Method not allowed", "the route doesn't support the method"),
+	problemType(CodeNotAcceptable, http.StatusNotAcceptable, "Not acceptable", "none of the formats of the Accept header or the extension are supported"),
+	problemType(CodeAlreadyExists, http.StatusConflict, "Already exists", "the song, or another resource the request creates, already exists"),
+	problemType(CodeInUse, http.StatusConflict, "In use", "the resource is still referred to and can't be deleted"),
+	problemType(CodeBuiltin, http.StatusConflict, "Built-in", "built-in roles and the default tenant can't be changed"),
+	problemType(CodeRequestInProgress, http.StatusConflict, "Request in progress", "a request with the same idempotency key is still being handled"),
+	problemType(CodeNothingToUpdate, http.StatusUnprocessableEntity, "Nothing to update", "the update request has no fields set"),
+	problemType(CodeUnknownTenant, http.StatusUnprocessableEntity, "Unknown tenant", "the tenant the request refers to doesn't exist"),
+	problemType(CodeInvalidParent, http.StatusUnprocessableEntity, "Invalid parent", "only genres have a parent, which must be another genre and not a subgenre"),
+	problemType(CodeInvalidRelation, http.StatusUnprocessableEntity, "Invalid relation", "a song can't derive from itself or from its own version"),
+	problemType(CodeSameSong, http.StatusUnprocessableEntity, "Same song", "the songs to merge are the same"),
+	problemType(CodeExplicit, http.StatusUnprocessableEntity, "Explicit song", "the song is marked explicit and can't be served in clean mode"),
+	problemType(CodeIdempotencyKeyReused, http.StatusUnprocessableEntity, "Idempotency key reused", "the idempotency key was already used for a different request"),
+	problemType(CodeRequestTooLarge, http.StatusRequestEntityTooLarge, "Request too large", "the body of the request with an idempotency key exceeds the limit"),
+	problemType(CodeInternal, http.StatusInternalServerError, "Internal error", "the request failed on the server, retrying may help"),
+}
+
+func problemType(code Code, status int, title, description string) *ProblemType {
+	return &ProblemType{
+		Type:        "/problems/" + string(code),
+		Code:        code,
+		Status:      status,
+		Title:       title,
+		Description: description,
+	}
+}
+
+// GetProblemType returns the catalogue entry of the code, internal-error for
+// an unknown one.
+func GetProblemType(code Code) *ProblemType {
+	for _, pt := range ProblemTypes {
+		if pt.Code == code {
+			return pt
+		}
+	}
+	return GetProblemType(CodeInternal)
+}
+
+// RenderError renders the problem of the code as application/problem+json,
+// with the status of the code.
+func RenderError(w http.ResponseWriter, r *http.Request, code Code, detail string) {
+	renderProblem(w, r, code, detail, nil)
+}
+
+// RenderValidationError renders the validation-failed problem listing the
+// invalid fields.
+func RenderValidationError(w http.ResponseWriter, r *http.Request, detail string, errs []*FieldError) {
+	renderProblem(w, r, CodeValidationFailed, detail, errs)
+}
+
+func renderProblem(w http.ResponseWriter, r *http.Request, code Code, detail string, errs []*FieldError) {
+	pt := GetProblemType(code)
+
+	body, err := json.Marshal(&Problem{
+		Type:      pt.Type,
+		Title:     pt.Title,
+		Status:    pt.Status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		Code:      pt.Code,
+		RequestID: middleware.GetReqID(r.Context()),
+		Errors:    errs,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(pt.Status)
+	_, _ = w.Write(body)
+}
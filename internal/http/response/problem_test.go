@@ -0,0 +1,148 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5/middleware"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetProblemType(t *testing.T) {
+	tests := []struct {
+		code       Code
+		wantCode   Code
+		wantStatus int
+	}{
+		{code: CodeMalformedRequest, wantCode: CodeMalformedRequest, wantStatus: http.StatusBadRequest},
+		{code: CodeValidationFailed, wantCode: CodeValidationFailed, wantStatus: http.StatusUnprocessableEntity},
+		{code: CodeUnauthorized, wantCode: CodeUnauthorized, wantStatus: http.StatusUnauthorized},
+		{code: CodeForbidden, wantCode: CodeForbidden, wantStatus: http.StatusForbidden},
+		{code: CodeNotFound, wantCode: CodeNotFound, wantStatus: http.StatusNotFound},
+		{code: CodeMissingLyrics, wantCode: CodeMissingLyrics, wantStatus: http.StatusNotFound},
+		{code: CodeAlreadyExists, wantCode: CodeAlreadyExists, wantStatus: http.StatusConflict},
+		{code: CodeRequestTooLarge, wantCode: CodeRequestTooLarge, wantStatus: http.StatusRequestEntityTooLarge},
+		{code: CodeInternal, wantCode: CodeInternal, wantStatus: http.StatusInternalServerError},
+		{code: "no-such-code", wantCode: CodeInternal, wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.code), func(t *testing.T) {
+			pt := GetProblemType(tt.code)
+
+			if pt.Code != tt.wantCode || pt.Status != tt.wantStatus {
+				t.Errorf("GetProblemType(%q) = %s %d, want %s %d", tt.code, pt.Code, pt.Status, tt.wantCode, tt.wantStatus)
+			}
+
+			if want := "/problems/" + string(tt.wantCode); pt.Type != want {
+				t.Errorf("Type = %q, want %q", pt.Type, want)
+			}
+		})
+	}
+}
+
+func TestProblemTypes(t *testing.T) {
+	seen := make(map[Code]bool)
+
+	for _, pt := range ProblemTypes {
+		t.Run(string(pt.Code), func(t *testing.T) {
+			if seen[pt.Code] {
+				t.Errorf("code %q listed twice", pt.Code)
+			}
+			seen[pt.Code] = true
+
+			if pt.Status < 400 || pt.Status > 599 {
+				t.Errorf("status %d isn't an error", pt.Status)
+			}
+
+			if pt.Title == "" || pt.Description == "" {
+				t.Error("title or description is empty")
+			}
+		})
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	fieldErrors := []*FieldError{{Field: "group", Rule: "required", Message: "group is a required field"}}
+
+	tests := []struct {
+		name   string
+		render func(w http.ResponseWriter, r *http.Request)
+		want   Problem
+	}{
+		{
+			name: "not found",
+			render: func(w http.ResponseWriter, r *http.Request) {
+				RenderError(w, r, CodeNotFound, "song not found")
+			},
+			want: Problem{
+				Type:      "/problems/not-found",
+				Title:     "Not found",
+				Status:    http.StatusNotFound,
+				Detail:    "song not found",
+				Instance:  "/v1/songs/text",
+				Code:      CodeNotFound,
+				RequestID: "host/abcdef-000001",
+			},
+		},
+		{
+			name: "unknown code",
+			render: func(w http.ResponseWriter, r *http.Request) {
+				RenderError(w, r, "no-such-code", "")
+			},
+			want: Problem{
+				Type:      "/problems/internal-error",
+				Title:     "Internal error",
+				Status:    http.StatusInternalServerError,
+				Instance:  "/v1/songs/text",
+				Code:      CodeInternal,
+				RequestID: "host/abcdef-000001",
+			},
+		},
+		{
+			name: "validation",
+			render: func(w http.ResponseWriter, r *http.Request) {
+				RenderValidationError(w, r, "invalid request", fieldErrors)
+			},
+			want: Problem{
+				Type:      "/problems/validation-failed",
+				Title:     "Validation failed",
+				Status:    http.StatusUnprocessableEntity,
+				Detail:    "invalid request",
+				Instance:  "/v1/songs/text",
+				Code:      CodeValidationFailed,
+				RequestID: "host/abcdef-000001",
+				Errors:    fieldErrors,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/v1/songs/text?group=Muse", nil)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, "host/abcdef-000001"))
+
+			w := httptest.NewRecorder()
+			tt.render(w, r)
+
+			if w.Code != tt.want.Status {
+				t.Errorf("status = %d, want %d", w.Code, tt.want.Status)
+			}
+
+			if got := w.Header().Get("Content-Type"); got != "application/problem+json" {
+				t.Errorf("Content-Type = %q, want application/problem+json", got)
+			}
+
+			var got Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %q: %v", w.Body.String(), err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("problem = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
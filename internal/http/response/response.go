@@ -10,18 +10,7 @@ type Response struct {
 	Message string `json:"message,omitempty"`
 }
 
-const (
-	statusError   = "error"
-	statusSuccess = "success"
-)
-
-func RenderError(w http.ResponseWriter, r *http.Request, status int, message string) {
-	render.Status(r, status)
-	render.JSON(w, r, &Response{
-		Status:  statusError,
-		Message: message,
-	})
-}
+const statusSuccess = "success"
 
 func RenderSuccess(w http.ResponseWriter, r *http.Request, status int, message string) {
 	render.Status(r, status)
@@ -0,0 +1,172 @@
+package validation
+
+import (
+	"effective-mobile-test/internal/http/response"
+	"errors"
+	enlocale "github.com/go-playground/locales/en"
+	rulocale "github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	rutranslations "github.com/go-playground/validator/v10/translations/ru"
+	"golang.org/x/text/language"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	validate = validator.New()
+
+	translators = ut.New(enlocale.New(), enlocale.New(), rulocale.New())
+
+	// languages are the languages the messages are translated to, the first
+	// one is the default.
+	languages = []language.Tag{language.English, language.Russian}
+	matcher   = language.NewMatcher(languages)
+)
+
+// details are the details of the problem per language.
+var details = map[string]string{
+	"en": "the request has invalid fields",
+	"ru": "запрос содержит недопустимые поля",
+}
+
+// translations fill in the rules the default translations of the validator
+// lack.
+var translations = map[string]map[string]string{
+	"en": {
+		"hostname_rfc1123": "{0} must be a valid hostname",
+		"maxbytes":         "{0} must be at most {1} bytes long",
+	},
+	"ru": {
+		"datetime":         "{0} не соответствует формату {1}",
+		"hostname_rfc1123": "{0} должен быть допустимым именем хоста",
+		"lowercase":        "{0} должен быть в нижнем регистре",
+		"maxbytes":         "{0} должен быть не длиннее {1} байт",
+	},
+}
+
+func init() {
+	validate.RegisterTagNameFunc(fieldName)
+
+	if err := validate.RegisterValidation("maxbytes", maxBytes); err != nil {
+		panic(err)
+	}
+
+	register := map[string]func(*validator.Validate, ut.Translator) error{
+		"en": entranslations.RegisterDefaultTranslations,
+		"ru": rutranslations.RegisterDefaultTranslations,
+	}
+
+	for lang, registerDefaults := range register {
+		trans, _ := translators.GetTranslator(lang)
+
+		if err := registerDefaults(validate, trans); err != nil {
+			panic(err)
+		}
+
+		for tag, text := range translations[lang] {
+			err := validate.RegisterTranslation(tag, trans, registerText(tag, text), translate)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// Struct validates the request, the errors are reported with RenderError.
+func Struct(s interface{}) error {
+	return validate.Struct(s)
+}
+
+// RenderError renders the validation-failed problem with an entry per invalid
+// field, translated to the language of the Accept-Language header, English
+// by default.
+func RenderError(w http.ResponseWriter, r *http.Request, err error) {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		response.RenderError(w, r, response.CodeInternal, "internal error")
+		return
+	}
+
+	lang := Language(r)
+	trans, _ := translators.GetTranslator(lang)
+
+	fields := make([]*response.FieldError, len(invalid))
+	for i, fe := range invalid {
+		fields[i] = &response.FieldError{
+			Field:   field(fe),
+			Rule:    fe.Tag(),
+			Message: fe.Translate(trans),
+		}
+	}
+
+	w.Header().Set("Content-Language", lang)
+	response.RenderValidationError(w, r, details[lang], fields)
+}
+
+// Language returns the one of the supported languages the Accept-Language
+// header prefers.
+func Language(r *http.Request) string {
+	tag, _ := language.MatchStrings(matcher, r.Header.Get("Accept-Language"))
+	base, _ := tag.Base()
+
+	for _, supported := range languages {
+		if b, _ := supported.Base(); b == base {
+			return b.String()
+		}
+	}
+
+	base, _ = languages[0].Base()
+	return base.String()
+}
+
+// fieldName names the fields after their JSON or query keys.
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "schema"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// field returns the path of the field within the request, such as
+// original.group or scopes[0].
+func field(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+// maxBytes limits the length of the string in bytes rather than in runes as
+// max does, for bcrypt taking the first 72 bytes of a password.
+func maxBytes(fl validator.FieldLevel) bool {
+	limit, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		panic(err)
+	}
+	return len(fl.Field().String()) <= limit
+}
+
+func registerText(tag, text string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, text, true)
+	}
+}
+
+func translate(trans ut.Translator, fe validator.FieldError) string {
+	text, err := trans.T(fe.Tag(), fe.Field(), fe.Param())
+	if err != nil {
+		return fe.Error()
+	}
+	return text
+}
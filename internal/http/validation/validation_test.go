@@ -0,0 +1,180 @@
+package validation
+
+import (
+	"effective-mobile-test/internal/http/response"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type testSong struct {
+	Group string `json:"group" validate:"required"`
+	Song  string `json:"song" validate:"required,max=5"`
+}
+
+type testRequest struct {
+	Original testSong `json:"original"`
+	Password string   `json:"password" validate:"maxbytes=4"`
+	Scopes   []string `json:"scopes" validate:"dive,lowercase"`
+	Page     int      `schema:"page" validate:"gte=1"`
+}
+
+func validRequest() testRequest {
+	return testRequest{
+		Original: testSong{Group: "Muse", Song: "Hi"},
+		Password: "pass",
+		Scopes:   []string{"songs:read"},
+		Page:     1,
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           string
+	}{
+		{name: "none", want: "en"},
+		{name: "russian", acceptLanguage: "ru", want: "ru"},
+		{name: "russian region", acceptLanguage: "ru-RU,ru;q=0.9,en;q=0.8", want: "ru"},
+		{name: "english preferred", acceptLanguage: "en-GB,ru;q=0.5", want: "en"},
+		{name: "russian preferred by weight", acceptLanguage: "en;q=0.1,ru", want: "ru"},
+		{name: "unsupported", acceptLanguage: "de", want: "en"},
+		{name: "malformed", acceptLanguage: ";;;", want: "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+
+			if got := Language(r); got != tt.want {
+				t.Errorf("Language(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	tests := []struct {
+		name           string
+		modify         func(req *testRequest)
+		acceptLanguage string
+		want           []*response.FieldError
+	}{
+		{
+			name:   "nested field",
+			modify: func(req *testRequest) { req.Original.Group = "" },
+			want:   []*response.FieldError{{Field: "original.group", Rule: "required", Message: "group is a required field"}},
+		},
+		{
+			name:           "nested field in russian",
+			modify:         func(req *testRequest) { req.Original.Group = "" },
+			acceptLanguage: "ru",
+			want:           []*response.FieldError{{Field: "original.group", Rule: "required", Message: "group обязательное поле"}},
+		},
+		{
+			name:   "parameter",
+			modify: func(req *testRequest) { req.Original.Song = "Uprising" },
+			want:   []*response.FieldError{{Field: "original.song", Rule: "max", Message: "song must be a maximum of 5 characters in length"}},
+		},
+		{
+			name:   "bytes rather than runes",
+			modify: func(req *testRequest) { req.Password = "пароль" },
+			want:   []*response.FieldError{{Field: "password", Rule: "maxbytes", Message: "password must be at most 4 bytes long"}},
+		},
+		{
+			name:           "bytes in russian",
+			modify:         func(req *testRequest) { req.Password = "пароль" },
+			acceptLanguage: "ru",
+			want:           []*response.FieldError{{Field: "password", Rule: "maxbytes", Message: "password должен быть не длиннее 4 байт"}},
+		},
+		{
+			name:           "element of a list",
+			modify:         func(req *testRequest) { req.Scopes = []string{"songs:read", "Songs:Write"} },
+			acceptLanguage: "ru",
+			want:           []*response.FieldError{{Field: "scopes[1]", Rule: "lowercase", Message: "scopes[1] должен быть в нижнем регистре"}},
+		},
+		{
+			name:   "query key",
+			modify: func(req *testRequest) { req.Page = 0 },
+			want:   []*response.FieldError{{Field: "page", Rule: "gte", Message: "page must be 1 or greater"}},
+		},
+		{
+			name: "several fields",
+			modify: func(req *testRequest) {
+				req.Original.Group = ""
+				req.Page = 0
+			},
+			want: []*response.FieldError{
+				{Field: "original.group", Rule: "required", Message: "group is a required field"},
+				{Field: "page", Rule: "gte", Message: "page must be 1 or greater"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := validRequest()
+			tt.modify(&req)
+
+			err := Struct(&req)
+			if err == nil {
+				t.Fatal("Struct() returned no error")
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/songs", nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+
+			w := httptest.NewRecorder()
+			RenderError(w, r, err)
+
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+			}
+
+			lang := Language(r)
+			if got := w.Header().Get("Content-Language"); got != lang {
+				t.Errorf("Content-Language = %q, want %q", got, lang)
+			}
+
+			var problem response.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("body %q: %v", w.Body.String(), err)
+			}
+
+			if problem.Code != response.CodeValidationFailed || problem.Detail != details[lang] {
+				t.Errorf("problem = %s %q, want %s %q", problem.Code, problem.Detail, response.CodeValidationFailed, details[lang])
+			}
+
+			if !reflect.DeepEqual(problem.Errors, tt.want) {
+				got, _ := json.Marshal(problem.Errors)
+				want, _ := json.Marshal(tt.want)
+				t.Errorf("errors = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestStructValid(t *testing.T) {
+	req := validRequest()
+	if err := Struct(&req); err != nil {
+		t.Errorf("Struct() error = %v", err)
+	}
+}
+
+func TestRenderErrorOther(t *testing.T) {
+	w := httptest.NewRecorder()
+	RenderError(w, httptest.NewRequest(http.MethodPost, "/v1/songs", nil), errors.New("not a validation error"))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+}
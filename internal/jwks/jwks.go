@@ -0,0 +1,219 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval throttles the reloads caused by unknown key ids.
+const minRefreshInterval = time.Minute
+
+var ErrKeyNotFound = errors.New("key not found")
+
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// KeySet is a JSON Web Key Set loaded from a file or an http(s) URL. The keys
+// are cached for the refresh interval and reloaded earlier when a token refers
+// to an unknown key id, so that rotated keys are picked up.
+type KeySet struct {
+	source  string
+	refresh time.Duration
+	client  *http.Client
+	log     *slog.Logger
+
+	mu       sync.RWMutex
+	keys     map[string]crypto.PublicKey
+	loadedAt time.Time
+}
+
+func New(source string, refresh time.Duration, log *slog.Logger) *KeySet {
+	return &KeySet{
+		source:  source,
+		refresh: refresh,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		log:     log,
+	}
+}
+
+func (ks *KeySet) Key(kid string) (crypto.PublicKey, error) {
+	const fn = "jwks.KeySet.Key"
+
+	ks.mu.RLock()
+	key, ok := ks.keys[kid]
+	stale := time.Since(ks.loadedAt) > ks.refresh
+	throttled := time.Since(ks.loadedAt) < minRefreshInterval
+	ks.mu.RUnlock()
+
+	if ok && !stale {
+		return key, nil
+	}
+
+	if ok || !throttled {
+		if err := ks.Load(); err != nil {
+			if ok {
+				ks.log.Error("failed to refresh key set, using the cached keys",
+					slog.String("fn", fn),
+					slog.String("error", err.Error()),
+				)
+
+				return key, nil
+			}
+
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if key, ok = ks.keys[kid]; !ok {
+		return nil, fmt.Errorf("%s: %w: %q", fn, ErrKeyNotFound, kid)
+	}
+
+	return key, nil
+}
+
+func (ks *KeySet) Load() error {
+	const fn = "jwks.KeySet.Load"
+
+	raw, err := ks.read()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err = json.Unmarshal(raw, &set); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			ks.log.Warn("skipping unsupported key",
+				slog.String("fn", fn),
+				slog.String("kid", jwk.Kid),
+				slog.String("error", err.Error()),
+			)
+
+			continue
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.loadedAt = time.Now()
+	ks.mu.Unlock()
+
+	ks.log.Info("key set loaded",
+		slog.String("fn", fn),
+		slog.Int("keys", len(keys)),
+	)
+
+	return nil
+}
+
+func (ks *KeySet) read() ([]byte, error) {
+	if !strings.HasPrefix(ks.source, "http://") && !strings.HasPrefix(ks.source, "https://") {
+		return os.ReadFile(ks.source)
+	}
+
+	res, err := ks.client.Get(ks.source)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(res.Body, 1<<20))
+}
+
+func (jwk *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := decodeInt(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeInt(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+
+		x, err := decodeInt(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeInt(jwk.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,232 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var discard = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func encodeInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func rsaJWK(t *testing.T, kid, use string) (jsonWebKey, *rsa.PublicKey) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return jsonWebKey{
+		Kid: kid,
+		Kty: "RSA",
+		Use: use,
+		N:   encodeInt(key.N),
+		E:   encodeInt(big.NewInt(int64(key.E))),
+	}, &key.PublicKey
+}
+
+func ecJWK(t *testing.T, kid string) (jsonWebKey, *ecdsa.PublicKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return jsonWebKey{
+		Kid: kid,
+		Kty: "EC",
+		Crv: "P-256",
+		X:   encodeInt(key.X),
+		Y:   encodeInt(key.Y),
+	}, &key.PublicKey
+}
+
+func marshalSet(t *testing.T, keys ...jsonWebKey) []byte {
+	t.Helper()
+
+	raw, err := json.Marshal(map[string][]jsonWebKey{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestKeySetKey(t *testing.T) {
+	rsaKey, rsaPublic := rsaJWK(t, "rsa", "sig")
+	ecKey, ecPublic := ecJWK(t, "ec")
+	encKey, _ := rsaJWK(t, "enc", "enc")
+
+	offCurve := ecKey
+	offCurve.Kid = "off-curve"
+	offCurve.Y = encodeInt(big.NewInt(1))
+
+	unknownCurve := ecKey
+	unknownCurve.Kid = "unknown-curve"
+	unknownCurve.Crv = "P-192"
+
+	symmetric := jsonWebKey{Kid: "oct", Kty: "oct"}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, marshalSet(t, rsaKey, ecKey, encKey, offCurve, unknownCurve, symmetric), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ks := New(path, time.Hour, discard)
+
+	tests := []struct {
+		name    string
+		kid     string
+		want    interface{ Equal(crypto.PublicKey) bool }
+		wantErr error
+	}{
+		{name: "rsa signing key", kid: "rsa", want: rsaPublic},
+		{name: "ec signing key", kid: "ec", want: ecPublic},
+		{name: "encryption key skipped", kid: "enc", wantErr: ErrKeyNotFound},
+		{name: "point off the curve skipped", kid: "off-curve", wantErr: ErrKeyNotFound},
+		{name: "unsupported curve skipped", kid: "unknown-curve", wantErr: ErrKeyNotFound},
+		{name: "unsupported key type skipped", kid: "oct", wantErr: ErrKeyNotFound},
+		{name: "unknown key id", kid: "missing", wantErr: ErrKeyNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ks.Key(tt.kid)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Key(%q) error = %v, want %v", tt.kid, err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Key(%q) error = %v", tt.kid, err)
+			}
+
+			if !tt.want.Equal(key) {
+				t.Errorf("Key(%q) returned another key", tt.kid)
+			}
+		})
+	}
+}
+
+func TestKeySetReload(t *testing.T) {
+	oldKey, oldPublic := rsaJWK(t, "old", "")
+	newKey, newPublic := rsaJWK(t, "new", "")
+
+	tests := []struct {
+		name     string
+		refresh  time.Duration
+		loadedAt time.Duration
+		kid      string
+		want     *rsa.PublicKey
+		wantErr  error
+		wantLoad bool
+	}{
+		{
+			name:     "unknown key reloads the rotated set",
+			refresh:  time.Hour,
+			loadedAt: -2 * minRefreshInterval,
+			kid:      "new",
+			want:     newPublic,
+			wantLoad: true,
+		},
+		{
+			name:     "unknown key within the throttle",
+			refresh:  time.Hour,
+			loadedAt: 0,
+			kid:      "new",
+			wantErr:  ErrKeyNotFound,
+		},
+		{
+			name:     "cached key",
+			refresh:  time.Hour,
+			loadedAt: 0,
+			kid:      "old",
+			want:     oldPublic,
+		},
+		{
+			name:     "stale cached key reloads",
+			refresh:  time.Second,
+			loadedAt: -time.Minute,
+			kid:      "old",
+			want:     oldPublic,
+			wantLoad: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var loads atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				loads.Add(1)
+				_, _ = w.Write(marshalSet(t, oldKey, newKey))
+			}))
+			defer srv.Close()
+
+			ks := New(srv.URL, tt.refresh, discard)
+			// The set was loaded before the rotation.
+			ks.keys = map[string]crypto.PublicKey{"old": oldPublic}
+			ks.loadedAt = time.Now().Add(tt.loadedAt)
+
+			key, err := ks.Key(tt.kid)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Key(%q) error = %v, want %v", tt.kid, err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("Key(%q) error = %v", tt.kid, err)
+			} else if !tt.want.Equal(key) {
+				t.Errorf("Key(%q) returned another key", tt.kid)
+			}
+
+			if got := loads.Load() > 0; got != tt.wantLoad {
+				t.Errorf("reloaded = %t, want %t", got, tt.wantLoad)
+			}
+		})
+	}
+}
+
+func TestKeySetReloadFailure(t *testing.T) {
+	_, public := rsaJWK(t, "cached", "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ks := New(srv.URL, time.Second, discard)
+	ks.keys = map[string]crypto.PublicKey{"cached": public}
+	ks.loadedAt = time.Now().Add(-time.Hour)
+
+	key, err := ks.Key("cached")
+	if err != nil {
+		t.Fatalf("Key() error = %v, want the cached key", err)
+	}
+	if !public.Equal(key) {
+		t.Error("Key() returned another key")
+	}
+
+	if _, err = ks.Key("missing"); err == nil || errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Key() error = %v, want the load failure", err)
+	}
+}
@@ -0,0 +1,270 @@
+package lyrics
+
+import (
+	"embed"
+	"math"
+	"path"
+	"strings"
+	"unicode"
+)
+
+const (
+	// minLetters is the shortest text the language is told for.
+	minLetters = 12
+	// profileSize is the number of the most frequent trigrams kept per
+	// language.
+	profileSize = 400
+)
+
+// The samples are the same text written in each of the languages, so that the
+// profiles differ by the language rather than by the subject.
+//
+//go:embed samples/*.txt
+var samples embed.FS
+
+type script struct {
+	table *unicode.RangeTable
+	// language of the texts in the script, empty when the profiles of the
+	// script tell it.
+	language string
+}
+
+// scripts maps the scripts to the languages, the ones written in Latin and
+// Cyrillic are told apart by their trigrams. Han without kana is taken for
+// Chinese.
+var scripts = []script{
+	{unicode.Latin, ""},
+	{unicode.Cyrillic, ""},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Georgian, "ka"},
+	{unicode.Armenian, "hy"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+type profile struct {
+	language string
+	trigrams map[string]float64
+}
+
+// profiles are the trigram frequencies of the samples per script, see
+// scripts.
+var profiles = make(map[*unicode.RangeTable][]profile)
+
+func init() {
+	entries, err := samples.ReadDir("samples")
+	if err != nil {
+		panic(err)
+	}
+
+	for _, entry := range entries {
+		raw, err := samples.ReadFile(path.Join("samples", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+
+		text := string(raw)
+		table := dominantScript(letterCounts(text))
+
+		profiles[table] = append(profiles[table], profile{
+			language: strings.TrimSuffix(entry.Name(), ".txt"),
+			trigrams: trigrams(text, table, profileSize),
+		})
+	}
+}
+
+// DetectLanguage returns the ISO 639-1 code of the language of the text and
+// the confidence in it from 0 to 1, ok is false when the text has too few
+// letters to tell. The confidence is the share of the letters written in the
+// script of the language, times how far the best of the languages sharing the
+// script is ahead of the runner-up.
+func DetectLanguage(text string) (language string, confidence float64, ok bool) {
+	counts := letterCounts(text)
+
+	var letters int
+	for _, count := range counts {
+		letters += count
+	}
+	if letters < minLetters {
+		return "", 0, false
+	}
+
+	table := dominantScript(counts)
+	share := float64(counts[table]) / float64(letters)
+
+	for _, s := range scripts {
+		if s.table != table || s.language == "" {
+			continue
+		}
+
+		// Japanese mixes kana in.
+		if table == unicode.Han && counts[unicode.Hiragana]+counts[unicode.Katakana] > 0 {
+			return "ja", round(float64(counts[unicode.Han]+counts[unicode.Hiragana]+counts[unicode.Katakana]) / float64(letters)), true
+		}
+
+		return s.language, round(share), true
+	}
+
+	candidates := profiles[table]
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+
+	target := trigrams(text, table, 0)
+
+	var best, second float64
+	for _, p := range candidates {
+		score := cosine(target, p.trigrams)
+		if score > best {
+			language, best, second = p.language, score, best
+		} else if score > second {
+			second = score
+		}
+	}
+
+	if best == 0 {
+		return "", 0, false
+	}
+
+	return language, round(share * (best - second) / best), true
+}
+
+// letterCounts counts the letters of the text per script, the letters of the
+// other scripts are counted under nil.
+func letterCounts(text string) map[*unicode.RangeTable]int {
+	counts := make(map[*unicode.RangeTable]int)
+
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+
+		var table *unicode.RangeTable
+		for _, s := range scripts {
+			if unicode.Is(s.table, r) {
+				table = s.table
+				break
+			}
+		}
+		counts[table]++
+	}
+
+	return counts
+}
+
+// dominantScript returns the script most letters are written in, kana count
+// toward Han since Japanese mixes them.
+func dominantScript(counts map[*unicode.RangeTable]int) *unicode.RangeTable {
+	var (
+		table *unicode.RangeTable
+		max   int
+	)
+
+	for _, s := range scripts {
+		count := counts[s.table]
+		if s.table == unicode.Han {
+			count += counts[unicode.Hiragana] + counts[unicode.Katakana]
+		}
+
+		if count > max {
+			table, max = s.table, count
+		}
+	}
+
+	return table
+}
+
+// trigrams returns the relative frequencies of the trigrams of the words
+// written in the script, padded with a space on both sides. With limit set
+// only the limit most frequent ones are kept.
+func trigrams(text string, table *unicode.RangeTable, limit int) map[string]float64 {
+	counts := make(map[string]int)
+	var total int
+
+	for _, word := range Tokenize(text) {
+		runes := []rune(" " + word + " ")
+		if !unicode.Is(table, runes[1]) {
+			continue
+		}
+
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])]++
+			total++
+		}
+	}
+
+	if limit > 0 && len(counts) > limit {
+		counts = mostFrequent(counts, limit)
+
+		total = 0
+		for _, count := range counts {
+			total += count
+		}
+	}
+
+	frequencies := make(map[string]float64, len(counts))
+	for trigram, count := range counts {
+		frequencies[trigram] = float64(count) / float64(total)
+	}
+
+	return frequencies
+}
+
+func mostFrequent(counts map[string]int, limit int) map[string]int {
+	type entry struct {
+		trigram string
+		count   int
+	}
+
+	entries := make([]entry, 0, len(counts))
+	for trigram, count := range counts {
+		entries = append(entries, entry{trigram, count})
+	}
+
+	// Ties are broken by the trigram, so that the profiles are the same on
+	// every start.
+	for i := 1; i < len(entries); i++ {
+		for j := i; j > 0; j-- {
+			a, b := entries[j-1], entries[j]
+			if a.count > b.count || a.count == b.count && a.trigram < b.trigram {
+				break
+			}
+			entries[j-1], entries[j] = b, a
+		}
+	}
+
+	top := make(map[string]int, limit)
+	for _, e := range entries[:limit] {
+		top[e.trigram] = e.count
+	}
+
+	return top
+}
+
+func cosine(a, b map[string]float64) float64 {
+	var dot, normA, normB float64
+
+	for trigram, x := range a {
+		dot += x * b[trigram]
+		normA += x * x
+	}
+	for _, y := range b {
+		normB += y * y
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / math.Sqrt(normA*normB)
+}
+
+func round(confidence float64) float64 {
+	return math.Round(confidence*1000) / 1000
+}
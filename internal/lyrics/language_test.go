@@ -0,0 +1,110 @@
+package lyrics
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "english",
+			text:   "I want to break free from your lies, you're so self satisfied I don't need you",
+			want:   "en",
+			wantOK: true,
+		},
+		{
+			name:   "russian",
+			text:   "Группа крови на рукаве, мой порядковый номер на рукаве, пожелай мне удачи в бою",
+			want:   "ru",
+			wantOK: true,
+		},
+		{
+			name:   "ukrainian",
+			text:   "Ой у лузі червона калина похилилася, чогось наша славна Україна зажурилася",
+			want:   "uk",
+			wantOK: true,
+		},
+		{
+			name:   "german",
+			text:   "Ich will deine Stimme hören, ich will dein Herz sehen und nicht mehr gehen",
+			want:   "de",
+			wantOK: true,
+		},
+		{
+			name:   "greek by the script",
+			text:   "Σ' αγαπώ σαν τον ήλιο που ανατέλλει κάθε πρωί",
+			want:   "el",
+			wantOK: true,
+		},
+		{
+			name:   "japanese with kana",
+			text:   "上を向いて歩こう涙がこぼれないように思い出す春の日",
+			want:   "ja",
+			wantOK: true,
+		},
+		{
+			name:   "chinese without kana",
+			text:   "月亮代表我的心你问我爱你有多深我爱你有几分",
+			want:   "zh",
+			wantOK: true,
+		},
+		{
+			name: "too few letters",
+			text: "la la la 123",
+		},
+		{
+			name: "no letters",
+			text: "1234567890 !!! ???",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			language, confidence, ok := DetectLanguage(tt.text)
+
+			if ok != tt.wantOK {
+				t.Fatalf("DetectLanguage(%q) ok = %t, want %t", tt.text, ok, tt.wantOK)
+			}
+
+			if language != tt.want {
+				t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, language, tt.want)
+			}
+
+			if ok && (confidence <= 0 || confidence > 1) {
+				t.Errorf("DetectLanguage(%q) confidence = %v, want it in (0, 1]", tt.text, confidence)
+			}
+		})
+	}
+}
+
+func TestDetectLanguageMixedScripts(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+		// less reports whether the confidence must be below the one of
+		// the text written in the single script.
+		less bool
+	}{
+		{name: "single script", text: "Σ' αγαπώ σαν τον ήλιο που ανατέλλει"},
+		{name: "latin mixed in", text: "Σ' αγαπώ σαν τον ήλιο που ανατέλλει baby", less: true},
+	}
+
+	_, single, _ := DetectLanguage(tests[0].text)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			language, confidence, ok := DetectLanguage(tt.text)
+			if !ok || language != "el" {
+				t.Fatalf("DetectLanguage(%q) = %q, %t, want el", tt.text, language, ok)
+			}
+
+			if got := confidence < single; got != tt.less {
+				t.Errorf("confidence %v below %v = %t, want %t", confidence, single, got, tt.less)
+			}
+		})
+	}
+}
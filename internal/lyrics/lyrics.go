@@ -0,0 +1,122 @@
+package lyrics
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+)
+
+// Analysis is the vocabulary of a text: Words counts every word, Terms how
+// many times each distinct one occurs, stopwords included.
+type Analysis struct {
+	Words int
+	Terms map[string]int
+}
+
+// UniqueWords returns the number of distinct words.
+func (a *Analysis) UniqueWords() int {
+	return len(a.Terms)
+}
+
+// LexicalDiversity returns the share of the distinct words among all of them,
+// zero for a text without words.
+func (a *Analysis) LexicalDiversity() float64 {
+	return Diversity(a.Words, len(a.Terms))
+}
+
+// Diversity returns the type-token ratio of unique words over words.
+func Diversity(words, unique int) float64 {
+	if words == 0 {
+		return 0
+	}
+	return float64(unique) / float64(words)
+}
+
+// Analyze tokenizes the text and counts its words.
+func Analyze(text string) *Analysis {
+	analysis := &Analysis{Terms: make(map[string]int)}
+
+	for _, word := range Tokenize(text) {
+		analysis.Words++
+		analysis.Terms[word]++
+	}
+
+	return analysis
+}
+
+// Tokenize splits the text into lowercase words: runs of letters, marks and
+// digits with at least one letter. Apostrophes and hyphens are kept between
+// letters, so "don't" and "кто-то" are single words, and ё is folded into е.
+func Tokenize(text string) []string {
+	var words []string
+
+	scan([]rune(norm.NFC.String(text)), func(word string, _, _ int) {
+		words = append(words, word)
+	})
+
+	return words
+}
+
+// scan calls fn with every word of the runes, see Tokenize, along with the
+// bounds of its runes.
+func scan(runes []rune, fn func(word string, start, end int)) {
+	var (
+		word      strings.Builder
+		start     int
+		hasLetter bool
+	)
+
+	flush := func(end int) {
+		if hasLetter {
+			fn(word.String(), start, end)
+		}
+		word.Reset()
+		hasLetter = false
+	}
+
+	for i, r := range runes {
+		if word.Len() == 0 {
+			start = i
+		}
+
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+			word.WriteRune(fold(r))
+		case unicode.IsDigit(r) || unicode.Is(unicode.Mn, r):
+			word.WriteRune(r)
+		case isJoiner(r) && word.Len() > 0 && i+1 < len(runes) && unicode.IsLetter(runes[i+1]):
+			if r == '-' || r == '‐' {
+				word.WriteRune('-')
+			} else {
+				word.WriteRune('\'')
+			}
+		default:
+			flush(i)
+		}
+	}
+	flush(len(runes))
+}
+
+func isJoiner(r rune) bool {
+	switch r {
+	case '\'', '’', 'ʼ', '-', '‐':
+		return true
+	}
+	return false
+}
+
+func fold(r rune) rune {
+	r = unicode.ToLower(r)
+	if r == 'ё' {
+		return 'е'
+	}
+	return r
+}
+
+// IsStopword reports whether the word, as returned by Tokenize, is a Russian
+// or English stopword.
+func IsStopword(word string) bool {
+	_, ok := stopwords[word]
+	return ok
+}
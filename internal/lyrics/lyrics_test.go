@@ -0,0 +1,86 @@
+package lyrics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "empty", text: "", want: nil},
+		{name: "lower-cased", text: "Hello, World!", want: []string{"hello", "world"}},
+		{name: "apostrophes kept between letters", text: "Don’t stop 'til", want: []string{"don't", "stop", "til"}},
+		{name: "hyphens kept between letters", text: "кто-то - где-то", want: []string{"кто-то", "где-то"}},
+		{name: "trailing hyphen dropped", text: "rock- n-roll", want: []string{"rock", "n-roll"}},
+		{name: "yo folded into ye", text: "Ёлка ещё", want: []string{"елка", "еще"}},
+		{name: "digits with letters", text: "Blink 182 mp3", want: []string{"blink", "mp3"}},
+		{name: "decomposed letters composed", text: "cafe\u0301", want: []string{"caf\u00e9"}},
+		{name: "lines", text: "one\ntwo\r\nthree", want: []string{"one", "two", "three"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		wantWords     int
+		wantUnique    int
+		wantDiversity float64
+	}{
+		{name: "empty", text: ""},
+		{name: "all distinct", text: "one two three", wantWords: 3, wantUnique: 3, wantDiversity: 1},
+		{name: "repeated up to case", text: "La la LA land", wantWords: 4, wantUnique: 2, wantDiversity: 0.5},
+		{name: "stopwords counted", text: "the the the", wantWords: 3, wantUnique: 1, wantDiversity: 1.0 / 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := Analyze(tt.text)
+
+			if analysis.Words != tt.wantWords {
+				t.Errorf("Words = %d, want %d", analysis.Words, tt.wantWords)
+			}
+
+			if got := analysis.UniqueWords(); got != tt.wantUnique {
+				t.Errorf("UniqueWords() = %d, want %d", got, tt.wantUnique)
+			}
+
+			if got := analysis.LexicalDiversity(); math.Abs(got-tt.wantDiversity) > 1e-9 {
+				t.Errorf("LexicalDiversity() = %v, want %v", got, tt.wantDiversity)
+			}
+		})
+	}
+}
+
+func TestIsStopword(t *testing.T) {
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{word: "the", want: true},
+		{word: "и", want: true},
+		{word: "love", want: false},
+		{word: "любовь", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := IsStopword(tt.word); got != tt.want {
+				t.Errorf("IsStopword(%q) = %t, want %t", tt.word, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package lyrics
+
+import "strings"
+
+// The lists are folded like the tokens, so ё is spelled е. The English one
+// includes the contractions and the interjections common in lyrics.
+const (
+	englishStopwords = `
+a about above after again against all am an and any are aren't as at
+be because been before being below between both but by
+can can't cannot could couldn't
+did didn't do does doesn't doing don't down during
+each few for from further
+had hadn't has hasn't have haven't having he he'd he'll he's her here here's hers herself him himself his how how's
+i i'd i'll i'm i've if in into is isn't it it's its itself
+let's me more most mustn't my myself
+no nor not of off on once only or other ought our ours ourselves out over own
+same shan't she she'd she'll she's should shouldn't so some such
+than that that's the their theirs them themselves then there there's these they they'd they'll they're they've this those through to too
+under until up very
+was wasn't we we'd we'll we're we've were weren't what what's when when's where where's which while who who's whom why why's with won't would wouldn't
+you you'd you'll you're you've your yours yourself yourselves
+gonna wanna gotta ain't cause
+oh ooh ah uh yeah yeah-yeah hey la na da whoa
+`
+
+	russianStopwords = `
+а без более бы был была были было быть в вам вас весь во вот все всего всех вы
+где да даже для до его ее ей ему если есть еще же за здесь и из или им их
+к как ко когда кто ли либо мне может мы на надо наш не него нее нет ни них но ну
+о об однако он она они оно от очень по под при с со так также такой там те тем то того тоже той только том ты
+у уже хотя чего чей чем что чтобы чье чья эта эти это я
+меня мой моя мое мои тебя тебе твой твоя твое твои себя себе свой своя свое свои
+нам нами вами ним ней нем ими
+этот этого этой этом эту тот та тут
+вдруг ведь вон вот-вот где-то когда-то кто-то что-то как-то
+будет будто сам сама само сами чтоб раз два
+ах ох эх ой ля
+`
+)
+
+var stopwords = make(map[string]struct{})
+
+func init() {
+	for _, list := range []string{englishStopwords, russianStopwords} {
+		for _, word := range strings.Fields(list) {
+			stopwords[word] = struct{}{}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package lyrics
+
+import (
+	"bufio"
+	"golang.org/x/text/unicode/norm"
+	"io"
+	"os"
+	"strings"
+)
+
+// Wordlist matches the words of a text, see Tokenize, against a list of words
+// in any language. An entry ending with * matches the words starting with it,
+// which covers the inflected forms.
+type Wordlist struct {
+	words    map[string]struct{}
+	prefixes []string
+}
+
+// LoadWordlist reads the wordlist from the file, an empty path gives an empty
+// list.
+func LoadWordlist(path string) (*Wordlist, error) {
+	if path == "" {
+		return ParseWordlist(strings.NewReader(""))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ParseWordlist(f)
+}
+
+// ParseWordlist reads an entry per line, the blank lines and the ones starting
+// with # are skipped.
+func ParseWordlist(r io.Reader) (*Wordlist, error) {
+	list := &Wordlist{words: make(map[string]struct{})}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		entry = strings.Map(fold, norm.NFC.String(entry))
+
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+			list.prefixes = append(list.prefixes, prefix)
+		} else {
+			list.words[entry] = struct{}{}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// Len returns the number of the entries.
+func (wl *Wordlist) Len() int {
+	return len(wl.words) + len(wl.prefixes)
+}
+
+// Match reports whether the word, as returned by Tokenize, is listed.
+func (wl *Wordlist) Match(word string) bool {
+	if _, ok := wl.words[word]; ok {
+		return true
+	}
+
+	for _, prefix := range wl.prefixes {
+		if strings.HasPrefix(word, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Find returns the listed words of the text in their order, each once.
+func (wl *Wordlist) Find(text string) []string {
+	var found []string
+	seen := make(map[string]bool)
+
+	for _, word := range Tokenize(text) {
+		if !seen[word] && wl.Match(word) {
+			found = append(found, word)
+			seen[word] = true
+		}
+	}
+
+	return found
+}
+
+// Mask replaces every letter of the listed words of the text with an
+// asterisk. The text comes back NFC-normalized.
+func (wl *Wordlist) Mask(text string) string {
+	runes := []rune(norm.NFC.String(text))
+
+	scan(runes, func(word string, start, end int) {
+		if !wl.Match(word) {
+			return
+		}
+
+		for i := start; i < end; i++ {
+			if !isJoiner(runes[i]) {
+				runes[i] = '*'
+			}
+		}
+	})
+
+	return string(runes)
+}
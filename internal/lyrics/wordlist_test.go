@@ -0,0 +1,119 @@
+package lyrics
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testWordlist = `
+# A comment, then a blank line.
+
+Damn
+хрен*
+  ЁЖ  
+`
+
+func parseTestWordlist(t *testing.T) *Wordlist {
+	t.Helper()
+
+	list, err := ParseWordlist(strings.NewReader(testWordlist))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return list
+}
+
+func TestParseWordlist(t *testing.T) {
+	list := parseTestWordlist(t)
+
+	if got := list.Len(); got != 3 {
+		t.Errorf("Len() = %d, want 3", got)
+	}
+}
+
+func TestWordlistMatch(t *testing.T) {
+	list := parseTestWordlist(t)
+
+	tests := []struct {
+		word string
+		want bool
+	}{
+		{word: "damn", want: true},
+		{word: "damned", want: false},
+		{word: "хрен", want: true},
+		{word: "хреновый", want: true},
+		{word: "хре", want: false},
+		{word: "еж", want: true},
+		{word: "love", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			if got := list.Match(tt.word); got != tt.want {
+				t.Errorf("Match(%q) = %t, want %t", tt.word, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordlistFind(t *testing.T) {
+	list := parseTestWordlist(t)
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "clean", text: "All you need is love", want: nil},
+		{name: "in order, each once", text: "Хреново, damn! DAMN, хрен", want: []string{"хреново", "damn", "хрен"}},
+		{name: "yo folded", text: "Ёж", want: []string{"еж"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := list.Find(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordlistMask(t *testing.T) {
+	list := parseTestWordlist(t)
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "clean", text: "All you need is love", want: "All you need is love"},
+		{name: "case kept elsewhere", text: "Oh Damn, it's fine", want: "Oh ****, it's fine"},
+		{name: "prefix", text: "Хреново тут", want: "******* тут"},
+		{name: "joiners kept", text: "хрен-то", want: "****-**"},
+		{name: "part of another word", text: "damnation", want: "damnation"},
+		{name: "decomposed letters composed", text: "\u0415\u0308ж", want: "**"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := list.Mask(tt.text); got != tt.want {
+				t.Errorf("Mask(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadWordlist(t *testing.T) {
+	list, err := LoadWordlist("")
+	if err != nil {
+		t.Fatalf("LoadWordlist(\"\") error = %v", err)
+	}
+	if got := list.Len(); got != 0 {
+		t.Errorf("Len() = %d, want 0", got)
+	}
+
+	if _, err = LoadWordlist("testdata/missing.txt"); err == nil {
+		t.Error("LoadWordlist() of a missing file returned no error")
+	}
+}
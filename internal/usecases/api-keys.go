@@ -0,0 +1,214 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/auth"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyPrefix       = "sk_"
+	apiKeyPrefixLength = 8
+)
+
+type ApiKeysRepo interface {
+	Create(name, prefix, hash string, scopes []string, tenant *string, expiresAt *time.Time) (*entities.ApiKey, error)
+	GetByHash(hash string) (*entities.ApiKey, error)
+	GetList(tenant string, withUnbound bool, pagination *pagination.Pagination) (*[]entities.ApiKey, error)
+	Revoke(id int, tenant string, withUnbound bool) error
+	Touch(id int) error
+}
+
+type ApiKeys struct {
+	repo ApiKeysRepo
+	log  *slog.Logger
+}
+
+func NewApiKeys(repo ApiKeysRepo, log *slog.Logger) *ApiKeys {
+	return &ApiKeys{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// Create issues the key. Callers bound to a tenant issue keys of their own
+// tenant, the others keys of the tenant of the request or, without tenantId,
+// keys bound to no tenant.
+func (ak *ApiKeys) Create(ctx context.Context, req *dto.CreateApiKeyRequest) (*dto.CreateApiKeyResponse, error) {
+	const fn = "usecases.ApiKeys.Create"
+
+	defer ak.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", req.Name, slog.Any("scopes", req.Scopes))
+
+	// A key is granted no scope its issuer lacks.
+	principal := auth.Get(ctx)
+	for _, scope := range req.Scopes {
+		if !principal.HasScope(scope) {
+			return nil, fmt.Errorf("%s: %w: the caller lacks the %s scope", fn, ErrForbidden, scope)
+		}
+	}
+
+	keyTenant := req.TenantID
+	if principal != nil && principal.Tenant != "" {
+		if keyTenant != nil && *keyTenant != principal.Tenant {
+			return nil, fmt.Errorf("%s: %w: %s is bound to tenant %s", fn, ErrForbidden, principal.Subject, principal.Tenant)
+		}
+		keyTenant = &principal.Tenant
+	} else if keyTenant != nil && *keyTenant != tenant.Get(ctx) {
+		return nil, fmt.Errorf("%s: %w: the request is scoped to tenant %s", fn, ErrForbidden, tenant.Get(ctx))
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	key := apiKeyPrefix + hex.EncodeToString(buf)
+
+	apiKey, err := ak.repo.Create(req.Name, displayPrefix(key), hashToken(key), req.Scopes, keyTenant, req.ExpiresAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation" {
+			return nil, fmt.Errorf("%s: %w", fn, ErrUnknownTenant)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &dto.CreateApiKeyResponse{
+		ApiKeyResponse: *dto.NewApiKeyResponse(apiKey),
+		Key:            key,
+	}, nil
+}
+
+// Bootstrap makes sure the configured key exists with every scope, so that
+// the first keys can be issued through the API. It returns the key subject.
+func (ak *ApiKeys) Bootstrap(key string) (string, error) {
+	const fn = "usecases.ApiKeys.Bootstrap"
+
+	apiKey, err := ak.repo.Create("bootstrap", displayPrefix(key), hashToken(key), entities.Scopes, nil, nil)
+	if errors.Is(err, sql.ErrNoRows) {
+		apiKey, err = ak.repo.GetByHash(hashToken(key))
+	}
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return apiKeySubject(apiKey.ID), nil
+}
+
+// GetList lists the keys of the tenant of the request, along with the keys
+// bound to no tenant for the callers bound to none either.
+func (ak *ApiKeys) GetList(ctx context.Context, pagination *pagination.Pagination) ([]*dto.ApiKeyResponse, error) {
+	const fn = "usecases.ApiKeys.GetList"
+
+	defer ak.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("pagination", pagination))
+
+	apiKeys, err := ak.repo.GetList(tenant.Get(ctx), isUnbound(ctx), pagination)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if len(*apiKeys) == 0 {
+		return nil, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+	}
+
+	return dto.NewApiKeysListResponse(apiKeys), nil
+}
+
+// Revoke revokes the key among the ones GetList lists.
+func (ak *ApiKeys) Revoke(ctx context.Context, id int) error {
+	const fn = "usecases.ApiKeys.Revoke"
+
+	defer ak.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("id", id))
+
+	err := ak.repo.Revoke(id, tenant.Get(ctx), isUnbound(ctx))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (ak *ApiKeys) Authenticate(key string) (*entities.Principal, error) {
+	const fn = "usecases.ApiKeys.Authenticate"
+
+	apiKey, err := ak.repo.GetByHash(hashToken(key))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", fn, ErrUnauthorized)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && apiKey.ExpiresAt.Before(time.Now())) {
+		return nil, fmt.Errorf("%s: %w", fn, ErrUnauthorized)
+	}
+
+	if err = ak.repo.Touch(apiKey.ID); err != nil {
+		ak.log.Error("failed to track api key usage",
+			slog.String("fn", fn),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return &entities.Principal{
+		Subject: apiKeySubject(apiKey.ID),
+		KeyID:   apiKey.ID,
+		Tenant:  tenantOf(apiKey),
+		Scopes:  apiKey.Scopes,
+	}, nil
+}
+
+// isUnbound tells whether the principal of ctx is bound to no tenant.
+func isUnbound(ctx context.Context) bool {
+	principal := auth.Get(ctx)
+	return principal != nil && principal.Tenant == ""
+}
+
+func tenantOf(apiKey *entities.ApiKey) string {
+	if apiKey.TenantID == nil {
+		return ""
+	}
+	return *apiKey.TenantID
+}
+
+func apiKeySubject(id int) string {
+	return "api-key:" + strconv.Itoa(id)
+}
+
+func hashToken(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func displayPrefix(key string) string {
+	prefix := strings.TrimPrefix(key, apiKeyPrefix)
+	if len(prefix) > apiKeyPrefixLength {
+		prefix = prefix[:apiKeyPrefixLength]
+	}
+	return apiKeyPrefix + prefix
+}
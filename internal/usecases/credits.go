@@ -0,0 +1,246 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
+)
+
+type CreditsRepo interface {
+	SongCreditsRepo
+	CreatePerson(tenant, name string) (*entities.Person, error)
+	EnsurePerson(tenant, name string) (int, error)
+	GetPeople(tenant, prefix string, pagination *pagination.Pagination) (*[]entities.Person, error)
+	UpdatePerson(tenant string, id int, name string) error
+	DeletePerson(tenant string, id int) error
+	AddCredit(songID, personID int, role string) (*entities.SongCredit, error)
+	RemoveCredit(tenant string, songID int, name, role string) error
+}
+
+type Credits struct {
+	repo  CreditsRepo
+	songs SongLibraryRepo
+	authz Authorizer
+	log   *slog.Logger
+}
+
+func NewCredits(repo CreditsRepo, songs SongLibraryRepo, authz Authorizer, log *slog.Logger) *Credits {
+	return &Credits{
+		repo:  repo,
+		songs: songs,
+		authz: authz,
+		log:   log,
+	}
+}
+
+func (cr *Credits) CreatePerson(ctx context.Context, req *dto.PersonRequest) (*dto.PersonResponse, error) {
+	const fn = "usecases.Credits.CreatePerson"
+
+	defer cr.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	if err := cr.authz.Authorize(ctx, entities.PermSongsUpdate); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	person, err := cr.repo.CreatePerson(tenant.Get(ctx), req.Name)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return nil, fmt.Errorf("%s: %w", fn, ErrAlreadyExists)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewPersonResponse(person), nil
+}
+
+func (cr *Credits) GetPeople(ctx context.Context, prefix string, pagination *pagination.Pagination) ([]*dto.PersonResponse, error) {
+	const fn = "usecases.Credits.GetPeople"
+
+	defer cr.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("prefix", prefix), slog.Any("pagination", pagination))
+
+	if err := cr.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	people, err := cr.repo.GetPeople(tenant.Get(ctx), prefix, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewPeopleListResponse(people), nil
+}
+
+// UpdatePerson renames the person on every song they are credited on.
+func (cr *Credits) UpdatePerson(ctx context.Context, id int, req *dto.PersonRequest) error {
+	const fn = "usecases.Credits.UpdatePerson"
+
+	defer cr.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("id", id), slog.Any("request", req))
+
+	if err := cr.authz.Authorize(ctx, entities.PermSongsUpdate); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err := cr.repo.UpdatePerson(tenant.Get(ctx), id, req.Name)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return fmt.Errorf("%s: %w", fn, ErrAlreadyExists)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// DeletePerson removes the person, the ones still credited on a song are kept
+// and reported with ErrInUse.
+func (cr *Credits) DeletePerson(ctx context.Context, id int) error {
+	const fn = "usecases.Credits.DeletePerson"
+
+	defer cr.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("id", id))
+
+	if err := cr.authz.Authorize(ctx, entities.PermSongsUpdate); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err := cr.repo.DeletePerson(tenant.Get(ctx), id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation" {
+			return fmt.Errorf("%s: %w", fn, ErrInUse)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (cr *Credits) GetSongCredits(ctx context.Context, group, song string) ([]*dto.CreditResponse, error) {
+	const fn = "usecases.Credits.GetSongCredits"
+
+	defer cr.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", group, song)
+
+	if err := cr.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	songID, err := cr.songID(ctx, group, song)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	credits, err := cr.repo.GetBySongs([]int{songID})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewCreditsListResponse(credits), nil
+}
+
+// AddCredit credits the person on the song under the role, the person is
+// created when the tenant has no one by the name yet.
+func (cr *Credits) AddCredit(ctx context.Context, req *dto.AddSongCreditRequest) (*dto.CreditResponse, error) {
+	const fn = "usecases.Credits.AddCredit"
+
+	defer cr.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	if err := cr.authz.Authorize(ctx, entities.PermSongsUpdate); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	songID, err := cr.songID(ctx, req.Group, req.Song)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	personID, err := cr.repo.EnsurePerson(tenant.Get(ctx), req.Person)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	credit, err := cr.repo.AddCredit(songID, personID, req.Role)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return nil, fmt.Errorf("%s: %w", fn, ErrAlreadyExists)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	credit.Name = req.Person
+
+	return dto.NewCreditResponse(credit), nil
+}
+
+// RemoveCredit removes the person from the song, from every role when the
+// role is empty. The person stays in the tenant.
+func (cr *Credits) RemoveCredit(ctx context.Context, req *dto.RemoveSongCreditRequest) error {
+	const fn = "usecases.Credits.RemoveCredit"
+
+	defer cr.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	if err := cr.authz.Authorize(ctx, entities.PermSongsUpdate); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	songID, err := cr.songID(ctx, req.Group, req.Song)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err = cr.repo.RemoveCredit(tenant.Get(ctx), songID, req.Person, req.Role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (cr *Credits) songID(ctx context.Context, group, song string) (int, error) {
+	res, err := cr.songs.Get(tenant.Get(ctx), group, song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrNoRowsAffected
+		}
+		return 0, err
+	}
+
+	return res.ID, nil
+}
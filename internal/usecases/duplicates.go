@@ -0,0 +1,314 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"effective-mobile-test/internal/lyrics"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	defaultDuplicateScore = 0.8
+	defaultDuplicateLimit = 50
+
+	// The weights of the signals, the missing ones are left out of the score.
+	namesWeight  = 0.5
+	lyricsWeight = 0.3
+	linkWeight   = 0.2
+)
+
+type DuplicatesRepo interface {
+	GetCandidates(tenant string) (*[]entities.Song, error)
+	Merge(survivorID, loserID int, fields map[string]interface{}) error
+}
+
+type Duplicates struct {
+	repo     DuplicatesRepo
+	songs    SongLibraryRepo
+	authz    Authorizer
+	explicit *lyrics.Wordlist
+	log      *slog.Logger
+}
+
+func NewDuplicates(repo DuplicatesRepo, songs SongLibraryRepo, authz Authorizer, explicit *lyrics.Wordlist, log *slog.Logger) *Duplicates {
+	return &Duplicates{
+		repo:     repo,
+		songs:    songs,
+		authz:    authz,
+		explicit: explicit,
+		log:      log,
+	}
+}
+
+// Find scores the pairs of the songs sharing the group, up to case, spacing
+// and punctuation, or the link, and returns the ones scoring at least
+// minScore, the likeliest first.
+func (dp *Duplicates) Find(ctx context.Context, minScore *float64, pagination *pagination.Pagination) ([]*dto.DuplicatePairResponse, error) {
+	const fn = "usecases.Duplicates.Find"
+
+	defer dp.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("minScore", minScore), slog.Any("pagination", pagination))
+
+	if err := dp.authz.Authorize(ctx, entities.PermSongsUpdate); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	threshold := defaultDuplicateScore
+	if minScore != nil {
+		threshold = *minScore
+	}
+
+	songs, err := dp.repo.GetCandidates(tenant.Get(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	pairs := findDuplicates(*songs, threshold)
+
+	if pagination.Limit <= 0 {
+		pagination.Limit = defaultDuplicateLimit
+	}
+	if pagination.Offset < 0 || pagination.Offset > len(pairs) {
+		pagination.Offset = len(pairs)
+	}
+	pairs = pairs[pagination.Offset:min(pagination.Offset+pagination.Limit, len(pairs))]
+
+	return dto.NewDuplicatesResponse(pairs), nil
+}
+
+// Merge folds the loser into the survivor. The fields are taken from the
+// song picked in the request, the loser's favorites, ratings, playlist
+// entries, plays, tags, credits and relations move over, and the loser's name
+// and id keep resolving to the survivor.
+func (dp *Duplicates) Merge(ctx context.Context, req *dto.MergeSongsRequest) error {
+	const fn = "usecases.Duplicates.Merge"
+
+	defer dp.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	if err := dp.authz.Authorize(ctx, entities.PermSongsUpdate); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := dp.authz.Authorize(ctx, entities.PermSongsDelete); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	survivor, err := dp.resolve(tenant.Get(ctx), &req.Survivor)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	loser, err := dp.resolve(tenant.Get(ctx), &req.Loser)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	// The loser's name may already redirect to the survivor.
+	if survivor.ID == loser.ID {
+		return fmt.Errorf("%s: %w", fn, ErrSameSong)
+	}
+
+	fields := make(map[string]interface{})
+	for column, field := range map[string]struct {
+		choice          string
+		survivor, loser *string
+	}{
+		"release_date": {req.Fields.ReleaseDate, survivor.ReleaseDate, loser.ReleaseDate},
+		"link":         {req.Fields.Link, survivor.Link, loser.Link},
+		"text":         {req.Fields.Text, survivor.Text, loser.Text},
+	} {
+		switch {
+		case field.choice == entities.MergeLoser:
+			fields[column] = field.loser
+		case field.choice == "" && field.survivor == nil && field.loser != nil:
+			fields[column] = field.loser
+		}
+	}
+
+	withLanguage(fields)
+	withExplicit(fields, dp.explicit)
+
+	if err = dp.repo.Merge(survivor.ID, loser.ID, fields); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// findDuplicates pairs up the songs within the blocks of the same normalized
+// group or link, each pair is scored once.
+func findDuplicates(songs []entities.Song, threshold float64) []entities.DuplicatePair {
+	blocks := make(map[string][]int)
+	words := make([]map[string]bool, len(songs))
+	for i, song := range songs {
+		blocks["group:"+normalizeName(song.Group)] = append(blocks["group:"+normalizeName(song.Group)], i)
+		if song.Link != nil && *song.Link != "" {
+			blocks["link:"+*song.Link] = append(blocks["link:"+*song.Link], i)
+		}
+		if song.Text != nil {
+			words[i] = wordSet(*song.Text)
+		}
+	}
+
+	var pairs []entities.DuplicatePair
+	seen := make(map[[2]int]bool)
+	for _, block := range blocks {
+		for x := 0; x < len(block); x++ {
+			for y := x + 1; y < len(block); y++ {
+				i, j := block[x], block[y]
+				if seen[[2]int{i, j}] {
+					continue
+				}
+				seen[[2]int{i, j}] = true
+
+				pair := scorePair(&songs[i], &songs[j], words[i], words[j])
+				if pair.Score >= threshold {
+					pairs = append(pairs, pair)
+				}
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(a, b int) bool {
+		if pairs[a].Score != pairs[b].Score {
+			return pairs[a].Score > pairs[b].Score
+		}
+		return pairs[a].First.ID < pairs[b].First.ID
+	})
+
+	return pairs
+}
+
+func scorePair(first, second *entities.Song, firstWords, secondWords map[string]bool) entities.DuplicatePair {
+	pair := entities.DuplicatePair{
+		First:  *first,
+		Second: *second,
+		Names: (similarity(normalizeName(first.Group), normalizeName(second.Group)) +
+			similarity(normalizeName(first.Song), normalizeName(second.Song))) / 2,
+	}
+
+	score, weight := pair.Names*namesWeight, namesWeight
+
+	if firstWords != nil && secondWords != nil {
+		lyrics := jaccard(firstWords, secondWords)
+		pair.Lyrics = &lyrics
+		score, weight = score+lyrics*lyricsWeight, weight+lyricsWeight
+	}
+
+	if first.Link != nil && second.Link != nil {
+		var link float64
+		if strings.EqualFold(strings.TrimSpace(*first.Link), strings.TrimSpace(*second.Link)) {
+			link = 1
+		}
+		pair.Link = &link
+		score, weight = score+link*linkWeight, weight+linkWeight
+	}
+
+	pair.Score = score / weight
+
+	return pair
+}
+
+// normalizeName keeps only the lower-cased letters and digits of the name.
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// similarity is 1 minus the edit distance between a and b relative to the
+// longer of them.
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return 1 - float64(prev[len(rb)])/float64(max(len(ra), len(rb)))
+}
+
+func wordSet(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	}) {
+		words[word] = true
+	}
+	return words
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+
+	common := 0
+	for word := range a {
+		if b[word] {
+			common++
+		}
+	}
+
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+// resolve returns the song of the tenant the ref refers to, by its id when
+// set.
+func (dp *Duplicates) resolve(tenant string, ref *dto.MergeSongRef) (*entities.Song, error) {
+	var song *entities.Song
+	var err error
+
+	if ref.ID != nil {
+		song, err = dp.songs.GetByID(*ref.ID)
+	} else {
+		song, err = dp.songs.Get(tenant, ref.Group, ref.Song)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRowsAffected
+		}
+		return nil, err
+	}
+
+	if song.TenantID != tenant {
+		return nil, ErrNoRowsAffected
+	}
+
+	return song, nil
+}
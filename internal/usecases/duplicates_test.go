@@ -0,0 +1,209 @@
+package usecases
+
+import (
+	"effective-mobile-test/internal/entities"
+	"math"
+	"testing"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "lower-cased", in: "Muse", want: "muse"},
+		{name: "punctuation and spaces dropped", in: "AC/DC - Live!", want: "acdclive"},
+		{name: "digits kept", in: "Blink-182", want: "blink182"},
+		{name: "non-latin letters kept", in: "Кино", want: "кино"},
+		{name: "empty", in: " - ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeName(tt.in); got != tt.want {
+				t.Errorf("normalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{name: "same", a: "muse", b: "muse", want: 1},
+		{name: "both empty", a: "", b: "", want: 1},
+		{name: "one empty", a: "muse", b: "", want: 0},
+		{name: "one substitution", a: "muse", b: "mase", want: 0.75},
+		{name: "one insertion", a: "muse", b: "muses", want: 0.8},
+		{name: "runes rather than bytes", a: "кино", b: "кина", want: 0.75},
+		{name: "nothing in common", a: "abc", b: "xyz", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := similarity(tt.a, tt.b); !approx(got, tt.want) {
+				t.Errorf("similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJaccard(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{name: "same words", a: "Hello, hello world", b: "world HELLO", want: 1},
+		{name: "half shared", a: "a b c", b: "b c d", want: 0.5},
+		{name: "nothing shared", a: "a b", b: "c d", want: 0},
+		{name: "both empty", a: "", b: "", want: 1},
+		{name: "apostrophes kept", a: "don't stop", b: "dont stop", want: 1.0 / 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jaccard(wordSet(tt.a), wordSet(tt.b)); !approx(got, tt.want) {
+				t.Errorf("jaccard(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScorePair(t *testing.T) {
+	tests := []struct {
+		name          string
+		first, second entities.Song
+		wantScore     float64
+		wantLyrics    *float64
+		wantLink      *float64
+	}{
+		{
+			name:      "names only",
+			first:     entities.Song{Group: "Muse", Song: "Uprising"},
+			second:    entities.Song{Group: "MUSE", Song: "Uprising!"},
+			wantScore: 1,
+		},
+		{
+			name:       "names and lyrics",
+			first:      entities.Song{Group: "Muse", Song: "Uprising", Text: ptr("a b c")},
+			second:     entities.Song{Group: "Muse", Song: "Uprising", Text: ptr("b c d")},
+			wantScore:  (1*namesWeight + 0.5*lyricsWeight) / (namesWeight + lyricsWeight),
+			wantLyrics: ptr(0.5),
+		},
+		{
+			name:      "same link up to case and spaces",
+			first:     entities.Song{Group: "Muse", Song: "Uprising", Link: ptr("https://example.com/Uprising ")},
+			second:    entities.Song{Group: "Muse", Song: "Uprising", Link: ptr("https://EXAMPLE.com/uprising")},
+			wantScore: 1,
+			wantLink:  ptr(1.0),
+		},
+		{
+			name:      "different links",
+			first:     entities.Song{Group: "Muse", Song: "Uprising", Link: ptr("https://example.com/a")},
+			second:    entities.Song{Group: "Muse", Song: "Uprising", Link: ptr("https://example.com/b")},
+			wantScore: namesWeight / (namesWeight + linkWeight),
+			wantLink:  ptr(0.0),
+		},
+		{
+			name:      "lyrics of one song only",
+			first:     entities.Song{Group: "Muse", Song: "Uprising", Text: ptr("a b c")},
+			second:    entities.Song{Group: "Muse", Song: "Uprising"},
+			wantScore: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var firstWords, secondWords map[string]bool
+			if tt.first.Text != nil {
+				firstWords = wordSet(*tt.first.Text)
+			}
+			if tt.second.Text != nil {
+				secondWords = wordSet(*tt.second.Text)
+			}
+
+			pair := scorePair(&tt.first, &tt.second, firstWords, secondWords)
+
+			if !approx(pair.Score, tt.wantScore) {
+				t.Errorf("Score = %v, want %v", pair.Score, tt.wantScore)
+			}
+
+			for _, signal := range []struct {
+				name      string
+				got, want *float64
+			}{
+				{"Lyrics", pair.Lyrics, tt.wantLyrics},
+				{"Link", pair.Link, tt.wantLink},
+			} {
+				switch {
+				case (signal.got == nil) != (signal.want == nil):
+					t.Errorf("%s = %v, want %v", signal.name, signal.got, signal.want)
+				case signal.got != nil && !approx(*signal.got, *signal.want):
+					t.Errorf("%s = %v, want %v", signal.name, *signal.got, *signal.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	songs := []entities.Song{
+		{ID: 1, Group: "Muse", Song: "Uprising"},
+		{ID: 2, Group: "muse", Song: "Uprising"},
+		{ID: 3, Group: "Muse", Song: "Starlight"},
+		{ID: 4, Group: "Queen", Song: "Uprising"},
+		{ID: 5, Group: "Kino", Song: "Uprising", Link: ptr("https://example.com/uprising")},
+		{ID: 6, Group: "Kino!", Song: "Uprisng", Link: ptr("https://example.com/uprising")},
+	}
+
+	tests := []struct {
+		name      string
+		threshold float64
+		want      [][2]int
+	}{
+		{
+			name:      "exact names",
+			threshold: 1,
+			want:      [][2]int{{1, 2}},
+		},
+		{
+			name:      "blocked by group or link",
+			threshold: 0.9,
+			want:      [][2]int{{1, 2}, {5, 6}},
+		},
+		{
+			name:      "everything within the blocks",
+			threshold: 0,
+			want:      [][2]int{{1, 2}, {5, 6}, {1, 3}, {2, 3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairs := findDuplicates(songs, tt.threshold)
+
+			if len(pairs) != len(tt.want) {
+				t.Fatalf("got %d pairs, want %d", len(pairs), len(tt.want))
+			}
+
+			for i, pair := range pairs {
+				if got := [2]int{pair.First.ID, pair.Second.ID}; got != tt.want[i] {
+					t.Errorf("pair %d = %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -3,7 +3,19 @@ package usecases
 import "errors"
 
 var (
-	ErrNoRowsAffected = errors.New("no rows affected")
-	ErrAlreadyExists  = errors.New("already exists")
-	ErrNullFields     = errors.New("null field")
+	ErrNoRowsAffected  = errors.New("no rows affected")
+	ErrAlreadyExists   = errors.New("already exists")
+	ErrNullFields      = errors.New("null field")
+	ErrInvalidToken    = errors.New("invalid token")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrForbidden       = errors.New("forbidden")
+	ErrBuiltin         = errors.New("built-in")
+	ErrUnknownTenant   = errors.New("unknown tenant")
+	ErrInUse           = errors.New("in use")
+	ErrUnknownWindow   = errors.New("unknown window")
+	ErrInvalidParent   = errors.New("invalid parent")
+	ErrInvalidRelation = errors.New("invalid relation")
+	ErrSameSong        = errors.New("same song")
+	ErrExplicit        = errors.New("explicit")
+	ErrInsecureURL     = errors.New("insecure url")
 )
@@ -0,0 +1,168 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"effective-mobile-test/internal/lyrics"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+const explicitRescanBatch = 500
+
+type ExplicitRepo interface {
+	GetTexts(afterID, limit int, all bool) (*[]entities.Song, error)
+	SetExplicit(id int, explicit bool) (bool, error)
+}
+
+// Explicit flags the songs whose lyrics have words of the wordlist. The flag
+// is set on every lyrics write and checked again on start, so that it follows
+// the changes of the wordlist.
+type Explicit struct {
+	repo     ExplicitRepo
+	songs    SongLibraryRepo
+	authz    Authorizer
+	wordlist *lyrics.Wordlist
+	log      *slog.Logger
+}
+
+func NewExplicit(repo ExplicitRepo, songs SongLibraryRepo, authz Authorizer, wordlist *lyrics.Wordlist, log *slog.Logger) *Explicit {
+	return &Explicit{
+		repo:     repo,
+		songs:    songs,
+		authz:    authz,
+		wordlist: wordlist,
+		log:      log,
+	}
+}
+
+// GetReport lists the lines of the lyrics with the words of the wordlist.
+func (ex *Explicit) GetReport(ctx context.Context, group, song string) (*dto.ExplicitReportResponse, error) {
+	const fn = "usecases.Explicit.GetReport"
+
+	defer ex.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", group, song)
+
+	if err := ex.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	res, err := ex.songs.Get(tenant.Get(ctx), group, song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	report := &dto.ExplicitReportResponse{
+		Explicit: res.IsExplicit(),
+		Detected: res.Explicit,
+		Override: res.ExplicitOverride,
+		Lines:    make([]*dto.ExplicitLineResponse, 0),
+	}
+
+	if res.Text == nil {
+		return report, nil
+	}
+
+	for i, paragraph := range strings.Split(*res.Text, "\n\n") {
+		for j, line := range strings.Split(paragraph, "\n") {
+			if words := ex.wordlist.Find(line); len(words) > 0 {
+				report.Lines = append(report.Lines, &dto.ExplicitLineResponse{
+					Paragraph: i,
+					Line:      j,
+					Text:      line,
+					Words:     words,
+				})
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// SetOverride marks the song explicit or clean by hand, a nil explicit goes
+// back to the detected flag.
+func (ex *Explicit) SetOverride(ctx context.Context, req *dto.SetExplicitRequest) error {
+	const fn = "usecases.Explicit.SetOverride"
+
+	defer ex.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	if err := ex.authz.Authorize(ctx, entities.PermSongsUpdate); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err := ex.songs.Update(tenant.Get(ctx), req.Group, req.Song, map[string]interface{}{
+		"explicit_override": req.Explicit,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// Rescan checks the lyrics of every song against the wordlist and updates the
+// flags that changed.
+func (ex *Explicit) Rescan(ctx context.Context) {
+	const fn = "usecases.Explicit.Rescan"
+
+	log := ex.log.With(
+		slog.String("fn", fn),
+	)
+
+	var afterID, updated int
+
+	for ctx.Err() == nil {
+		songs, err := ex.repo.GetTexts(afterID, explicitRescanBatch, true)
+		if err != nil {
+			log.Error("failed to get lyrics", slog.String("error", err.Error()))
+			return
+		}
+
+		for _, song := range *songs {
+			afterID = song.ID
+
+			changed, err := ex.repo.SetExplicit(song.ID, len(ex.wordlist.Find(*song.Text)) > 0)
+			if err != nil {
+				log.Error("failed to flag song", slog.Int("song", song.ID), slog.String("error", err.Error()))
+				return
+			}
+
+			if changed {
+				updated++
+			}
+		}
+
+		if len(*songs) < explicitRescanBatch {
+			break
+		}
+	}
+
+	log.Info("explicit flags checked", slog.Int("updated", updated))
+}
+
+// withExplicit flags the text the fields write, if any.
+func withExplicit(fields map[string]interface{}, wordlist *lyrics.Wordlist) {
+	value, ok := fields["text"]
+	if !ok {
+		return
+	}
+
+	text, _ := value.(*string)
+	fields["explicit"] = text != nil && len(wordlist.Find(*text)) > 0
+}
@@ -0,0 +1,127 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+type FavoritesRepo interface {
+	Add(userID, songID int) error
+	Remove(userID, songID int) error
+	GetList(userID int, pagination *pagination.Pagination) (*[]entities.FavoriteSong, error)
+	GetFavorited(userID int, songIDs []int) ([]int, error)
+}
+
+type Favorites struct {
+	repo  FavoritesRepo
+	songs SongLibraryRepo
+	authz Authorizer
+	log   *slog.Logger
+}
+
+func NewFavorites(repo FavoritesRepo, songs SongLibraryRepo, authz Authorizer, log *slog.Logger) *Favorites {
+	return &Favorites{
+		repo:  repo,
+		songs: songs,
+		authz: authz,
+		log:   log,
+	}
+}
+
+func (fv *Favorites) Add(ctx context.Context, group, song string) error {
+	const fn = "usecases.Favorites.Add"
+
+	defer fv.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("group", group), slog.String("song", song))
+
+	userID, songID, err := fv.resolve(ctx, group, song)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err = fv.repo.Add(userID, songID); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (fv *Favorites) Remove(ctx context.Context, group, song string) error {
+	const fn = "usecases.Favorites.Remove"
+
+	defer fv.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("group", group), slog.String("song", song))
+
+	userID, songID, err := fv.resolve(ctx, group, song)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err = fv.repo.Remove(userID, songID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (fv *Favorites) GetList(ctx context.Context, pagination *pagination.Pagination) ([]*dto.FavoriteSongResponse, error) {
+	const fn = "usecases.Favorites.GetList"
+
+	defer fv.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("pagination", pagination))
+
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err = fv.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	songs, err := fv.repo.GetList(userID, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewFavoritesListResponse(songs), nil
+}
+
+// resolve returns the current user and the id of the song in their tenant.
+func (fv *Favorites) resolve(ctx context.Context, group, song string) (int, int, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err = fv.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return 0, 0, err
+	}
+
+	songRes, err := fv.songs.Get(tenant.Get(ctx), group, song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, ErrNoRowsAffected
+		}
+		return 0, 0, err
+	}
+
+	return userID, songRes.ID, nil
+}
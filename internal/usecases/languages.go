@@ -0,0 +1,97 @@
+package usecases
+
+import (
+	"context"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/lyrics"
+	"fmt"
+	"log/slog"
+)
+
+type LanguagesRepo interface {
+	GetTexts(afterID, limit int, all bool) (*[]entities.Song, error)
+	SetLanguage(id int, language *string, confidence *float64) (bool, error)
+}
+
+// Languages detects the languages of the lyrics written before the detection
+// ran on every write.
+type Languages struct {
+	repo LanguagesRepo
+	log  *slog.Logger
+}
+
+func NewLanguages(repo LanguagesRepo, log *slog.Logger) *Languages {
+	return &Languages{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// Backfill detects the language of the songs with lyrics but without one, of
+// every song with lyrics when all is set, batch songs at a time. It returns
+// the number of the songs whose language changed.
+func (lg *Languages) Backfill(ctx context.Context, batch int, all bool) (int, error) {
+	const fn = "usecases.Languages.Backfill"
+
+	var afterID, updated int
+
+	for ctx.Err() == nil {
+		songs, err := lg.repo.GetTexts(afterID, batch, all)
+		if err != nil {
+			return updated, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		for _, song := range *songs {
+			afterID = song.ID
+
+			language, confidence := detectLanguage(song.Text)
+
+			changed, err := lg.repo.SetLanguage(song.ID, language, confidence)
+			if err != nil {
+				return updated, fmt.Errorf("%s: %w", fn, err)
+			}
+
+			if changed {
+				updated++
+			}
+		}
+
+		lg.log.Info("detected languages",
+			slog.String("fn", fn),
+			slog.Int("after_id", afterID),
+			slog.Int("updated", updated),
+		)
+
+		if len(*songs) < batch {
+			return updated, nil
+		}
+	}
+
+	return updated, ctx.Err()
+}
+
+// detectLanguage returns the language of the lyrics and the confidence in it,
+// both nil when the lyrics are too short to tell.
+func detectLanguage(text *string) (*string, *float64) {
+	if text == nil {
+		return nil, nil
+	}
+
+	language, confidence, ok := lyrics.DetectLanguage(*text)
+	if !ok {
+		return nil, nil
+	}
+
+	return &language, &confidence
+}
+
+// withLanguage sets the language of the text the fields write, if any.
+func withLanguage(fields map[string]interface{}) {
+	value, ok := fields["text"]
+	if !ok {
+		return
+	}
+
+	text, _ := value.(*string)
+	fields["language"], fields["language_confidence"] = detectLanguage(text)
+}
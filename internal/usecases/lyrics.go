@@ -0,0 +1,204 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"effective-mobile-test/internal/lyrics"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+const defaultTermsLimit = 20
+
+type LyricsRepo interface {
+	GetStale(limit int) (*[]entities.Song, error)
+	Save(stats *entities.TextStats) error
+	GetGroupStats(tenant string, scope *entities.LyricsScope, limit int) (*entities.TextStats, error)
+}
+
+type LyricsOptions struct {
+	IndexInterval time.Duration
+	IndexBatch    int
+}
+
+// Lyrics counts the words of the lyrics. The songs are analyzed on request,
+// the groups are aggregated from the terms indexed in the background every
+// IndexInterval, so they lag the lyrics changes by up to that.
+type Lyrics struct {
+	repo  LyricsRepo
+	songs SongLibraryRepo
+	authz Authorizer
+	opts  LyricsOptions
+	log   *slog.Logger
+}
+
+func NewLyrics(repo LyricsRepo, songs SongLibraryRepo, authz Authorizer, opts LyricsOptions, log *slog.Logger) *Lyrics {
+	return &Lyrics{
+		repo:  repo,
+		songs: songs,
+		authz: authz,
+		opts:  opts,
+		log:   log,
+	}
+}
+
+func (ly *Lyrics) GetSong(ctx context.Context, req *dto.GetSongLyricsStatsRequest) (*dto.LyricsStatsResponse, error) {
+	const fn = "usecases.Lyrics.GetSong"
+
+	defer ly.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	if err := ly.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	song, err := ly.songs.Get(tenant.Get(ctx), req.Group, req.Song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	stats := textStats(song)
+	stats.Terms = topTerms(stats.Terms, termsLimit(req.Limit))
+
+	return dto.NewLyricsStatsResponse(stats), nil
+}
+
+func (ly *Lyrics) GetGroup(ctx context.Context, req *dto.GetGroupLyricsStatsRequest) (*dto.GroupLyricsStatsResponse, error) {
+	const fn = "usecases.Lyrics.GetGroup"
+
+	defer ly.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	if err := ly.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	stats, err := ly.repo.GetGroupStats(tenant.Get(ctx), &entities.LyricsScope{
+		Group:    req.Group,
+		FromYear: req.FromYear,
+		ToYear:   req.ToYear,
+	}, termsLimit(req.Limit))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewGroupLyricsStatsResponse(stats), nil
+}
+
+// Run indexes the terms of the songs updated since their last analysis until
+// ctx is done.
+func (ly *Lyrics) Run(ctx context.Context) {
+	const fn = "usecases.Lyrics.Run"
+
+	ticker := time.NewTicker(ly.opts.IndexInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ly.index(ctx); err != nil {
+				ly.log.Error("failed to index lyrics",
+					slog.String("fn", fn),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+// index analyzes the stale songs batch by batch until none is left. A song
+// updated meanwhile stays stale, since it's saved with the update time it was
+// read with.
+func (ly *Lyrics) index(ctx context.Context) error {
+	for ctx.Err() == nil {
+		songs, err := ly.repo.GetStale(ly.opts.IndexBatch)
+		if err != nil {
+			return err
+		}
+
+		for _, song := range *songs {
+			if err = ly.repo.Save(textStats(&song)); err != nil {
+				return err
+			}
+		}
+
+		if len(*songs) < ly.opts.IndexBatch {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func textStats(song *entities.Song) *entities.TextStats {
+	var text string
+	if song.Text != nil {
+		text = *song.Text
+	}
+
+	analysis := lyrics.Analyze(text)
+
+	stats := &entities.TextStats{
+		SongID:      song.ID,
+		Words:       analysis.Words,
+		UniqueWords: analysis.UniqueWords(),
+		AnalyzedAt:  song.UpdatedAt,
+		Terms:       make([]entities.TermCount, 0, len(analysis.Terms)),
+	}
+
+	for term, count := range analysis.Terms {
+		stats.Terms = append(stats.Terms, entities.TermCount{
+			Term:     term,
+			Count:    count,
+			Stopword: lyrics.IsStopword(term),
+		})
+	}
+
+	return stats
+}
+
+// topTerms returns the limit most frequent terms that aren't stopwords, the
+// ties in alphabetical order.
+func topTerms(terms []entities.TermCount, limit int) []entities.TermCount {
+	top := make([]entities.TermCount, 0, len(terms))
+	for _, term := range terms {
+		if !term.Stopword {
+			top = append(top, term)
+		}
+	}
+
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].Count != top[j].Count {
+			return top[i].Count > top[j].Count
+		}
+		return top[i].Term < top[j].Term
+	})
+
+	if len(top) > limit {
+		top = top[:limit]
+	}
+
+	return top
+}
+
+func termsLimit(limit int) int {
+	if limit == 0 {
+		return defaultTermsLimit
+	}
+	return limit
+}
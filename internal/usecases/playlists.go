@@ -0,0 +1,323 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"errors"
+	"fmt"
+	"github.com/fatih/structs"
+	"log/slog"
+	"reflect"
+)
+
+type PlaylistsRepo interface {
+	Create(playlist *entities.Playlist) (*entities.Playlist, error)
+	Get(id int) (*entities.Playlist, error)
+	GetList(tenant string, userID int, withTenantOwned bool, pagination *pagination.Pagination) (*[]entities.Playlist, error)
+	Update(id int, fields map[string]interface{}) error
+	Delete(id int) error
+	GetEntries(playlistID int) (*[]entities.PlaylistEntry, error)
+	AddEntry(playlistID, songID int, index *int) (*entities.PlaylistEntry, bool, error)
+	MoveEntry(playlistID, entryID, index int) error
+	RemoveEntry(playlistID, entryID int) error
+}
+
+// Playlists are managed by their owner: the user who created them, or the
+// principals with playlists:manage for the ones of the tenant. Others only
+// see the public playlists.
+type Playlists struct {
+	repo  PlaylistsRepo
+	songs SongLibraryRepo
+	authz Authorizer
+	log   *slog.Logger
+}
+
+func NewPlaylists(repo PlaylistsRepo, songs SongLibraryRepo, authz Authorizer, log *slog.Logger) *Playlists {
+	return &Playlists{
+		repo:  repo,
+		songs: songs,
+		authz: authz,
+		log:   log,
+	}
+}
+
+// Create makes the playlist owned by the signed in user, or by the tenant
+// for the other principals.
+func (pl *Playlists) Create(ctx context.Context, req *dto.CreatePlaylistRequest) (*dto.PlaylistResponse, error) {
+	const fn = "usecases.Playlists.Create"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	if err := pl.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	playlist := &entities.Playlist{
+		TenantID:        tenant.Get(ctx),
+		Name:            req.Name,
+		Description:     req.Description,
+		Visibility:      req.Visibility,
+		AllowDuplicates: req.AllowDuplicates,
+	}
+
+	if playlist.Visibility == "" {
+		playlist.Visibility = entities.PlaylistPrivate
+	}
+
+	if userID, err := currentUser(ctx); err == nil {
+		playlist.OwnerUserID = &userID
+	} else if err = pl.authz.Authorize(ctx, entities.PermPlaylistsManage); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	playlist, err := pl.repo.Create(playlist)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewPlaylistResponse(playlist), nil
+}
+
+func (pl *Playlists) Get(ctx context.Context, id int) (*dto.PlaylistWithEntriesResponse, error) {
+	const fn = "usecases.Playlists.Get"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("id", id))
+
+	playlist, err := pl.get(ctx, id, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	entries, err := pl.repo.GetEntries(id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewPlaylistWithEntriesResponse(playlist, entries), nil
+}
+
+func (pl *Playlists) GetList(ctx context.Context, pagination *pagination.Pagination) ([]*dto.PlaylistResponse, error) {
+	const fn = "usecases.Playlists.GetList"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("pagination", pagination))
+
+	if err := pl.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	userID, _ := currentUser(ctx)
+
+	withTenantOwned, err := pl.manages(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	playlists, err := pl.repo.GetList(tenant.Get(ctx), userID, withTenantOwned, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewPlaylistsListResponse(playlists), nil
+}
+
+func (pl *Playlists) Update(ctx context.Context, id int, req *dto.UpdatePlaylistRequest) error {
+	const fn = "usecases.Playlists.Update"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("id", id), slog.Any("request", req))
+
+	var fields = make(map[string]interface{})
+	for _, field := range structs.New(req).Fields() {
+		tag := field.Tag("db")
+		val := reflect.ValueOf(field.Value())
+		if tag == "" || val.IsNil() {
+			continue
+		}
+		fields[tag] = val.Elem().Interface()
+	}
+
+	if len(fields) == 0 {
+		return fmt.Errorf("%s: %w", fn, ErrNullFields)
+	}
+
+	if _, err := pl.get(ctx, id, true); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err := pl.repo.Update(id, fields)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (pl *Playlists) Delete(ctx context.Context, id int) error {
+	const fn = "usecases.Playlists.Delete"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("id", id))
+
+	if _, err := pl.get(ctx, id, true); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err := pl.repo.Delete(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// AddEntry puts the song at the requested position, at the end without one.
+// When the playlist doesn't allow duplicates and already has the song, its
+// existing entry is returned with added set to false.
+func (pl *Playlists) AddEntry(ctx context.Context, id int, req *dto.AddPlaylistEntryRequest) (*dto.PlaylistEntryResponse, bool, error) {
+	const fn = "usecases.Playlists.AddEntry"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("id", id), slog.Any("request", req))
+
+	if _, err := pl.get(ctx, id, true); err != nil {
+		return nil, false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	song, err := pl.songs.Get(tenant.Get(ctx), req.Group, req.Song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return nil, false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	entry, added, err := pl.repo.AddEntry(id, song.ID, req.Position)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return nil, false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewPlaylistEntryResponse(entry), added, nil
+}
+
+func (pl *Playlists) MoveEntry(ctx context.Context, id, entryID int, req *dto.MovePlaylistEntryRequest) error {
+	const fn = "usecases.Playlists.MoveEntry"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("id", id), slog.Int("entryId", entryID), slog.Any("request", req))
+
+	if _, err := pl.get(ctx, id, true); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err := pl.repo.MoveEntry(id, entryID, *req.Position)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (pl *Playlists) RemoveEntry(ctx context.Context, id, entryID int) error {
+	const fn = "usecases.Playlists.RemoveEntry"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("id", id), slog.Int("entryId", entryID))
+
+	if _, err := pl.get(ctx, id, true); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err := pl.repo.RemoveEntry(id, entryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// get returns the playlist of the ctx tenant visible to the principal, the
+// invisible ones are reported as missing. With write the principal has to
+// own it.
+func (pl *Playlists) get(ctx context.Context, id int, write bool) (*entities.Playlist, error) {
+	if err := pl.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, err
+	}
+
+	playlist, err := pl.repo.Get(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRowsAffected
+		}
+		return nil, err
+	}
+
+	if playlist.TenantID != tenant.Get(ctx) {
+		return nil, ErrNoRowsAffected
+	}
+
+	owns := false
+	if playlist.OwnerUserID != nil {
+		userID, _ := currentUser(ctx)
+		owns = userID == *playlist.OwnerUserID
+	} else if owns, err = pl.manages(ctx); err != nil {
+		return nil, err
+	}
+
+	if !owns {
+		if playlist.Visibility != entities.PlaylistPublic {
+			return nil, ErrNoRowsAffected
+		}
+		if write {
+			return nil, ErrForbidden
+		}
+	}
+
+	return playlist, nil
+}
+
+// manages reports whether the principal manages the playlists of the tenant.
+func (pl *Playlists) manages(ctx context.Context) (bool, error) {
+	err := pl.authz.Authorize(ctx, entities.PermPlaylistsManage)
+	if errors.Is(err, ErrForbidden) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,273 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type PlaysRepo interface {
+	AddPlays(counts []entities.PlayCount) error
+	GetTrending(tenant string, window, halfLife time.Duration, pagination *pagination.Pagination) (*[]entities.TrendingSong, error)
+	GetStats(songID int, granularity string, since, until time.Time) (*[]entities.PlayBucket, error)
+	DeleteHourlyBefore(before time.Time) (int64, error)
+}
+
+// PlayRecorder counts a play of the song.
+type PlayRecorder interface {
+	Record(songID int)
+}
+
+type PlaysOptions struct {
+	FlushInterval   time.Duration
+	MaxBatch        int
+	HourlyRetention time.Duration
+	Windows         map[string]time.Duration
+	DefaultWindow   string
+}
+
+type playKey struct {
+	songID int
+	hour   time.Time
+}
+
+// Plays buffers the plays in memory and adds them to the counters in batches,
+// every FlushInterval or once MaxBatch songs are pending. The counters lag by
+// up to FlushInterval.
+type Plays struct {
+	repo  PlaysRepo
+	songs SongLibraryRepo
+	authz Authorizer
+	opts  PlaysOptions
+	log   *slog.Logger
+
+	mu      sync.Mutex
+	pending map[playKey]int64
+	flush   chan struct{}
+}
+
+func NewPlays(repo PlaysRepo, songs SongLibraryRepo, authz Authorizer, opts PlaysOptions, log *slog.Logger) *Plays {
+	return &Plays{
+		repo:    repo,
+		songs:   songs,
+		authz:   authz,
+		opts:    opts,
+		log:     log,
+		pending: make(map[playKey]int64),
+		flush:   make(chan struct{}, 1),
+	}
+}
+
+func (pl *Plays) Record(songID int) {
+	key := playKey{songID: songID, hour: time.Now().UTC().Truncate(time.Hour)}
+
+	pl.mu.Lock()
+	pl.pending[key]++
+	full := len(pl.pending) >= pl.opts.MaxBatch
+	pl.mu.Unlock()
+
+	if full {
+		select {
+		case pl.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Ingest records the plays reported by the client, the events of unknown
+// songs fail the whole batch.
+func (pl *Plays) Ingest(ctx context.Context, req *dto.PlayEventsRequest) error {
+	const fn = "usecases.Plays.Ingest"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("events", len(req.Events)))
+
+	if err := pl.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	ids := make([]int, 0, len(req.Events))
+	resolved := make(map[dto.PlayEvent]int)
+	for _, event := range req.Events {
+		id, ok := resolved[event]
+		if !ok {
+			song, err := pl.songs.Get(tenant.Get(ctx), event.Group, event.Song)
+			if err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return fmt.Errorf("%s: %w: %s - %s", fn, ErrNoRowsAffected, event.Group, event.Song)
+				}
+				return fmt.Errorf("%s: %w", fn, err)
+			}
+
+			id = song.ID
+			resolved[event] = id
+		}
+
+		ids = append(ids, id)
+	}
+
+	for _, id := range ids {
+		pl.Record(id)
+	}
+
+	return nil
+}
+
+// GetTrending scores the songs by their plays within the window, a play
+// weighs half as much every quarter of the window.
+func (pl *Plays) GetTrending(ctx context.Context, window string, pagination *pagination.Pagination) ([]*dto.TrendingSongResponse, error) {
+	const fn = "usecases.Plays.GetTrending"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("window", window), slog.Any("pagination", pagination))
+
+	if err := pl.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if window == "" {
+		window = pl.opts.DefaultWindow
+	}
+
+	duration, ok := pl.opts.Windows[window]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w: %s", fn, ErrUnknownWindow, window)
+	}
+
+	songs, err := pl.repo.GetTrending(tenant.Get(ctx), duration, duration/4, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewTrendingSongsListResponse(songs), nil
+}
+
+// GetStats returns the counters of the song, by default hourly for the last
+// day or daily for the last 30 days.
+func (pl *Plays) GetStats(ctx context.Context, req *dto.GetPlayStatsRequest) (*dto.PlayStatsResponse, error) {
+	const fn = "usecases.Plays.GetStats"
+
+	defer pl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	if err := pl.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	song, err := pl.songs.Get(tenant.Get(ctx), req.Group, req.Song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	granularity, span := entities.PlaysHourly, 24*time.Hour
+	if req.Granularity == entities.PlaysDaily {
+		granularity, span = entities.PlaysDaily, 30*24*time.Hour
+	}
+
+	until := time.Now().UTC()
+	if req.Until != nil {
+		until = req.Until.UTC()
+	}
+
+	since := until.Add(-span)
+	if req.Since != nil {
+		since = req.Since.UTC()
+	}
+
+	buckets, err := pl.repo.GetStats(song.ID, granularity, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewPlayStatsResponse(song, granularity, buckets), nil
+}
+
+// Run flushes the pending plays until ctx is done, then flushes them for the
+// last time, and drops the hourly counters past HourlyRetention.
+func (pl *Plays) Run(ctx context.Context) {
+	const fn = "usecases.Plays.Run"
+
+	ticker := time.NewTicker(pl.opts.FlushInterval)
+	defer ticker.Stop()
+
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			pl.flushPending()
+			return
+		case <-ticker.C:
+			pl.flushPending()
+		case <-pl.flush:
+			pl.flushPending()
+		case <-cleanup.C:
+			if _, err := pl.repo.DeleteHourlyBefore(time.Now().Add(-pl.opts.HourlyRetention)); err != nil {
+				pl.log.Error("failed to delete hourly play counters",
+					slog.String("fn", fn),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+// maxPendingBatches bounds the plays kept for the retries, past MaxBatch times
+// it the plays of a failed write are dropped rather than put back.
+const maxPendingBatches = 10
+
+// flushPending writes the pending plays, they are put back to be retried with
+// the next batch when the write fails, unless too many are pending already.
+func (pl *Plays) flushPending() {
+	const fn = "usecases.Plays.flushPending"
+
+	pl.mu.Lock()
+	pending := pl.pending
+	pl.pending = make(map[playKey]int64, len(pending))
+	pl.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	counts := make([]entities.PlayCount, 0, len(pending))
+	for key, plays := range pending {
+		counts = append(counts, entities.PlayCount{SongID: key.songID, Hour: key.hour, Plays: plays})
+	}
+
+	if err := pl.repo.AddPlays(counts); err != nil {
+		pl.mu.Lock()
+		requeue := len(pl.pending)+len(pending) <= maxPendingBatches*pl.opts.MaxBatch
+		if requeue {
+			for key, plays := range pending {
+				pl.pending[key] += plays
+			}
+		}
+		pl.mu.Unlock()
+
+		pl.log.Error("failed to flush plays",
+			slog.String("fn", fn),
+			slog.Int("counts", len(counts)),
+			slog.Bool("requeued", requeue),
+			slog.String("error", err.Error()),
+		)
+	}
+}
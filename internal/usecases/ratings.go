@@ -0,0 +1,292 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
+	"strings"
+	"unicode"
+)
+
+type RatingsRepo interface {
+	Create(rating *entities.Rating) (*entities.Rating, error)
+	Update(songID, userID int, fields map[string]interface{}) error
+	Delete(songID, userID int) error
+	GetByUser(userID int, pagination *pagination.Pagination) (*[]entities.Rating, error)
+	GetReviews(songID int, pagination *pagination.Pagination) (*[]entities.Rating, error)
+	GetPending(tenant string, pagination *pagination.Pagination) (*[]entities.Rating, error)
+	SetReviewStatus(tenant string, id int, status string) error
+}
+
+// Ratings lets the users rate the songs. The reviews containing a word of the
+// blocklist, or all of them without one, are held as pending until a
+// moderator publishes or rejects them.
+type Ratings struct {
+	repo      RatingsRepo
+	songs     SongLibraryRepo
+	authz     Authorizer
+	blocklist map[string]struct{}
+	log       *slog.Logger
+}
+
+func NewRatings(repo RatingsRepo, songs SongLibraryRepo, authz Authorizer, blocklist []string, log *slog.Logger) *Ratings {
+	words := make(map[string]struct{}, len(blocklist))
+	for _, word := range blocklist {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			words[word] = struct{}{}
+		}
+	}
+
+	return &Ratings{
+		repo:      repo,
+		songs:     songs,
+		authz:     authz,
+		blocklist: words,
+		log:       log,
+	}
+}
+
+func (rt *Ratings) Create(ctx context.Context, req *dto.RateSongRequest) (*dto.RatingResponse, error) {
+	const fn = "usecases.Ratings.Create"
+
+	defer rt.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	userID, songID, err := rt.resolve(ctx, req.Group, req.Song)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	review, status := rt.screen(req.Review)
+
+	rating, err := rt.repo.Create(&entities.Rating{
+		SongID:       songID,
+		UserID:       userID,
+		Rating:       req.Rating,
+		Review:       review,
+		ReviewStatus: status,
+	})
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return nil, fmt.Errorf("%s: %w", fn, ErrAlreadyExists)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	rating.Group, rating.Song = req.Group, req.Song
+
+	return dto.NewRatingResponse(rating), nil
+}
+
+// Update changes the rating of the user, a changed review goes through the
+// moderation again.
+func (rt *Ratings) Update(ctx context.Context, req *dto.UpdateRatingRequest) error {
+	const fn = "usecases.Ratings.Update"
+
+	defer rt.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	var fields = make(map[string]interface{})
+	if req.Rating != nil {
+		fields["rating"] = *req.Rating
+	}
+	if req.Review != nil {
+		fields["review"], fields["review_status"] = rt.screen(req.Review)
+	}
+
+	if len(fields) == 0 {
+		return fmt.Errorf("%s: %w", fn, ErrNullFields)
+	}
+
+	userID, songID, err := rt.resolve(ctx, req.Group, req.Song)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err = rt.repo.Update(songID, userID, fields)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (rt *Ratings) Delete(ctx context.Context, group, song string) error {
+	const fn = "usecases.Ratings.Delete"
+
+	defer rt.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("group", group), slog.String("song", song))
+
+	userID, songID, err := rt.resolve(ctx, group, song)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err = rt.repo.Delete(songID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (rt *Ratings) GetList(ctx context.Context, pagination *pagination.Pagination) ([]*dto.RatingResponse, error) {
+	const fn = "usecases.Ratings.GetList"
+
+	defer rt.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("pagination", pagination))
+
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err = rt.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	ratings, err := rt.repo.GetByUser(userID, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewRatingsListResponse(ratings), nil
+}
+
+func (rt *Ratings) GetReviews(ctx context.Context, group, song string, pagination *pagination.Pagination) ([]*dto.ReviewResponse, error) {
+	const fn = "usecases.Ratings.GetReviews"
+
+	defer rt.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("group", group), slog.String("song", song), slog.Any("pagination", pagination))
+
+	if err := rt.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	songRes, err := rt.songs.Get(tenant.Get(ctx), group, song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	reviews, err := rt.repo.GetReviews(songRes.ID, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewReviewsListResponse(reviews), nil
+}
+
+func (rt *Ratings) GetPending(ctx context.Context, pagination *pagination.Pagination) ([]*dto.ReviewResponse, error) {
+	const fn = "usecases.Ratings.GetPending"
+
+	defer rt.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("pagination", pagination))
+
+	if err := rt.authz.Authorize(ctx, entities.PermReviewsModerate); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	reviews, err := rt.repo.GetPending(tenant.Get(ctx), pagination)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewReviewsListResponse(reviews), nil
+}
+
+func (rt *Ratings) Moderate(ctx context.Context, id int, status string) error {
+	const fn = "usecases.Ratings.Moderate"
+
+	defer rt.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("id", id), slog.String("status", status))
+
+	if err := rt.authz.Authorize(ctx, entities.PermReviewsModerate); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err := rt.repo.SetReviewStatus(tenant.Get(ctx), id, status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// resolve returns the current user and the id of the song in their tenant.
+func (rt *Ratings) resolve(ctx context.Context, group, song string) (int, int, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err = rt.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return 0, 0, err
+	}
+
+	songRes, err := rt.songs.Get(tenant.Get(ctx), group, song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, ErrNoRowsAffected
+		}
+		return 0, 0, err
+	}
+
+	return userID, songRes.ID, nil
+}
+
+// screen returns the review to store, nil for a blank one, and its status:
+// pending when it contains a word of the blocklist or there is no blocklist.
+func (rt *Ratings) screen(review *string) (*string, string) {
+	if review == nil || strings.TrimSpace(*review) == "" {
+		return nil, entities.ReviewPublished
+	}
+
+	if len(rt.blocklist) == 0 {
+		return review, entities.ReviewPending
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(*review), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if _, ok := rt.blocklist[word]; ok {
+			return review, entities.ReviewPending
+		}
+	}
+
+	return review, entities.ReviewPublished
+}
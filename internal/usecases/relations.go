@@ -0,0 +1,127 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
+)
+
+type RelationsRepo interface {
+	SongRelationsRepo
+	Add(songID, relatedID int, kind string) error
+	Remove(songID, relatedID int, kind string) error
+}
+
+type Relations struct {
+	repo  RelationsRepo
+	songs SongLibraryRepo
+	authz Authorizer
+	log   *slog.Logger
+}
+
+func NewRelations(repo RelationsRepo, songs SongLibraryRepo, authz Authorizer, log *slog.Logger) *Relations {
+	return &Relations{
+		repo:  repo,
+		songs: songs,
+		authz: authz,
+		log:   log,
+	}
+}
+
+// Link marks the song as a version of the original, or as sampling it. A song
+// is a version of a single original per kind and can't derive from one of its
+// own versions.
+func (rl *Relations) Link(ctx context.Context, req *dto.SongRelationRequest) error {
+	const fn = "usecases.Relations.Link"
+
+	defer rl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	songID, originalID, err := rl.resolve(ctx, req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if songID == originalID {
+		return fmt.Errorf("%s: %w", fn, ErrInvalidRelation)
+	}
+
+	originals, err := rl.repo.GetOriginals(originalID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	for _, original := range *originals {
+		if original.RelatedID == songID {
+			return fmt.Errorf("%s: %w", fn, ErrInvalidRelation)
+		}
+	}
+
+	err = rl.repo.Add(songID, originalID, req.Kind)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return fmt.Errorf("%s: %w", fn, ErrAlreadyExists)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (rl *Relations) Unlink(ctx context.Context, req *dto.SongRelationRequest) error {
+	const fn = "usecases.Relations.Unlink"
+
+	defer rl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	songID, originalID, err := rl.resolve(ctx, req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err = rl.repo.Remove(songID, originalID, req.Kind)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// resolve returns the ids of the song and of the original in the ctx tenant.
+func (rl *Relations) resolve(ctx context.Context, req *dto.SongRelationRequest) (int, int, error) {
+	if err := rl.authz.Authorize(ctx, entities.PermSongsUpdate); err != nil {
+		return 0, 0, err
+	}
+
+	song, err := rl.songs.Get(tenant.Get(ctx), req.Group, req.Song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, ErrNoRowsAffected
+		}
+		return 0, 0, err
+	}
+
+	original, err := rl.songs.Get(tenant.Get(ctx), req.Original.Group, req.Original.Song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, 0, ErrNoRowsAffected
+		}
+		return 0, 0, err
+	}
+
+	return song.ID, original.ID, nil
+}
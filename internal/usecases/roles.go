@@ -0,0 +1,333 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/auth"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"errors"
+	"fmt"
+	"github.com/fatih/structs"
+	"github.com/lib/pq"
+	"log/slog"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+type RolesRepo interface {
+	Create(name, description string, permissions []string) (*entities.Role, error)
+	Get(name string) (*entities.Role, error)
+	GetList() (*[]entities.Role, error)
+	Update(name string, fields map[string]interface{}) error
+	Delete(name string) error
+	GetSubjectRoles(subject, tenant string) (*[]entities.Role, error)
+	Assign(subject string, roleID int, tenant string) error
+	Unassign(subject string, roleID int, tenant string) error
+}
+
+// Roles grants the permissions of the library operations. Roles are assigned
+// in the tenant of the request, the subjects get the roles of the tenant and
+// the ones assigned in every tenant. Subjects without any get the permissions
+// of the default role.
+type Roles struct {
+	repo        RolesRepo
+	defaultRole string
+	log         *slog.Logger
+}
+
+func NewRoles(repo RolesRepo, defaultRole string, log *slog.Logger) *Roles {
+	return &Roles{
+		repo:        repo,
+		defaultRole: defaultRole,
+		log:         log,
+	}
+}
+
+func (rl *Roles) Authorize(ctx context.Context, permission string) error {
+	const fn = "usecases.Roles.Authorize"
+
+	principal := auth.Get(ctx)
+	if principal == nil {
+		return fmt.Errorf("%s: %w", fn, ErrForbidden)
+	}
+
+	_, permissions, err := rl.subjectRoles(principal.Subject, tenant.Get(ctx))
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if !slices.Contains(permissions, permission) {
+		return fmt.Errorf("%s: %w: %s requires %s", fn, ErrForbidden, principal.Subject, permission)
+	}
+
+	return nil
+}
+
+// Bootstrap makes the subject an admin of every tenant, bypassing the
+// permission checks.
+func (rl *Roles) Bootstrap(subject string) error {
+	const fn = "usecases.Roles.Bootstrap"
+
+	role, err := rl.repo.Get(entities.RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err = rl.repo.Assign(subject, role.ID, ""); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (rl *Roles) Create(ctx context.Context, req *dto.CreateRoleRequest) (*dto.RoleResponse, error) {
+	const fn = "usecases.Roles.Create"
+
+	defer rl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	if err := authorizeUnbound(ctx, rl, entities.PermRolesManage); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	role, err := rl.repo.Create(req.Name, req.Description, req.Permissions)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return nil, fmt.Errorf("%s: %w", fn, ErrAlreadyExists)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewRoleResponse(role), nil
+}
+
+func (rl *Roles) GetList(ctx context.Context) ([]*dto.RoleResponse, error) {
+	const fn = "usecases.Roles.GetList"
+
+	defer rl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("")
+
+	if err := rl.Authorize(ctx, entities.PermRolesManage); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	roles, err := rl.repo.GetList()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewRolesListResponse(roles), nil
+}
+
+func (rl *Roles) Update(ctx context.Context, name string, req *dto.UpdateRoleRequest) error {
+	const fn = "usecases.Roles.Update"
+
+	defer rl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("name", name), slog.Any("request", req))
+
+	if err := authorizeUnbound(ctx, rl, entities.PermRolesManage); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := rl.checkCustom(name); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var fields = make(map[string]interface{})
+	for _, field := range structs.New(req).Fields() {
+		tag := field.Tag("db")
+		val := reflect.ValueOf(field.Value())
+		if tag == "" || val.IsNil() {
+			continue
+		}
+		fields[tag] = val.Elem().Interface()
+	}
+
+	if len(fields) == 0 {
+		return fmt.Errorf("%s: %w", fn, ErrNullFields)
+	}
+
+	if err := rl.repo.Update(name, fields); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (rl *Roles) Delete(ctx context.Context, name string) error {
+	const fn = "usecases.Roles.Delete"
+
+	defer rl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("name", name))
+
+	if err := authorizeUnbound(ctx, rl, entities.PermRolesManage); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := rl.checkCustom(name); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := rl.repo.Delete(name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (rl *Roles) GetSubjectRoles(ctx context.Context, subject string) (*dto.SubjectRolesResponse, error) {
+	const fn = "usecases.Roles.GetSubjectRoles"
+
+	defer rl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("subject", subject))
+
+	if principal := auth.Get(ctx); principal == nil || principal.Subject != subject {
+		if err := rl.Authorize(ctx, entities.PermRolesManage); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	roles, permissions, err := rl.subjectRoles(subject, tenant.Get(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &dto.SubjectRolesResponse{
+		Subject:     subject,
+		Roles:       dto.NewRolesListResponse(roles),
+		Permissions: permissions,
+	}, nil
+}
+
+func (rl *Roles) Assign(ctx context.Context, subject, name string) error {
+	const fn = "usecases.Roles.Assign"
+
+	defer rl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("subject", subject), slog.String("name", name))
+
+	if err := rl.Authorize(ctx, entities.PermRolesManage); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	role, err := rl.repo.Get(name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err = rl.repo.Assign(subject, role.ID, tenant.Get(ctx)); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (rl *Roles) Unassign(ctx context.Context, subject, name string) error {
+	const fn = "usecases.Roles.Unassign"
+
+	defer rl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("subject", subject), slog.String("name", name))
+
+	if err := rl.Authorize(ctx, entities.PermRolesManage); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	role, err := rl.repo.Get(name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err = rl.repo.Unassign(subject, role.ID, tenant.Get(ctx)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (rl *Roles) checkCustom(name string) error {
+	role, err := rl.repo.Get(name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRowsAffected
+		}
+		return err
+	}
+
+	if role.Builtin {
+		return ErrBuiltin
+	}
+
+	return nil
+}
+
+func (rl *Roles) subjectRoles(subject, tenant string) (*[]entities.Role, []string, error) {
+	roles, err := rl.repo.GetSubjectRoles(subject, tenant)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(*roles) == 0 && rl.defaultRole != "" {
+		role, err := rl.repo.Get(rl.defaultRole)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, nil, err
+		}
+
+		if role != nil {
+			*roles = append(*roles, *role)
+		}
+	}
+
+	var permissions []string
+	for _, role := range *roles {
+		for _, permission := range role.Permissions {
+			if !slices.Contains(permissions, permission) {
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	sort.Strings(permissions)
+
+	return roles, permissions, nil
+}
+
+// authorizeUnbound lets only the principals bound to no tenant, holding the
+// permission in every tenant, change what the tenants share.
+func authorizeUnbound(ctx context.Context, authz Authorizer, permission string) error {
+	if principal := auth.Get(ctx); principal == nil || principal.Tenant != "" {
+		return ErrForbidden
+	}
+
+	return authz.Authorize(tenant.With(ctx, ""), permission)
+}
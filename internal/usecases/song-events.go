@@ -0,0 +1,306 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+const (
+	songEventsBuffer      = 64
+	songEventsReplayLimit = 100
+)
+
+type SongEventsSource interface {
+	Listen(ctx context.Context) (<-chan *entities.SongEvent, error)
+}
+
+// songEventsSubscriber keeps the group as its song_name_key.
+type songEventsSubscriber struct {
+	tenant string
+	group  string
+	events chan *dto.SongEventResponse
+}
+
+type SongEvents struct {
+	repo   SongLibraryRepo
+	source SongEventsSource
+	log    *slog.Logger
+
+	mu          sync.Mutex
+	subscribers map[*songEventsSubscriber]struct{}
+	lastBound   int64
+}
+
+func NewSongEvents(repo SongLibraryRepo, source SongEventsSource, log *slog.Logger) *SongEvents {
+	return &SongEvents{
+		repo:        repo,
+		source:      source,
+		log:         log,
+		subscribers: make(map[*songEventsSubscriber]struct{}),
+	}
+}
+
+func (se *SongEvents) Run(ctx context.Context) error {
+	const fn = "usecases.SongEvents.Run"
+
+	log := se.log.With(
+		slog.String("fn", fn),
+	)
+
+	events, err := se.source.Listen(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	defer se.closeSubscribers()
+
+	for event := range events {
+		if event == nil {
+			log.Info("listener reconnected, replaying missed events", slog.Int64("bound", se.lastBound))
+
+			if se.lastBound == 0 {
+				continue
+			}
+
+			// The replayed events the subscribers got before the listener
+			// dropped are sent again.
+			missed, err := se.replay("", "", se.lastBound)
+			if err != nil {
+				log.Error("failed to replay missed events", slog.String("error", err.Error()))
+
+				continue
+			}
+
+			for _, res := range missed {
+				se.publish(res)
+			}
+
+			continue
+		}
+
+		res, err := se.resolve(event)
+		if err != nil {
+			log.Error("failed to resolve event", slog.String("error", err.Error()))
+
+			continue
+		}
+
+		se.publish(res)
+	}
+
+	return nil
+}
+
+// Subscribe streams the events of the group (all groups when empty) of the
+// ctx tenant (all tenants outside of one) until ctx is done, starting with the
+// changes from the bound of the last event received when lastEventID is set.
+// The events follow the commit order rather than the seqs, the ones around the
+// bound may come again. The channel is closed when the subscriber falls too
+// far behind.
+func (se *SongEvents) Subscribe(ctx context.Context, group string, lastEventID int64) (<-chan *dto.SongEventResponse, error) {
+	const fn = "usecases.SongEvents.Subscribe"
+
+	defer se.log.With(
+		slog.String("fn", fn),
+	).Debug("", group, lastEventID)
+
+	var groupKey string
+	if group != "" {
+		var err error
+		groupKey, err = se.repo.GetNameKey(group)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	sub := &songEventsSubscriber{
+		tenant: tenant.Get(ctx),
+		group:  groupKey,
+		events: make(chan *dto.SongEventResponse, songEventsBuffer),
+	}
+
+	se.mu.Lock()
+	se.subscribers[sub] = struct{}{}
+	se.mu.Unlock()
+
+	var missed []*dto.SongEventResponse
+	if lastEventID > 0 {
+		var err error
+		missed, err = se.replay(sub.tenant, sub.group, lastEventID)
+		if err != nil {
+			se.unsubscribe(sub)
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	out := make(chan *dto.SongEventResponse)
+
+	go func() {
+		defer close(out)
+		defer se.unsubscribe(sub)
+
+		// The events committed while replaying come live too.
+		replayed := make(map[int64]struct{}, len(missed))
+		for _, res := range missed {
+			replayed[res.ID] = struct{}{}
+		}
+
+		send := func(res *dto.SongEventResponse) bool {
+			select {
+			case out <- res:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for _, res := range missed {
+			if !send(res) {
+				return
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case res, ok := <-sub.events:
+				if !ok {
+					return
+				}
+
+				if _, ok = replayed[res.ID]; ok {
+					delete(replayed, res.ID)
+					continue
+				}
+
+				if !send(res) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+func (se *SongEvents) resolve(event *entities.SongEvent) (*dto.SongEventResponse, error) {
+	if event.Type == entities.SongEventDeleted {
+		return dto.NewSongEventResponse(event, nil), nil
+	}
+
+	song, err := se.repo.GetByID(event.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return dto.NewSongEventResponse(event, nil), nil
+		}
+		return nil, err
+	}
+
+	return dto.NewSongEventResponse(event, song), nil
+}
+
+// replay returns the changes of the transactions from the bound on as the
+// events. A replayed event is bound by its transaction, or by the oldest one
+// running when the replay started, whichever is older: the changes committing
+// later come live.
+func (se *SongEvents) replay(tenant, group string, bound int64) ([]*dto.SongEventResponse, error) {
+	var missed []*dto.SongEventResponse
+
+	after := entities.ChangePosition{Xid: bound}
+	first := true
+	for {
+		changes, err := se.repo.GetChanges(tenant, after, songEventsReplayLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, change := range *changes {
+			after = entities.ChangePosition{Xid: change.Xid, Seq: change.Seq}
+			if first {
+				bound, first = change.Bound, false
+			}
+
+			if group != "" && change.GroupKey != group {
+				continue
+			}
+
+			event := &entities.SongEvent{
+				Seq:      change.Seq,
+				Bound:    min(change.Xid, bound),
+				Type:     entities.SongEventUpdated,
+				Tenant:   change.TenantID,
+				ID:       change.ID,
+				Group:    change.Group,
+				GroupKey: change.GroupKey,
+				Song:     change.Song.Song,
+			}
+
+			switch {
+			case change.Type == entities.SongChangeDelete:
+				event.Type = entities.SongEventDeleted
+				missed = append(missed, dto.NewSongEventResponse(event, nil))
+				continue
+			case change.CreatedAt.Equal(change.UpdatedAt):
+				event.Type = entities.SongEventCreated
+			}
+
+			missed = append(missed, dto.NewSongEventResponse(event, &change.Song))
+		}
+
+		if len(*changes) < songEventsReplayLimit {
+			return missed, nil
+		}
+	}
+}
+
+func (se *SongEvents) publish(res *dto.SongEventResponse) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if res.Bound > se.lastBound {
+		se.lastBound = res.Bound
+	}
+
+	for sub := range se.subscribers {
+		if (sub.tenant != "" && sub.tenant != res.Tenant) || (sub.group != "" && sub.group != res.GroupKey) {
+			continue
+		}
+
+		select {
+		case sub.events <- res:
+		default:
+			se.log.Warn("dropping slow song events subscriber", slog.String("group", sub.group))
+
+			delete(se.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+func (se *SongEvents) unsubscribe(sub *songEventsSubscriber) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if _, ok := se.subscribers[sub]; ok {
+		delete(se.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+func (se *SongEvents) closeSubscribers() {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	for sub := range se.subscribers {
+		delete(se.subscribers, sub)
+		close(sub.events)
+	}
+}
@@ -1,73 +1,137 @@
 package usecases
 
 import (
+	"context"
 	"database/sql"
 	"effective-mobile-test/internal/entities"
 	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/auth"
 	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"effective-mobile-test/internal/lyrics"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"github.com/fatih/structs"
 	"github.com/lib/pq"
 	"log/slog"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
+const (
+	syncTokenPrefix  = "v2:"
+	defaultSyncLimit = 100
+)
+
 type SongLibraryRepo interface {
-	Create(group, song string) error
-	Get(group, song string) (*entities.Song, error)
-	GetList(filter map[string]interface{}, pagination *pagination.Pagination) (*[]entities.Song, error)
-	Update(group, song string, fields map[string]interface{}) error
-	Delete(group, song string) error
+	Create(tenant, group, song string) error
+	Get(tenant, group, song string) (*entities.Song, error)
+	GetByID(id int) (*entities.Song, error)
+	GetList(tenant string, filter map[string]interface{}, sort string, pagination *pagination.Pagination) (*[]entities.Song, error)
+	GetChanges(tenant string, after entities.ChangePosition, limit int) (*[]entities.SongChange, error)
+	GetSettledChanges(tenant string, after entities.ChangePosition, limit int) (*[]entities.SongChange, error)
+	GetNameKey(name string) (string, error)
+	Update(tenant, group, song string, fields map[string]interface{}) error
+	Delete(tenant, group, song string) error
+}
+
+// SongTagsRepo is the part of TagsRepo the song queries need.
+type SongTagsRepo interface {
+	Expand(tenant string, names []string) (map[string][]int, error)
+	GetBySongs(songIDs []int) (*[]entities.SongTag, error)
+}
+
+// SongCreditsRepo is the part of CreditsRepo the song queries need.
+type SongCreditsRepo interface {
+	GetBySongs(songIDs []int) (*[]entities.SongCredit, error)
+}
+
+// SongRelationsRepo is the part of RelationsRepo the song queries need.
+type SongRelationsRepo interface {
+	GetOriginals(songID int) (*[]entities.SongRelation, error)
+	GetVersions(songID int) (*[]entities.SongRelation, error)
+}
+
+type Authorizer interface {
+	Authorize(ctx context.Context, permission string) error
 }
 
 type SongLibrary struct {
-	repo SongLibraryRepo
-	log  *slog.Logger
+	repo      SongLibraryRepo
+	favorites FavoritesRepo
+	tags      SongTagsRepo
+	credits   SongCreditsRepo
+	relations SongRelationsRepo
+	plays     PlayRecorder
+	authz     Authorizer
+	explicit  *lyrics.Wordlist
+	log       *slog.Logger
 }
 
-func NewSongLibrary(repo SongLibraryRepo, log *slog.Logger) *SongLibrary {
+func NewSongLibrary(repo SongLibraryRepo, favorites FavoritesRepo, tags SongTagsRepo, credits SongCreditsRepo, relations SongRelationsRepo, plays PlayRecorder, authz Authorizer, explicit *lyrics.Wordlist, log *slog.Logger) *SongLibrary {
 	return &SongLibrary{
-		repo: repo,
-		log:  log,
+		repo:      repo,
+		favorites: favorites,
+		tags:      tags,
+		credits:   credits,
+		relations: relations,
+		plays:     plays,
+		authz:     authz,
+		explicit:  explicit,
+		log:       log,
 	}
 }
 
-func (sl *SongLibrary) Create(group, song string) error {
+func (sl *SongLibrary) Create(ctx context.Context, group, song string) error {
 	const fn = "usecases.SongLibrary.Create"
 
 	defer sl.log.With(
 		slog.String("fn", fn),
+		subjectAttr(ctx),
 	).Debug("", group, song)
 
-	err := sl.repo.Create(group, song)
+	if err := sl.authz.Authorize(ctx, entities.PermSongsCreate); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err := sl.repo.Create(tenant.Get(ctx), group, song)
 	if err != nil {
 		var pqErr *pq.Error
 		if errors.As(err, &pqErr) {
 			if pqErr.Code.Name() == "unique_violation" {
-				return fmt.Errorf("%s: %s", fn, ErrAlreadyExists)
+				return fmt.Errorf("%s: %w", fn, ErrAlreadyExists)
 			}
 		}
-		return fmt.Errorf("%s: %s", fn, err)
+		return fmt.Errorf("%s: %w", fn, err)
 	}
 
 	return nil
 }
 
-func (sl *SongLibrary) GetText(group, song string, pagination *pagination.Pagination) (*dto.GetTextResponse, error) {
+// GetText returns a paragraph of the lyrics. In the clean mode the explicit
+// words are masked, and the songs marked explicit by hand are refused since
+// their words aren't known.
+func (sl *SongLibrary) GetText(ctx context.Context, group, song string, clean bool, pagination *pagination.Pagination) (*dto.GetTextResponse, error) {
 	const fn = "usecases.SongLibrary.GetText"
 	var paragraph string
 
 	defer sl.log.With(
 		slog.String("fn", fn),
+		subjectAttr(ctx),
 	).Debug("",
 		group,
 		song,
+		slog.Bool("clean", clean),
 		slog.Any("pagination", pagination),
 	)
 
-	songRes, err := sl.repo.Get(group, song)
+	if err := sl.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	songRes, err := sl.repo.Get(tenant.Get(ctx), group, song)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
@@ -80,10 +144,19 @@ func (sl *SongLibrary) GetText(group, song string, pagination *pagination.Pagina
 		return nil, fmt.Errorf("%s: %w", fn, ErrNullFields)
 	}
 
+	if clean && songRes.ExplicitOverride != nil && *songRes.ExplicitOverride {
+		return nil, fmt.Errorf("%s: %w", fn, ErrExplicit)
+	}
+
 	if pagination.Offset < 0 {
 		pagination.Offset = 0
 	}
 
+	// A read starts from the first verse, the later ones don't count again.
+	if pagination.Offset == 0 {
+		sl.plays.Record(songRes.ID)
+	}
+
 	for key, text := range strings.Split(*songRes.Text, "\n\n") {
 		if key == pagination.Offset {
 			paragraph = text
@@ -91,19 +164,29 @@ func (sl *SongLibrary) GetText(group, song string, pagination *pagination.Pagina
 		}
 	}
 
+	if clean {
+		paragraph = sl.explicit.Mask(paragraph)
+	}
+
 	return &dto.GetTextResponse{
-		Text: paragraph,
+		Text:      paragraph,
+		UpdatedAt: songRes.UpdatedAt,
 	}, nil
 }
 
-func (sl *SongLibrary) Get(group, song string) (*dto.GetSongResponse, error) {
+func (sl *SongLibrary) Get(ctx context.Context, group, song string) (*dto.GetSongResponse, error) {
 	const fn = "usecases.SongLibrary.Get"
 
 	defer sl.log.With(
 		slog.String("fn", fn),
+		subjectAttr(ctx),
 	).Debug("", group, song)
 
-	songRes, err := sl.repo.Get(group, song)
+	if err := sl.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	songRes, err := sl.repo.Get(tenant.Get(ctx), group, song)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
@@ -112,16 +195,52 @@ func (sl *SongLibrary) Get(group, song string) (*dto.GetSongResponse, error) {
 		return nil, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	return dto.NewGetSongResponse(songRes), nil
+	favorited, err := sl.favorited(ctx, []int{songRes.ID})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	tags, err := sl.songTags([]int{songRes.ID})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	credits, err := sl.songCredits([]int{songRes.ID})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	originals, err := sl.relations.GetOriginals(songRes.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	versions, err := sl.relations.GetVersions(songRes.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	res := dto.NewGetSongResponse(songRes)
+	res.Tags = tags[songRes.ID]
+	res.Credits = credits[songRes.ID]
+	res.Originals = dto.NewRelatedSongsResponse(originals)
+	res.Versions = dto.NewRelatedSongsResponse(versions)
+	if favorited != nil {
+		isFavorited := favorited[songRes.ID]
+		res.Favorited = &isFavorited
+	}
+
+	return res, nil
 }
 
-func (sl *SongLibrary) GetList(filter *dto.GetSongsListRequest, pagination *pagination.Pagination) ([]*dto.GetSongsListResponse, error) {
+func (sl *SongLibrary) GetList(ctx context.Context, filter *dto.GetSongsListRequest, pagination *pagination.Pagination) ([]*dto.GetSongsListResponse, error) {
 	const fn = "usecases.SongLibrary.GetList"
 	var filterMap = make(map[string]interface{})
 
 	defer func(filterMap *map[string]interface{}) {
 		sl.log.With(
 			slog.String("fn", fn),
+			subjectAttr(ctx),
 		).Debug("",
 			slog.Any("filter", filter),
 			slog.Any("filterMap", *filterMap),
@@ -129,6 +248,62 @@ func (sl *SongLibrary) GetList(filter *dto.GetSongsListRequest, pagination *pagi
 		)
 	}(&filterMap)
 
+	if err := sl.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	filterMap, err := sl.Filter(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	songs, err := sl.repo.GetList(tenant.Get(ctx), filterMap, filter.Sort, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if len(*songs) == 0 {
+		return nil, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+	}
+
+	ids := make([]int, 0, len(*songs))
+	for _, song := range *songs {
+		ids = append(ids, song.ID)
+	}
+
+	favorited, err := sl.favorited(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	tags, err := sl.songTags(ids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	credits, err := sl.songCredits(ids)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	res := dto.NewGetSongsListResponse(songs)
+	for i, song := range *songs {
+		res[i].Tags = tags[song.ID]
+		res[i].Credits = credits[song.ID]
+		if favorited != nil {
+			isFavorited := favorited[song.ID]
+			res[i].Favorited = &isFavorited
+		}
+	}
+
+	return res, nil
+}
+
+// Filter turns the GetList request into the filter of the repo queries, the
+// tag names are resolved to the ids of the tags and of their subgenres.
+func (sl *SongLibrary) Filter(ctx context.Context, filter *dto.GetSongsListRequest) (map[string]interface{}, error) {
+	var filterMap = make(map[string]interface{})
+
 	s := structs.New(&filter)
 
 	for _, field := range s.Fields() {
@@ -154,25 +329,233 @@ func (sl *SongLibrary) GetList(filter *dto.GetSongsListRequest, pagination *pagi
 		}
 	}
 
-	songs, err := sl.repo.GetList(filterMap, pagination)
+	if len(filter.Tags) > 0 {
+		groups, err := sl.tagGroups(ctx, filter.Tags, filter.TagMatch)
+		if err != nil {
+			return nil, err
+		}
+		filterMap["tags"] = groups
+	}
+
+	for key, credit := range map[string]entities.CreditFilter{
+		"artist":   {Person: filter.Artist, Roles: []string{entities.CreditPrimary, entities.CreditFeatured}},
+		"writer":   {Person: filter.Writer, Roles: []string{entities.CreditWriter}},
+		"composer": {Person: filter.Composer, Roles: []string{entities.CreditComposer}},
+		"producer": {Person: filter.Producer, Roles: []string{entities.CreditProducer}},
+	} {
+		if credit.Person != "" {
+			filterMap[key] = credit
+		}
+	}
+
+	if filter.Clean {
+		filterMap["clean"] = entities.CleanFilter{}
+	}
+
+	if filter.Language != "" {
+		filterMap["language"] = entities.LanguageFilter(strings.Split(strings.ToLower(filter.Language), ","))
+	}
+
+	return filterMap, nil
+}
+
+func (sl *SongLibrary) Sync(ctx context.Context, token string, pagination *pagination.Pagination) (*dto.SyncResponse, error) {
+	const fn = "usecases.SongLibrary.Sync"
+
+	defer sl.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("",
+		token,
+		slog.Any("pagination", pagination),
+	)
+
+	if err := sl.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	after, err := decodeSyncToken(token)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	if len(*songs) == 0 {
-		return nil, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+	limit := defaultSyncLimit
+	if pagination != nil && pagination.Limit > 0 {
+		limit = pagination.Limit
+	}
+
+	changes, err := sl.repo.GetSettledChanges(tenant.Get(ctx), after, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	res := &dto.SyncResponse{
+		Changes: make([]*dto.SongChangeResponse, 0, len(*changes)),
+	}
+
+	if len(*changes) > limit {
+		res.HasMore = true
+		*changes = (*changes)[:limit]
+	}
+
+	for _, change := range *changes {
+		res.Changes = append(res.Changes, dto.NewSongChangeResponse(&change))
+		after = entities.ChangePosition{Xid: change.Xid, Seq: change.Seq}
+	}
+
+	res.NextToken = encodeSyncToken(after)
+
+	return res, nil
+}
+
+// favorited tells which of the songs the user has in the favorites, it's nil
+// for the callers that aren't users.
+func (sl *SongLibrary) favorited(ctx context.Context, ids []int) (map[int]bool, error) {
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, nil
+	}
+
+	favoriteIDs, err := sl.favorites.GetFavorited(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	favorited := make(map[int]bool, len(favoriteIDs))
+	for _, id := range favoriteIDs {
+		favorited[id] = true
+	}
+
+	return favorited, nil
+}
+
+// tagGroups turns the tag names, comma separated or repeated, into the
+// groups of the tag ids a song has to match one of: a group per name for the
+// all match, a single group otherwise. A genre stands for its subgenres too.
+func (sl *SongLibrary) tagGroups(ctx context.Context, tags []string, match string) ([][]int, error) {
+	var names []string
+	for _, tag := range tags {
+		for _, name := range strings.Split(tag, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	expanded, err := sl.tags.Expand(tenant.Get(ctx), names)
+	if err != nil {
+		return nil, err
 	}
 
-	return dto.NewGetSongsListResponse(songs), nil
+	if match == entities.TagMatchAll {
+		groups := make([][]int, 0, len(names))
+		for _, name := range names {
+			if len(expanded[name]) == 0 {
+				return nil, ErrNoRowsAffected
+			}
+			groups = append(groups, expanded[name])
+		}
+		return groups, nil
+	}
+
+	var ids []int
+	for _, name := range names {
+		ids = append(ids, expanded[name]...)
+	}
+
+	if len(ids) == 0 {
+		return nil, ErrNoRowsAffected
+	}
+
+	return [][]int{ids}, nil
+}
+
+// songTags returns the tag names of the songs by their ids.
+func (sl *SongLibrary) songTags(ids []int) (map[int][]string, error) {
+	songTags, err := sl.tags.GetBySongs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[int][]string, len(ids))
+	for _, tag := range *songTags {
+		tags[tag.SongID] = append(tags[tag.SongID], tag.Name)
+	}
+
+	return tags, nil
+}
+
+// songCredits returns the credits of the songs by their ids.
+func (sl *SongLibrary) songCredits(ids []int) (map[int][]*dto.CreditResponse, error) {
+	songCredits, err := sl.credits.GetBySongs(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	credits := make(map[int][]*dto.CreditResponse, len(ids))
+	for _, credit := range *songCredits {
+		credits[credit.SongID] = append(credits[credit.SongID], dto.NewCreditResponse(&credit))
+	}
+
+	return credits, nil
+}
+
+func subjectAttr(ctx context.Context) slog.Attr {
+	if principal := auth.Get(ctx); principal != nil {
+		return slog.String("subject", principal.Subject)
+	}
+	return slog.String("subject", "")
+}
+
+// encodeSyncToken encodes the position as the xid and the seq, the tokens of
+// the v1 seqs alone are invalid.
+func encodeSyncToken(position entities.ChangePosition) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(
+		syncTokenPrefix + strconv.FormatInt(position.Xid, 10) + "." + strconv.FormatInt(position.Seq, 10),
+	))
 }
 
-func (sl *SongLibrary) Update(song *dto.UpdateSongRequest) error {
+func decodeSyncToken(token string) (entities.ChangePosition, error) {
+	if token == "" {
+		return entities.ChangePosition{}, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !strings.HasPrefix(string(raw), syncTokenPrefix) {
+		return entities.ChangePosition{}, ErrInvalidToken
+	}
+
+	xid, seq, ok := strings.Cut(strings.TrimPrefix(string(raw), syncTokenPrefix), ".")
+	if !ok {
+		return entities.ChangePosition{}, ErrInvalidToken
+	}
+
+	var position entities.ChangePosition
+	position.Xid, err = strconv.ParseInt(xid, 10, 64)
+	if err != nil || position.Xid < 0 {
+		return entities.ChangePosition{}, ErrInvalidToken
+	}
+
+	position.Seq, err = strconv.ParseInt(seq, 10, 64)
+	if err != nil || position.Seq < 0 {
+		return entities.ChangePosition{}, ErrInvalidToken
+	}
+
+	return position, nil
+}
+
+func (sl *SongLibrary) Update(ctx context.Context, song *dto.UpdateSongRequest) error {
 	const fn = "usecases.SongLibrary.Update"
 
 	defer sl.log.With(
 		slog.String("fn", fn),
+		subjectAttr(ctx),
 	).Debug("", slog.Any("song", song))
 
+	if err := sl.authz.Authorize(ctx, entities.PermSongsUpdate); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
 	s := structs.New(&song)
 
 	var fields = make(map[string]interface{})
@@ -182,7 +565,10 @@ func (sl *SongLibrary) Update(song *dto.UpdateSongRequest) error {
 		}
 	}
 
-	err := sl.repo.Update(song.Group, song.Song, fields)
+	withLanguage(fields)
+	withExplicit(fields, sl.explicit)
+
+	err := sl.repo.Update(tenant.Get(ctx), song.Group, song.Song, fields)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
@@ -193,14 +579,19 @@ func (sl *SongLibrary) Update(song *dto.UpdateSongRequest) error {
 	return nil
 }
 
-func (sl *SongLibrary) Delete(group, song string) error {
+func (sl *SongLibrary) Delete(ctx context.Context, group, song string) error {
 	const fn = "usecases.SongLibrary.Delete"
 
 	defer sl.log.With(
 		slog.String("fn", fn),
+		subjectAttr(ctx),
 	).Debug("", group, song)
 
-	err := sl.repo.Delete(group, song)
+	if err := sl.authz.Authorize(ctx, entities.PermSongsDelete); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err := sl.repo.Delete(tenant.Get(ctx), group, song)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
@@ -0,0 +1,75 @@
+package usecases
+
+import (
+	"effective-mobile-test/internal/entities"
+	"encoding/base64"
+	"errors"
+	"testing"
+)
+
+func TestSyncToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		position entities.ChangePosition
+	}{
+		{name: "start", position: entities.ChangePosition{}},
+		{name: "xid and seq", position: entities.ChangePosition{Xid: 7401, Seq: 42}},
+		{name: "large xid", position: entities.ChangePosition{Xid: 1<<40 + 3, Seq: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeSyncToken(encodeSyncToken(tt.position))
+			if err != nil {
+				t.Fatalf("decodeSyncToken() error = %v", err)
+			}
+
+			if got != tt.position {
+				t.Errorf("decodeSyncToken(encodeSyncToken(%v)) = %v", tt.position, got)
+			}
+		})
+	}
+}
+
+func TestDecodeSyncToken(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    entities.ChangePosition
+		wantErr bool
+	}{
+		{name: "empty for a full sync", token: ""},
+		{name: "valid", token: encode("v2:10.3"), want: entities.ChangePosition{Xid: 10, Seq: 3}},
+		{name: "seq alone of v1", token: encode("v1:42"), wantErr: true},
+		{name: "no prefix", token: encode("10.3"), wantErr: true},
+		{name: "no seq", token: encode("v2:10"), wantErr: true},
+		{name: "negative xid", token: encode("v2:-1.3"), wantErr: true},
+		{name: "negative seq", token: encode("v2:10.-3"), wantErr: true},
+		{name: "not a number", token: encode("v2:x.3"), wantErr: true},
+		{name: "not base64", token: "v2:10.3!", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeSyncToken(tt.token)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("decodeSyncToken(%q) error = %v, want ErrInvalidToken", tt.token, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("decodeSyncToken(%q) error = %v", tt.token, err)
+			}
+
+			if got != tt.want {
+				t.Errorf("decodeSyncToken(%q) = %v, want %v", tt.token, got, tt.want)
+			}
+		})
+	}
+}
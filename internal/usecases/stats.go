@@ -0,0 +1,123 @@
+package usecases
+
+import (
+	"context"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type StatsRepo interface {
+	GetStats(tenant string, filter map[string]interface{}) (*entities.LibraryStats, error)
+}
+
+// SongFilter builds the repo filter of the song list requests.
+type SongFilter interface {
+	Filter(ctx context.Context, filter *dto.GetSongsListRequest) (map[string]interface{}, error)
+}
+
+type statsEntry struct {
+	stats   *dto.StatsResponse
+	expires time.Time
+}
+
+// Stats caches the aggregates per tenant and filter for ttl, a zero ttl turns
+// the cache off.
+type Stats struct {
+	repo   StatsRepo
+	filter SongFilter
+	authz  Authorizer
+	ttl    time.Duration
+	log    *slog.Logger
+
+	mu    sync.Mutex
+	cache map[string]statsEntry
+}
+
+func NewStats(repo StatsRepo, filter SongFilter, authz Authorizer, ttl time.Duration, log *slog.Logger) *Stats {
+	return &Stats{
+		repo:   repo,
+		filter: filter,
+		authz:  authz,
+		ttl:    ttl,
+		log:    log,
+		cache:  make(map[string]statsEntry),
+	}
+}
+
+func (st *Stats) Get(ctx context.Context, filter *dto.GetSongsListRequest) (*dto.StatsResponse, error) {
+	const fn = "usecases.Stats.Get"
+	var cached bool
+
+	defer func(cached *bool) {
+		st.log.With(
+			slog.String("fn", fn),
+			subjectAttr(ctx),
+		).Debug("", slog.Any("filter", filter), slog.Bool("cached", *cached))
+	}(&cached)
+
+	if err := st.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	// The order doesn't change the aggregates.
+	keyFilter := *filter
+	keyFilter.Sort = ""
+	rawKey, err := json.Marshal(keyFilter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	key := tenant.Get(ctx) + "\x00" + string(rawKey)
+
+	now := time.Now()
+
+	st.mu.Lock()
+	entry, ok := st.cache[key]
+	st.mu.Unlock()
+
+	if ok && now.Before(entry.expires) {
+		cached = true
+		return entry.stats, nil
+	}
+
+	filterMap, err := st.filter.Filter(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	stats, err := st.repo.GetStats(tenant.Get(ctx), filterMap)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	res := dto.NewStatsResponse(stats, now)
+
+	if st.ttl > 0 {
+		st.store(key, res, now)
+	}
+
+	return res, nil
+}
+
+// store caches the stats and drops the expired ones, so that the filters
+// asked for once don't pile up.
+func (st *Stats) store(key string, stats *dto.StatsResponse, now time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	for k, entry := range st.cache {
+		if !now.Before(entry.expires) {
+			delete(st.cache, k)
+		}
+	}
+
+	st.cache[key] = statsEntry{
+		stats:   stats,
+		expires: now.Add(st.ttl),
+	}
+}
@@ -0,0 +1,332 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+type TagsRepo interface {
+	SongTagsRepo
+	Create(tag *entities.Tag) (*entities.Tag, error)
+	Get(tenant string, id int) (*entities.Tag, error)
+	GetList(tenant, kind string, pagination *pagination.Pagination) (*[]entities.Tag, error)
+	GetByNames(tenant string, names []string) (*[]entities.Tag, error)
+	Update(tenant string, id int, fields map[string]interface{}) error
+	Delete(tenant string, id int) error
+	AddSongTags(songID int, tagIDs []int) error
+	RemoveSongTags(songID int, tagIDs []int) error
+	GetCloud(tenant, kind string) (*[]entities.TagCount, error)
+}
+
+type Tags struct {
+	repo  TagsRepo
+	songs SongLibraryRepo
+	authz Authorizer
+	log   *slog.Logger
+}
+
+func NewTags(repo TagsRepo, songs SongLibraryRepo, authz Authorizer, log *slog.Logger) *Tags {
+	return &Tags{
+		repo:  repo,
+		songs: songs,
+		authz: authz,
+		log:   log,
+	}
+}
+
+func (tg *Tags) Create(ctx context.Context, req *dto.CreateTagRequest) (*dto.TagResponse, error) {
+	const fn = "usecases.Tags.Create"
+
+	defer tg.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	if err := tg.authz.Authorize(ctx, entities.PermTagsManage); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	tag := &entities.Tag{
+		TenantID: tenant.Get(ctx),
+		Name:     req.Name,
+		Kind:     req.Kind,
+		ParentID: req.ParentID,
+	}
+
+	if tag.Kind == "" {
+		tag.Kind = entities.TagTag
+	}
+
+	if tag.ParentID != nil {
+		if err := tg.checkParent(ctx, tag, *tag.ParentID); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	tag, err := tg.repo.Create(tag)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return nil, fmt.Errorf("%s: %w", fn, ErrAlreadyExists)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewTagResponse(tag), nil
+}
+
+func (tg *Tags) GetList(ctx context.Context, kind string, pagination *pagination.Pagination) ([]*dto.TagResponse, error) {
+	const fn = "usecases.Tags.GetList"
+
+	defer tg.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("kind", kind), slog.Any("pagination", pagination))
+
+	if err := tg.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	tags, err := tg.repo.GetList(tenant.Get(ctx), kind, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewTagsListResponse(tags), nil
+}
+
+func (tg *Tags) GetCloud(ctx context.Context, kind string) ([]*dto.TagCountResponse, error) {
+	const fn = "usecases.Tags.GetCloud"
+
+	defer tg.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("kind", kind))
+
+	if err := tg.authz.Authorize(ctx, entities.PermSongsRead); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	tags, err := tg.repo.GetCloud(tenant.Get(ctx), kind)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewTagCloudResponse(tags), nil
+}
+
+// Update renames the tag or moves it in the hierarchy, a zero parentId makes
+// it a top-level one.
+func (tg *Tags) Update(ctx context.Context, id int, req *dto.UpdateTagRequest) error {
+	const fn = "usecases.Tags.Update"
+
+	defer tg.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("id", id), slog.Any("request", req))
+
+	if err := tg.authz.Authorize(ctx, entities.PermTagsManage); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var fields = make(map[string]interface{})
+	if req.Name != nil {
+		fields["name"] = *req.Name
+	}
+
+	if req.ParentID != nil {
+		tag, err := tg.repo.Get(tenant.Get(ctx), id)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+			}
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+
+		if *req.ParentID == 0 {
+			fields["parent_id"] = nil
+		} else if err = tg.checkParent(ctx, tag, *req.ParentID); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		} else {
+			fields["parent_id"] = *req.ParentID
+		}
+	}
+
+	if len(fields) == 0 {
+		return fmt.Errorf("%s: %w", fn, ErrNullFields)
+	}
+
+	err := tg.repo.Update(tenant.Get(ctx), id, fields)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return fmt.Errorf("%s: %w", fn, ErrAlreadyExists)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// Delete removes the tag from the songs. Genres that still have subgenres are
+// kept and reported with ErrInUse.
+func (tg *Tags) Delete(ctx context.Context, id int) error {
+	const fn = "usecases.Tags.Delete"
+
+	defer tg.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Int("id", id))
+
+	if err := tg.authz.Authorize(ctx, entities.PermTagsManage); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err := tg.repo.Delete(tenant.Get(ctx), id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation" {
+			return fmt.Errorf("%s: %w", fn, ErrInUse)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (tg *Tags) TagSong(ctx context.Context, req *dto.SongTagsRequest) error {
+	const fn = "usecases.Tags.TagSong"
+
+	defer tg.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	songID, tagIDs, err := tg.resolve(ctx, req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err = tg.repo.AddSongTags(songID, tagIDs); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (tg *Tags) UntagSong(ctx context.Context, req *dto.SongTagsRequest) error {
+	const fn = "usecases.Tags.UntagSong"
+
+	defer tg.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	songID, tagIDs, err := tg.resolve(ctx, req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	err = tg.repo.RemoveSongTags(songID, tagIDs)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// resolve returns the id of the song and of the tags in the ctx tenant, the
+// unknown tags are reported with ErrNoRowsAffected.
+func (tg *Tags) resolve(ctx context.Context, req *dto.SongTagsRequest) (int, []int, error) {
+	if err := tg.authz.Authorize(ctx, entities.PermSongsUpdate); err != nil {
+		return 0, nil, err
+	}
+
+	song, err := tg.songs.Get(tenant.Get(ctx), req.Group, req.Song)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, ErrNoRowsAffected
+		}
+		return 0, nil, err
+	}
+
+	tags, err := tg.repo.GetByNames(tenant.Get(ctx), req.Tags)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ids := make([]int, 0, len(*tags))
+	found := make([]string, 0, len(*tags))
+	for _, tag := range *tags {
+		ids = append(ids, tag.ID)
+		found = append(found, tag.Name)
+	}
+
+	var unknown []string
+	for _, name := range req.Tags {
+		if !slices.Contains(found, name) {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return 0, nil, fmt.Errorf("%w: unknown tags %s", ErrNoRowsAffected, strings.Join(unknown, ", "))
+	}
+
+	return song.ID, ids, nil
+}
+
+// checkParent makes sure that only genres get a parent, that the parent is a
+// genre of the tenant and that it isn't the tag itself or one of its
+// subgenres.
+func (tg *Tags) checkParent(ctx context.Context, tag *entities.Tag, parentID int) error {
+	if tag.Kind != entities.TagGenre {
+		return ErrInvalidParent
+	}
+
+	parent, err := tg.repo.Get(tenant.Get(ctx), parentID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInvalidParent
+		}
+		return err
+	}
+
+	if parent.Kind != entities.TagGenre {
+		return ErrInvalidParent
+	}
+
+	if tag.ID == 0 {
+		return nil
+	}
+
+	subtree, err := tg.repo.Expand(tenant.Get(ctx), []string{tag.Name})
+	if err != nil {
+		return err
+	}
+
+	if slices.Contains(subtree[tag.Name], parentID) {
+		return ErrInvalidParent
+	}
+
+	return nil
+}
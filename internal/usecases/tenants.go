@@ -0,0 +1,141 @@
+package usecases
+
+import (
+	"context"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"log/slog"
+)
+
+type TenantsRepo interface {
+	Create(id, name string) (*entities.Tenant, error)
+	Get(id string) (*entities.Tenant, error)
+	GetList() (*[]entities.Tenant, error)
+	Delete(id string) error
+}
+
+type Tenants struct {
+	repo  TenantsRepo
+	authz Authorizer
+	log   *slog.Logger
+}
+
+func NewTenants(repo TenantsRepo, authz Authorizer, log *slog.Logger) *Tenants {
+	return &Tenants{
+		repo:  repo,
+		authz: authz,
+		log:   log,
+	}
+}
+
+func (tn *Tenants) Create(ctx context.Context, req *dto.CreateTenantRequest) (*dto.TenantResponse, error) {
+	const fn = "usecases.Tenants.Create"
+
+	defer tn.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.Any("request", req))
+
+	if err := authorizeUnbound(ctx, tn.authz, entities.PermTenantsManage); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	tenant, err := tn.repo.Create(req.ID, req.Name)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return nil, fmt.Errorf("%s: %w", fn, ErrAlreadyExists)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewTenantResponse(tenant), nil
+}
+
+func (tn *Tenants) GetList(ctx context.Context) ([]*dto.TenantResponse, error) {
+	const fn = "usecases.Tenants.GetList"
+
+	defer tn.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("")
+
+	if err := authorizeUnbound(ctx, tn.authz, entities.PermTenantsManage); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	tenants, err := tn.repo.GetList()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewTenantsListResponse(tenants), nil
+}
+
+// Delete removes the tenant. Tenants still owning songs, webhooks or API keys
+// are kept and reported with ErrInUse.
+func (tn *Tenants) Delete(ctx context.Context, id string) error {
+	const fn = "usecases.Tenants.Delete"
+
+	defer tn.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("", slog.String("id", id))
+
+	if err := authorizeUnbound(ctx, tn.authz, entities.PermTenantsManage); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if id == entities.DefaultTenant {
+		return fmt.Errorf("%s: %w", fn, ErrBuiltin)
+	}
+
+	err := tn.repo.Delete(id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "foreign_key_violation" {
+			return fmt.Errorf("%s: %w", fn, ErrInUse)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// CanSwitch tells whether the principal of ctx may scope its requests to any
+// tenant with X-Tenant-ID, which takes managing the tenants.
+func (tn *Tenants) CanSwitch(ctx context.Context) (bool, error) {
+	const fn = "usecases.Tenants.CanSwitch"
+
+	err := authorizeUnbound(ctx, tn.authz, entities.PermTenantsManage)
+	if err != nil {
+		if errors.Is(err, ErrForbidden) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return true, nil
+}
+
+// Exists tells whether requests may be scoped to the tenant.
+func (tn *Tenants) Exists(id string) (bool, error) {
+	const fn = "usecases.Tenants.Exists"
+
+	_, err := tn.repo.Get(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,106 @@
+package usecases
+
+import (
+	"crypto"
+	"effective-mobile-test/internal/entities"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+type KeySource interface {
+	Key(kid string) (crypto.PublicKey, error)
+}
+
+type TokensOptions struct {
+	Issuer      string
+	Audience    string
+	Leeway      time.Duration
+	ScopesClaim string
+	TenantClaim string
+}
+
+type Tokens struct {
+	keys KeySource
+	opts TokensOptions
+	log  *slog.Logger
+}
+
+func NewTokens(keys KeySource, opts TokensOptions, log *slog.Logger) *Tokens {
+	return &Tokens{
+		keys: keys,
+		opts: opts,
+		log:  log,
+	}
+}
+
+// Authenticate validates an RS256 or ES256 bearer token against the key set
+// and returns its subject, scopes, tenant and claims.
+func (t *Tokens) Authenticate(token string) (*entities.Principal, error) {
+	const fn = "usecases.Tokens.Authenticate"
+
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(t.opts.Leeway),
+	}
+
+	if t.opts.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(t.opts.Issuer))
+	}
+
+	if t.opts.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(t.opts.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return t.keys.Key(kid)
+	}, parserOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", fn, ErrUnauthorized, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%s: %w: missing subject", fn, ErrUnauthorized)
+	}
+
+	issuer, _ := claims.GetIssuer()
+
+	tenantClaim, _ := claims[t.opts.TenantClaim].(string)
+
+	return &entities.Principal{
+		Subject: jwtSubject(issuer, subject),
+		Tenant:  tenantClaim,
+		Scopes:  scopes(claims[t.opts.ScopesClaim]),
+		Claims:  claims,
+	}, nil
+}
+
+// jwtSubject keeps the subjects of the issuers apart from each other and from
+// the subjects of the API keys and the users.
+func jwtSubject(issuer, subject string) string {
+	return "jwt:" + issuer + ":" + subject
+}
+
+// scopes accepts both the space-delimited "scope" string of RFC 8693 and
+// the array form used by some issuers.
+func scopes(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		var res []string
+		for _, scope := range v {
+			if s, ok := scope.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/auth"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+type UsersRepo interface {
+	Create(tenant, email, name, passwordHash string) (*entities.User, error)
+	Get(id int) (*entities.User, error)
+	GetByEmail(tenant, email string) (*entities.User, error)
+	CreateSession(userID int, tokenHash string, expiresAt time.Time) (*entities.Session, error)
+	GetSession(tokenHash string) (*entities.Session, error)
+	DeleteSession(id int) error
+	DeleteExpiredSessions() (int64, error)
+}
+
+// Users registers the listeners and signs them in with session tokens. The
+// sessions get both song scopes, what they may actually do is up to their
+// roles.
+type Users struct {
+	repo       UsersRepo
+	sessionTTL time.Duration
+	log        *slog.Logger
+
+	// dummyHash is compared against on unknown emails, so that the response
+	// time doesn't tell whether the user exists.
+	dummyHash []byte
+}
+
+func NewUsers(repo UsersRepo, sessionTTL time.Duration, log *slog.Logger) *Users {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	dummyHash, _ := bcrypt.GenerateFromPassword(buf, bcrypt.DefaultCost)
+
+	return &Users{
+		repo:       repo,
+		sessionTTL: sessionTTL,
+		log:        log,
+		dummyHash:  dummyHash,
+	}
+}
+
+func (us *Users) Register(ctx context.Context, req *dto.RegisterUserRequest) (*dto.UserResponse, error) {
+	const fn = "usecases.Users.Register"
+
+	defer us.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.String("email", req.Email))
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	user, err := us.repo.Create(tenant.Get(ctx), req.Email, req.Name, string(hash))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Name() == "unique_violation" {
+			return nil, fmt.Errorf("%s: %w", fn, ErrAlreadyExists)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewUserResponse(user), nil
+}
+
+func (us *Users) Login(ctx context.Context, req *dto.CreateSessionRequest) (*dto.SessionResponse, error) {
+	const fn = "usecases.Users.Login"
+
+	defer us.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.String("email", req.Email))
+
+	user, err := us.repo.GetByEmail(tenant.Get(ctx), req.Email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			_ = bcrypt.CompareHashAndPassword(us.dummyHash, []byte(req.Password))
+			return nil, fmt.Errorf("%s: %w", fn, ErrUnauthorized)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, ErrUnauthorized)
+	}
+
+	buf := make([]byte, 32)
+	if _, err = rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	token := entities.SessionTokenPrefix + hex.EncodeToString(buf)
+
+	session, err := us.repo.CreateSession(user.ID, hashToken(token), time.Now().Add(us.sessionTTL))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &dto.SessionResponse{
+		Token:     token,
+		ExpiresAt: session.ExpiresAt,
+	}, nil
+}
+
+// Logout ends the session the request is authenticated with.
+func (us *Users) Logout(ctx context.Context) error {
+	const fn = "usecases.Users.Logout"
+
+	defer us.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("")
+
+	principal := auth.Get(ctx)
+	if principal == nil || principal.SessionID == 0 {
+		return fmt.Errorf("%s: %w", fn, ErrForbidden)
+	}
+
+	err := us.repo.DeleteSession(principal.SessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (us *Users) Me(ctx context.Context) (*dto.UserResponse, error) {
+	const fn = "usecases.Users.Me"
+
+	defer us.log.With(
+		slog.String("fn", fn),
+		subjectAttr(ctx),
+	).Debug("")
+
+	userID, err := currentUser(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	user, err := us.repo.Get(userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewUserResponse(user), nil
+}
+
+// Authenticate resolves the session token to its user, bound to the tenant
+// the user registered with.
+func (us *Users) Authenticate(token string) (*entities.Principal, error) {
+	const fn = "usecases.Users.Authenticate"
+
+	session, err := us.repo.GetSession(hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", fn, ErrUnauthorized)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	user, err := us.repo.Get(session.UserID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", fn, ErrUnauthorized)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &entities.Principal{
+		Subject:   userSubject(user.ID),
+		UserID:    user.ID,
+		SessionID: session.ID,
+		Tenant:    user.TenantID,
+		Scopes:    []string{entities.ScopeSongsRead, entities.ScopeSongsWrite},
+	}, nil
+}
+
+func (us *Users) RunCleanup(ctx context.Context, interval time.Duration) {
+	const fn = "usecases.Users.RunCleanup"
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := us.repo.DeleteExpiredSessions(); err != nil {
+				us.log.Error("failed to delete expired sessions",
+					slog.String("fn", fn),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+// currentUser returns the user the request is signed in as.
+func currentUser(ctx context.Context) (int, error) {
+	principal := auth.Get(ctx)
+	if principal == nil || principal.UserID == 0 {
+		return 0, ErrForbidden
+	}
+	return principal.UserID, nil
+}
+
+func userSubject(id int) string {
+	return "user:" + strconv.Itoa(id)
+}
@@ -0,0 +1,441 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"effective-mobile-test/internal/entities"
+	"effective-mobile-test/internal/entities/dto"
+	"effective-mobile-test/internal/http/middlewares/pagination"
+	"effective-mobile-test/internal/http/middlewares/tenant"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/fatih/structs"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	webhookClaimLimit = 10
+	webhookMaxBackoff = time.Hour
+)
+
+type WebhooksRepo interface {
+	Create(tenant, url string, eventTypes []string, secret string) (*entities.Webhook, error)
+	Get(id int) (*entities.Webhook, error)
+	GetList(tenant string, pagination *pagination.Pagination) (*[]entities.Webhook, error)
+	GetByEventType(tenant, eventType string) (*[]entities.Webhook, error)
+	Update(tenant string, id int, fields map[string]interface{}) error
+	Delete(tenant string, id int) error
+	CreateDelivery(delivery *entities.WebhookDelivery) (*entities.WebhookDelivery, error)
+	ClaimDeliveries(limit int, lease time.Duration) (*[]entities.WebhookDelivery, error)
+	GetLastEventBound() (int64, error)
+	UpdateDelivery(id int, fields map[string]interface{}) error
+	GetDelivery(webhookID, id int) (*entities.WebhookDelivery, error)
+	GetDeliveries(webhookID int, pagination *pagination.Pagination) (*[]entities.WebhookDelivery, error)
+}
+
+type WebhookSender interface {
+	Send(ctx context.Context, url, secret string, deliveryID int, eventType string, payload []byte) (int, error)
+}
+
+type WebhooksOptions struct {
+	Timeout      time.Duration
+	MaxAttempts  int
+	Backoff      time.Duration
+	PollInterval time.Duration
+	// AllowInsecure accepts the http URLs, for local development.
+	AllowInsecure bool
+}
+
+type Webhooks struct {
+	repo   WebhooksRepo
+	events *SongEvents
+	sender WebhookSender
+	opts   WebhooksOptions
+	log    *slog.Logger
+}
+
+func NewWebhooks(repo WebhooksRepo, events *SongEvents, sender WebhookSender, opts WebhooksOptions, log *slog.Logger) *Webhooks {
+	return &Webhooks{
+		repo:   repo,
+		events: events,
+		sender: sender,
+		opts:   opts,
+		log:    log,
+	}
+}
+
+func (wh *Webhooks) Create(ctx context.Context, req *dto.CreateWebhookRequest) (*dto.CreateWebhookResponse, error) {
+	const fn = "usecases.Webhooks.Create"
+
+	defer wh.log.With(
+		slog.String("fn", fn),
+	).Debug("", req.URL, slog.Any("eventTypes", req.EventTypes))
+
+	if err := wh.checkURL(req.URL); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		secret = hex.EncodeToString(buf)
+	}
+
+	webhook, err := wh.repo.Create(tenant.Get(ctx), req.URL, req.EventTypes, secret)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return &dto.CreateWebhookResponse{
+		WebhookResponse: *dto.NewWebhookResponse(webhook),
+		Secret:          webhook.Secret,
+	}, nil
+}
+
+func (wh *Webhooks) Get(ctx context.Context, id int) (*dto.WebhookResponse, error) {
+	const fn = "usecases.Webhooks.Get"
+
+	defer wh.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.Int("id", id))
+
+	webhook, err := wh.get(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewWebhookResponse(webhook), nil
+}
+
+// get returns the webhook of the ctx tenant, webhooks of the other tenants
+// are reported as missing.
+func (wh *Webhooks) get(ctx context.Context, id int) (*entities.Webhook, error) {
+	webhook, err := wh.repo.Get(id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNoRowsAffected
+		}
+		return nil, err
+	}
+
+	if webhook.TenantID != tenant.Get(ctx) {
+		return nil, ErrNoRowsAffected
+	}
+
+	return webhook, nil
+}
+
+func (wh *Webhooks) GetList(ctx context.Context, pagination *pagination.Pagination) ([]*dto.WebhookResponse, error) {
+	const fn = "usecases.Webhooks.GetList"
+
+	defer wh.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.Any("pagination", pagination))
+
+	webhooks, err := wh.repo.GetList(tenant.Get(ctx), pagination)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if len(*webhooks) == 0 {
+		return nil, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+	}
+
+	return dto.NewWebhooksListResponse(webhooks), nil
+}
+
+func (wh *Webhooks) Update(ctx context.Context, id int, req *dto.UpdateWebhookRequest) error {
+	const fn = "usecases.Webhooks.Update"
+
+	defer wh.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.Int("id", id), slog.Any("request", req))
+
+	var fields = make(map[string]interface{})
+	for _, field := range structs.New(req).Fields() {
+		tag := field.Tag("db")
+		val := reflect.ValueOf(field.Value())
+		if tag == "" || val.IsNil() {
+			continue
+		}
+		fields[tag] = val.Elem().Interface()
+	}
+
+	if len(fields) == 0 {
+		return fmt.Errorf("%s: %w", fn, ErrNullFields)
+	}
+
+	if req.URL != nil {
+		if err := wh.checkURL(*req.URL); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	err := wh.repo.Update(tenant.Get(ctx), id, fields)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (wh *Webhooks) Delete(ctx context.Context, id int) error {
+	const fn = "usecases.Webhooks.Delete"
+
+	defer wh.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.Int("id", id))
+
+	err := wh.repo.Delete(tenant.Get(ctx), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func (wh *Webhooks) GetDeliveries(ctx context.Context, id int, pagination *pagination.Pagination) ([]*dto.WebhookDeliveryResponse, error) {
+	const fn = "usecases.Webhooks.GetDeliveries"
+
+	defer wh.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.Int("id", id), slog.Any("pagination", pagination))
+
+	if _, err := wh.get(ctx, id); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	deliveries, err := wh.repo.GetDeliveries(id, pagination)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewWebhookDeliveriesListResponse(deliveries), nil
+}
+
+func (wh *Webhooks) Redeliver(ctx context.Context, id, deliveryID int) (*dto.WebhookDeliveryResponse, error) {
+	const fn = "usecases.Webhooks.Redeliver"
+
+	defer wh.log.With(
+		slog.String("fn", fn),
+	).Debug("", slog.Int("id", id), slog.Int("deliveryId", deliveryID))
+
+	if _, err := wh.get(ctx, id); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	delivery, err := wh.repo.GetDelivery(id, deliveryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", fn, ErrNoRowsAffected)
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	original := delivery.ID
+	if delivery.RedeliveryOf != nil {
+		original = *delivery.RedeliveryOf
+	}
+
+	redelivery, err := wh.repo.CreateDelivery(&entities.WebhookDelivery{
+		WebhookID:    delivery.WebhookID,
+		EventID:      delivery.EventID,
+		EventBound:   delivery.EventBound,
+		EventType:    delivery.EventType,
+		Payload:      delivery.Payload,
+		RedeliveryOf: &original,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return dto.NewWebhookDeliveryResponse(redelivery), nil
+}
+
+// Run queues a delivery for every song event matching a webhook and sends
+// the due deliveries until ctx is done.
+func (wh *Webhooks) Run(ctx context.Context) {
+	go wh.enqueue(ctx)
+
+	ticker := time.NewTicker(wh.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			wh.dispatch(ctx)
+		}
+	}
+}
+
+// checkURL refuses the URLs other than https ones unless AllowInsecure is
+// set, the sender refuses the private addresses on its own.
+func (wh *Webhooks) checkURL(url string) error {
+	if !wh.opts.AllowInsecure && !strings.HasPrefix(strings.ToLower(url), "https://") {
+		return fmt.Errorf("%w: %s", ErrInsecureURL, url)
+	}
+	return nil
+}
+
+func (wh *Webhooks) enqueue(ctx context.Context) {
+	const fn = "usecases.Webhooks.enqueue"
+
+	log := wh.log.With(
+		slog.String("fn", fn),
+	)
+
+	// The events from the newest bound queued are queued again after a
+	// restart, the deliveries of the same event to the same webhook are
+	// dropped as duplicates.
+	lastBound, err := wh.repo.GetLastEventBound()
+	if err != nil {
+		log.Error("failed to get the last queued event, queueing the new ones only", slog.String("error", err.Error()))
+	}
+
+	for ctx.Err() == nil {
+		events, err := wh.events.Subscribe(ctx, "", lastBound)
+		if err != nil {
+			log.Error("failed to subscribe to song events", slog.String("error", err.Error()))
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(wh.opts.PollInterval):
+			}
+
+			continue
+		}
+
+		for event := range events {
+			lastBound = event.Bound
+
+			if err = wh.queue(event); err != nil {
+				log.Error("failed to queue deliveries", slog.Int64("event", event.ID), slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+func (wh *Webhooks) queue(event *dto.SongEventResponse) error {
+	webhooks, err := wh.repo.GetByEventType(event.Tenant, event.Type)
+	if err != nil {
+		return err
+	}
+
+	if len(*webhooks) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(&dto.WebhookPayload{
+		ID:        event.ID,
+		Type:      event.Type,
+		CreatedAt: time.Now().UTC(),
+		Data:      event,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range *webhooks {
+		_, err = wh.repo.CreateDelivery(&entities.WebhookDelivery{
+			WebhookID:  webhook.ID,
+			EventID:    event.ID,
+			EventBound: event.Bound,
+			EventType:  event.Type,
+			Payload:    string(payload),
+		})
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (wh *Webhooks) dispatch(ctx context.Context) {
+	const fn = "usecases.Webhooks.dispatch"
+
+	log := wh.log.With(
+		slog.String("fn", fn),
+	)
+
+	// The deliveries are sent one after another, the lease covers them all
+	// with a timeout to spare for the bookkeeping.
+	lease := time.Duration(webhookClaimLimit+1) * wh.opts.Timeout
+
+	deliveries, err := wh.repo.ClaimDeliveries(webhookClaimLimit, lease)
+	if err != nil {
+		log.Error("failed to claim deliveries", slog.String("error", err.Error()))
+
+		return
+	}
+
+	for _, delivery := range *deliveries {
+		fields := wh.deliver(ctx, &delivery)
+
+		if err = wh.repo.UpdateDelivery(delivery.ID, fields); err != nil {
+			log.Error("failed to update delivery", slog.Int("delivery", delivery.ID), slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (wh *Webhooks) deliver(ctx context.Context, delivery *entities.WebhookDelivery) map[string]interface{} {
+	attempts := delivery.Attempts + 1
+	fields := map[string]interface{}{
+		"attempts": attempts,
+	}
+
+	webhook, err := wh.repo.Get(delivery.WebhookID)
+	if err == nil && !webhook.Active {
+		err = errors.New("webhook is inactive")
+	}
+
+	var status int
+	if err == nil {
+		status, err = wh.sender.Send(ctx, webhook.URL, webhook.Secret, delivery.ID, delivery.EventType, []byte(delivery.Payload))
+	}
+
+	if status != 0 {
+		fields["response_status"] = status
+	}
+
+	if err == nil {
+		fields["status"] = entities.DeliverySucceeded
+		fields["error"] = nil
+		fields["delivered_at"] = time.Now()
+
+		return fields
+	}
+
+	fields["error"] = err.Error()
+
+	if attempts >= wh.opts.MaxAttempts || (webhook != nil && !webhook.Active) {
+		fields["status"] = entities.DeliveryFailed
+
+		return fields
+	}
+
+	backoff := wh.opts.Backoff << (attempts - 1)
+	if backoff <= 0 || backoff > webhookMaxBackoff {
+		backoff = webhookMaxBackoff
+	}
+	fields["next_attempt_at"] = time.Now().Add(backoff)
+
+	return fields
+}
@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+
+	signaturePrefix = "sha256="
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrInsecureURL      = errors.New("insecure url")
+	ErrPrivateAddress   = errors.New("private address")
+)
+
+// sharedAddressSpace is the carrier-grade NAT range, netip doesn't count it as
+// private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// Sign returns the signature of the body sent at timestamp: the hex-encoded
+// HMAC-SHA256 of "<timestamp>.<body>" keyed by the webhook secret.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature headers of a received delivery, rejecting
+// deliveries signed more than tolerance ago.
+func Verify(secret string, header http.Header, body []byte, tolerance time.Duration) error {
+	timestamp, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 && time.Since(time.Unix(timestamp, 0)).Abs() > tolerance {
+		return ErrInvalidSignature
+	}
+
+	signature := header.Get(HeaderSignature)
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+type Sender struct {
+	client   *http.Client
+	insecure bool
+}
+
+// NewSender returns a sender posting to the https URLs resolving to public
+// addresses only, unless insecure is set for local development. The redirects
+// aren't followed, they are reported as the unexpected statuses.
+func NewSender(timeout time.Duration, insecure bool) *Sender {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+	}
+	if !insecure {
+		dialer.Control = publicOnly
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be the only address checked.
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Sender{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		insecure: insecure,
+	}
+}
+
+// checkURL refuses the URLs other than https ones, unless insecure is set.
+func checkURL(rawURL string, insecure bool) error {
+	if insecure {
+		return nil
+	}
+
+	if !strings.HasPrefix(strings.ToLower(rawURL), "https://") {
+		return ErrInsecureURL
+	}
+
+	return nil
+}
+
+// publicOnly refuses to connect to the loopback, private, link-local and other
+// non-public addresses, checked once the host is resolved.
+func publicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || sharedAddressSpace.Contains(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, ip)
+	}
+
+	return nil
+}
+
+// Send posts the signed payload and returns the response status code.
+// Any status outside of 2xx is reported as an error.
+func (s *Sender) Send(ctx context.Context, url, secret string, deliveryID int, eventType string, payload []byte) (int, error) {
+	const fn = "webhook.Sender.Send"
+
+	if err := checkURL(url, s.insecure); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "rest-api-example-webhooks/1.0")
+	req.Header.Set(HeaderDelivery, strconv.Itoa(deliveryID))
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, payload))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer res.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("%s: unexpected status %d", fn, res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}